@@ -40,7 +40,7 @@ func (m *MockContainerEngine) EXPECT() *MockContainerEngineMockRecorder {
 }
 
 // Build mocks base method.
-func (m *MockContainerEngine) Build(arg0, arg1, arg2 string, arg3 map[string]string) error {
+func (m *MockContainerEngine) Build(arg0, arg1, arg2 string, arg3 containerengine.BuildOptions) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Build", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(error)
@@ -141,6 +141,21 @@ func (mr *MockContainerEngineMockRecorder) ListImages(arg0, arg1 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListImages", reflect.TypeOf((*MockContainerEngine)(nil).ListImages), arg0, arg1)
 }
 
+// Logs mocks base method.
+func (m *MockContainerEngine) Logs(arg0 string, arg1 types.ContainerLogsOptions) (io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logs", arg0, arg1)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Logs indicates an expected call of Logs.
+func (mr *MockContainerEngineMockRecorder) Logs(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logs", reflect.TypeOf((*MockContainerEngine)(nil).Logs), arg0, arg1)
+}
+
 // NetworkCreate mocks base method.
 func (m *MockContainerEngine) NetworkCreate(arg0 string) error {
 	m.ctrl.T.Helper()
@@ -169,18 +184,46 @@ func (mr *MockContainerEngineMockRecorder) Pull(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pull", reflect.TypeOf((*MockContainerEngine)(nil).Pull), arg0)
 }
 
+// Push mocks base method.
+func (m *MockContainerEngine) Push(arg0 string, arg1 *containerengine.RegistryAuth) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Push", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Push indicates an expected call of Push.
+func (mr *MockContainerEngineMockRecorder) Push(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Push", reflect.TypeOf((*MockContainerEngine)(nil).Push), arg0, arg1)
+}
+
 // RemoveByLabel mocks base method.
-func (m *MockContainerEngine) RemoveByLabel(arg0, arg1 string) error {
+func (m *MockContainerEngine) RemoveByLabel(arg0 map[string]string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RemoveByLabel", arg0, arg1)
+	ret := m.ctrl.Call(m, "RemoveByLabel", arg0)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // RemoveByLabel indicates an expected call of RemoveByLabel.
-func (mr *MockContainerEngineMockRecorder) RemoveByLabel(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockContainerEngineMockRecorder) RemoveByLabel(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveByLabel", reflect.TypeOf((*MockContainerEngine)(nil).RemoveByLabel), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveByLabel", reflect.TypeOf((*MockContainerEngine)(nil).RemoveByLabel), arg0)
+}
+
+// RemoveContainer mocks base method.
+func (m *MockContainerEngine) RemoveContainer(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveContainer", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveContainer indicates an expected call of RemoveContainer.
+func (mr *MockContainerEngineMockRecorder) RemoveContainer(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveContainer", reflect.TypeOf((*MockContainerEngine)(nil).RemoveContainer), arg0)
 }
 
 // Start mocks base method.
@@ -210,3 +253,17 @@ func (mr *MockContainerEngineMockRecorder) Stop(arg0, arg1 interface{}) *gomock.
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockContainerEngine)(nil).Stop), arg0, arg1)
 }
+
+// Tag mocks base method.
+func (m *MockContainerEngine) Tag(arg0, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Tag", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Tag indicates an expected call of Tag.
+func (mr *MockContainerEngineMockRecorder) Tag(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tag", reflect.TypeOf((*MockContainerEngine)(nil).Tag), arg0, arg1)
+}
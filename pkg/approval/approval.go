@@ -0,0 +1,207 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package approval
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// Request is posted to the approval webhook, text is a summary for chat webhooks (e.g. Slack) that
+// only display a message
+type Request struct {
+	ID         string   `json:"id"`
+	Stack      string   `json:"stack"`
+	Target     string   `json:"target"`
+	Deployment string   `json:"deployment"`
+	Commit     string   `json:"commit,omitempty"`
+	Resources  []string `json:"resources"`
+	Text       string   `json:"text"`
+}
+
+// Decision is returned by the status url of an approval request
+type Decision struct {
+	Status   string `json:"status"`
+	Approver string `json:"approver,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	// Token proves the approval, it is required for approved requests and must be the Sign of the request id
+	Token string `json:"token,omitempty"`
+}
+
+type Options struct {
+	// Webhook the request is posted to
+	Webhook string
+	// StatusURL is polled with ?id=<request id> for the decision
+	StatusURL string
+	// Secret shared with the approval service, the tokens of approvals are signed with it
+	Secret   string
+	Interval time.Duration
+	Timeout  time.Duration
+	Out      io.Writer
+}
+
+type approver struct {
+	opts Options
+	http *http.Client
+}
+
+// NewRequest creates a request with a random ID
+func NewRequest(stack, target, deployment, commit string, resources []string) (*Request, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	r := &Request{
+		ID:         hex.EncodeToString(id),
+		Stack:      stack,
+		Target:     target,
+		Deployment: deployment,
+		Commit:     commit,
+		Resources:  resources,
+	}
+	r.Text = fmt.Sprintf("Approval requested (%s): deploy %s of stack %s to %s", r.ID, deployment, stack, target)
+	if commit != "" {
+		r.Text += " from commit " + commit
+	}
+	if len(resources) > 0 {
+		r.Text += "\n• " + strings.Join(resources, "\n• ")
+	}
+	return r, nil
+}
+
+// Sign returns the token of an approved request, the hex HMAC-SHA256 of the request id keyed by the secret
+func Sign(secret, id string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(id))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Ask posts the request to the webhook and waits for it to be approved, an error is returned
+// when it is rejected, approved without a valid token or not decided before the timeout
+func Ask(opts Options, r *Request) (*Decision, error) {
+	if opts.Webhook == "" || opts.StatusURL == "" || opts.Secret == "" {
+		return nil, errors.New("approvals need a webhook, a status url and a secret")
+	}
+	if opts.Interval == 0 {
+		opts.Interval = 5 * time.Second
+	}
+	a := &approver{opts: opts, http: &http.Client{Timeout: 10 * time.Second}}
+
+	if err := a.post(r); err != nil {
+		return nil, err
+	}
+	if opts.Out != nil {
+		fmt.Fprintf(opts.Out, "Waiting for approval of request %s\n", r.ID)
+	}
+
+	deadline := time.Now().Add(opts.Timeout)
+	for {
+		d, err := a.status(r.ID)
+		if err != nil {
+			return nil, err
+		}
+		switch d.Status {
+		case StatusApproved:
+			if d.Token == "" {
+				return nil, fmt.Errorf("request %s was approved without a token", r.ID)
+			}
+			if !hmac.Equal([]byte(d.Token), []byte(Sign(opts.Secret, r.ID))) {
+				return nil, fmt.Errorf("request %s was approved with an invalid token", r.ID)
+			}
+			return d, nil
+		case StatusRejected:
+			msg := fmt.Sprintf("request %s was rejected", r.ID)
+			if d.Approver != "" {
+				msg += " by " + d.Approver
+			}
+			if d.Reason != "" {
+				msg += ": " + d.Reason
+			}
+			return nil, errors.New(msg)
+		case StatusPending, "":
+		default:
+			return nil, fmt.Errorf("unknown status %s of request %s", d.Status, r.ID)
+		}
+
+		if opts.Timeout > 0 && time.Now().After(deadline) {
+			return nil, fmt.Errorf("request %s was not approved within %v", r.ID, opts.Timeout)
+		}
+		time.Sleep(opts.Interval)
+	}
+}
+
+func (a *approver) post(r *Request) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	resp, err := a.http.Post(a.opts.Webhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.WithMessage(err, "posting the approval request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("posting the approval request: webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+func (a *approver) status(id string) (*Decision, error) {
+	u, err := url.Parse(a.opts.StatusURL)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("id", id)
+	u.RawQuery = q.Encode()
+
+	resp, err := a.http.Get(u.String())
+	if err != nil {
+		return nil, errors.WithMessage(err, "checking the approval status")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		// the approval service may not have seen the request yet
+		return &Decision{Status: StatusPending}, nil
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("checking the approval status: status url returned %s", resp.Status)
+	}
+	d := &Decision{}
+	if err := json.NewDecoder(resp.Body).Decode(d); err != nil {
+		return nil, errors.WithMessage(err, "checking the approval status")
+	}
+	return d, nil
+}
@@ -0,0 +1,121 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package approval
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAsk(t *testing.T) {
+	tests := []struct {
+		name      string
+		decisions []Decision
+		wantErr   string
+	}{
+		{
+			name:      "approved after pending",
+			decisions: []Decision{{Status: StatusPending}, {Status: StatusApproved, Approver: "jane", Token: "valid"}},
+		},
+		{
+			name:      "approved with an invalid token",
+			decisions: []Decision{{Status: StatusApproved, Approver: "jane", Token: "abc"}},
+			wantErr:   "invalid token",
+		},
+		{
+			name:      "rejected",
+			decisions: []Decision{{Status: StatusRejected, Approver: "jane", Reason: "not on a friday"}},
+			wantErr:   "rejected by jane: not on a friday",
+		},
+		{
+			name:      "approved without a token",
+			decisions: []Decision{{Status: StatusApproved}},
+			wantErr:   "without a token",
+		},
+		{
+			name:      "timeout",
+			decisions: []Decision{{Status: StatusPending}},
+			wantErr:   "not approved within",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var posted *Request
+			polls := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/webhook":
+					posted = &Request{}
+					if err := json.NewDecoder(r.Body).Decode(posted); err != nil {
+						t.Error(err)
+					}
+				case "/status":
+					if posted == nil || r.URL.Query().Get("id") != posted.ID {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					d := tt.decisions[len(tt.decisions)-1]
+					if polls < len(tt.decisions) {
+						d = tt.decisions[polls]
+					}
+					if d.Token == "valid" {
+						d.Token = Sign("s3cret", posted.ID)
+					}
+					polls++
+					_ = json.NewEncoder(w).Encode(d)
+				}
+			}))
+			defer srv.Close()
+
+			req, err := NewRequest("demo", "prod", "main", "1234abcd", []string{"function:api"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			d, err := Ask(Options{
+				Webhook:   srv.URL + "/webhook",
+				StatusURL: srv.URL + "/status",
+				Secret:    "s3cret",
+				Interval:  time.Millisecond,
+				Timeout:   20 * time.Millisecond,
+			}, req)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Ask() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Token != Sign("s3cret", posted.ID) || posted.Stack != "demo" || !strings.Contains(posted.Text, "function:api") {
+				t.Errorf("Ask() = %+v, posted %+v", d, posted)
+			}
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n 1234 | openssl dgst -sha256 -hmac s3cret
+	want := "8d1632fe7c1882fd2425b39bc862017f40d28fa39d892582ccaaae44d09f926b"
+	if got := Sign("s3cret", "1234"); got != want {
+		t.Errorf("Sign() = %s, want %s", got, want)
+	}
+}
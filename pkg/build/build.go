@@ -21,11 +21,18 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
 
 	"github.com/nitrictech/newcli/pkg/containerengine"
 	"github.com/nitrictech/newcli/pkg/functiondockerfile"
 	"github.com/nitrictech/newcli/pkg/stack"
 	"github.com/nitrictech/newcli/pkg/target"
+	"github.com/nitrictech/newcli/pkg/utils"
 )
 
 func Create(s *stack.Stack, t *target.Target) error {
@@ -33,46 +40,200 @@ func Create(s *stack.Stack, t *target.Target) error {
 	if err != nil {
 		return err
 	}
+
+	err = createBases(cr, s, t)
+	if err != nil {
+		return err
+	}
+
+	jobs := []buildJob{}
 	for _, f := range s.Functions {
-		for _, script := range f.BuildScripts {
-			cmd := exec.Command(script)
-			cmd.Dir = path.Join(s.Path(), f.Context)
-			err := cmd.Run()
-			if err != nil {
-				return err
-			}
-		}
+		f := f
+		jobs = append(jobs, buildJob{
+			name:  "function " + f.Name(),
+			build: func() error { return createFunction(cr, s, t, &f) },
+		})
+	}
+
+	for _, c := range s.Containers {
+		c := c
+		jobs = append(jobs, buildJob{
+			name: "container " + c.Name(),
+			build: func() error {
+				opts, err := buildOptions(s, t, &c.ComputeUnit, c.Name())
+				if err != nil {
+					return err
+				}
+				return cr.Build(path.Join(c.ContextDirectory(), c.Dockerfile), c.ContextDirectory(), c.ImageTagName(s, t.Provider), opts)
+			},
+		})
+	}
+	return runJobs(jobs, buildParallelism())
+}
+
+func createFunction(cr containerengine.ContainerEngine, s *stack.Stack, t *target.Target, f *stack.Function) error {
+	opts, err := buildOptions(s, t, &f.ComputeUnit, f.Name())
+	if err != nil {
+		return err
+	}
 
-		fh, err := os.CreateTemp("", "Dockerfile.*")
+	for _, script := range f.BuildScripts {
+		cmd := exec.Command(script)
+		cmd.Dir = path.Join(s.Path(), f.Context)
+		err := cmd.Run()
 		if err != nil {
 			return err
 		}
+	}
 
-		defer func() {
-			fh.Close()
-			os.Remove(fh.Name())
-		}()
+	fh, err := os.CreateTemp("", "Dockerfile.*")
+	if err != nil {
+		return err
+	}
 
-		err = functiondockerfile.Generate(&f, f.VersionString(s), t.Provider, fh)
-		if err != nil {
-			return err
+	defer func() {
+		fh.Close()
+		os.Remove(fh.Name())
+	}()
+
+	err = functiondockerfile.Generate(f, f.VersionString(s), t.Provider, fh)
+	if err != nil {
+		return err
+	}
+	return cr.Build(fh.Name(), f.ContextDirectory(), f.ImageTagName(s, t.Provider), opts)
+}
+
+// buildOptions merges the build args and labels of the compute unit with the ones nitric sets,
+// labelling every image with the stack, compute unit and git metadata
+func buildOptions(s *stack.Stack, t *target.Target, cu *stack.ComputeUnit, name string) (containerengine.BuildOptions, error) {
+	platform, err := cu.Platform()
+	if err != nil {
+		return containerengine.BuildOptions{}, err
+	}
+
+	buildArgs := map[string]string{}
+	for k, v := range cu.BuildArgs {
+		buildArgs[k] = v
+	}
+	buildArgs["PROVIDER"] = t.Provider
+	if buildArgs["PROVIDER"] == "local" {
+		buildArgs["PROVIDER"] = "dev"
+	}
+
+	labels := map[string]string{
+		"org.opencontainers.image.title": name,
+		"io.nitric.stack":                s.Name,
+		"io.nitric.provider":             t.Provider,
+	}
+	if git, err := utils.GitMetadata(s.Path()); err == nil && viper.GetBool("git_metadata") {
+		labels["org.opencontainers.image.revision"] = git.Commit
+		if git.RemoteURL != "" {
+			labels["org.opencontainers.image.source"] = git.RemoteURL
 		}
-		buildArgs := map[string]string{"PROVIDER": t.Provider}
-		if buildArgs["PROVIDER"] == "local" {
-			buildArgs["PROVIDER"] = "dev"
+		for k, v := range git.Labels("io.nitric.git.") {
+			labels[k] = v
 		}
-		err = cr.Build(fh.Name(), f.ContextDirectory(), f.ImageTagName(s, t.Provider), buildArgs)
+	}
+	for k, v := range cu.Labels {
+		labels[k] = v
+	}
+
+	return containerengine.BuildOptions{
+		BuildArgs: buildArgs,
+		Platform:  platform,
+		Labels:    labels,
+		CacheFrom: buildCaches("build_cache_from", name),
+		CacheTo:   buildCaches("build_cache_to", name),
+	}, nil
+}
+
+// buildCaches returns the configured BuildKit caches for the image,
+// {image} is replaced with the image's name so each image can have its own cache
+func buildCaches(key, name string) []string {
+	caches := []string{}
+	for _, c := range viper.GetStringSlice(key) {
+		caches = append(caches, strings.ReplaceAll(c, "{image}", name))
+	}
+	return caches
+}
+
+func buildParallelism() int {
+	n := viper.GetInt("build_parallelism")
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// buildJob is a single image build, named after the function or container it is for
+type buildJob struct {
+	name  string
+	build func() error
+}
+
+// runJobs runs up to parallelism jobs at once. All jobs are run even when some fail,
+// the returned error names every job that failed.
+func runJobs(jobs []buildJob, parallelism int) error {
+	queue := make(chan buildJob)
+	failures := []string{}
+	lock := sync.Mutex{}
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				if err := j.build(); err != nil {
+					lock.Lock()
+					failures = append(failures, fmt.Sprintf("%s: %v", j.name, err))
+					lock.Unlock()
+					continue
+				}
+				if parallelism > 1 {
+					fmt.Printf("built %s\n", j.name)
+				}
+			}
+		}()
+	}
+
+	for _, j := range jobs {
+		queue <- j
+	}
+	close(queue)
+	wg.Wait()
+
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return fmt.Errorf("%d of %d builds failed:\n%s", len(failures), len(jobs), strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
+// createBases builds the dependency layers shared by functions of the same runtime, context and membrane version once,
+// so that the function builds that follow extend the cached layers instead of installing the same dependencies again.
+// The function dockerfiles repeat the base steps rather than starting from the base image, as builders that pull
+// (or that run in their own container, for caches) can't use a local image, so the base is built untagged.
+func createBases(cr containerengine.ContainerEngine, s *stack.Stack, t *target.Target) error {
+	groups := map[string][]stack.Function{}
+	for _, f := range s.Functions {
+		if !functiondockerfile.HasBase(&f) {
+			continue
+		}
+		rt, err := utils.NewRunTimeFromFilename(f.Handler)
 		if err != nil {
 			return err
 		}
+		key := strings.Join([]string{rt.String(), f.ContextDirectory(), f.VersionString(s), f.Architecture}, ":")
+		groups[key] = append(groups[key], f)
 	}
 
-	for _, c := range s.Containers {
-		buildArgs := map[string]string{"PROVIDER": t.Provider}
-		if buildArgs["PROVIDER"] == "local" {
-			buildArgs["PROVIDER"] = "dev"
+	for _, fns := range groups {
+		if len(fns) < 2 {
+			// nothing to share
+			continue
 		}
-		err := cr.Build(path.Join(c.ContextDirectory(), c.Dockerfile), c.ContextDirectory(), c.ImageTagName(s, t.Provider), buildArgs)
+		err := createBase(cr, s, t, &fns[0])
 		if err != nil {
 			return err
 		}
@@ -80,8 +241,31 @@ func Create(s *stack.Stack, t *target.Target) error {
 	return nil
 }
 
-// CreateBaseDev builds images for code-as-config
-func CreateBaseDev(stackPath string, imagesToBuild map[string]string) error {
+func createBase(cr containerengine.ContainerEngine, s *stack.Stack, t *target.Target, f *stack.Function) error {
+	opts, err := buildOptions(s, t, &f.ComputeUnit, f.Name())
+	if err != nil {
+		return err
+	}
+
+	fh, err := os.CreateTemp("", "Dockerfile.*")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		fh.Close()
+		os.Remove(fh.Name())
+	}()
+
+	err = functiondockerfile.GenerateBase(f, f.VersionString(s), t.Provider, fh)
+	if err != nil {
+		return err
+	}
+	return cr.Build(fh.Name(), f.ContextDirectory(), "", opts)
+}
+
+// CreateBaseDev builds images for code-as-config, baseImages are the overrides of the stack's base images and may be nil
+func CreateBaseDev(stackPath string, imagesToBuild map[string]string, baseImages map[string]string) error {
 	ce, err := containerengine.Discover()
 	if err != nil {
 		return err
@@ -98,11 +282,11 @@ func CreateBaseDev(stackPath string, imagesToBuild map[string]string) error {
 			os.Remove(f.Name())
 		}()
 
-		if err := functiondockerfile.GenerateForCodeAsConfig("handler."+lang, f); err != nil {
+		if err := functiondockerfile.GenerateForCodeAsConfig("handler."+lang, baseImages, f); err != nil {
 			return err
 		}
 
-		if err := ce.Build(f.Name(), stackPath, imageTag, map[string]string{}); err != nil {
+		if err := ce.Build(f.Name(), stackPath, imageTag, containerengine.BuildOptions{}); err != nil {
 			return err
 		}
 	}
@@ -134,3 +318,111 @@ func List(s *stack.Stack) ([]containerengine.Image, error) {
 	}
 	return images, nil
 }
+
+// Push pushes the images of the stack to the registry (e.g. 123456789012.dkr.ecr.us-east-1.amazonaws.com/shop),
+// tagged with the git commit of the stack (see pushVersion) or latest outside of git. Images with a custom tag are pushed as they are.
+// ECR, GCR and ACR credentials are fetched with the provider's cli, see containerengine.RegistryLogin.
+// With attest a SLSA provenance attestation is pushed alongside each image, see attestProvenance.
+func Push(s *stack.Stack, t *target.Target, registry string, attest bool) ([]string, error) {
+	cr, err := containerengine.Discover()
+	if err != nil {
+		return nil, err
+	}
+
+	git, gitErr := utils.GitMetadata(s.Path())
+	version := "latest"
+	if gitErr == nil {
+		version = pushVersion(git)
+	}
+	// checked before anything is pushed, so no image is left without its provenance
+	if attest {
+		if err := checkProvenanceSource(git, gitErr); err != nil {
+			return nil, err
+		}
+	}
+
+	jobs := []pushJob{}
+	for _, f := range s.Functions {
+		f := f
+		image := f.ImageTagName(s, t.Provider)
+		jobs = append(jobs, pushJob{image: image, ref: imageRef(registry, f.Tag, image, version), name: f.Name(), cu: &f.ComputeUnit, entryPoint: f.Handler})
+	}
+	for _, c := range s.Containers {
+		c := c
+		image := c.ImageTagName(s, t.Provider)
+		jobs = append(jobs, pushJob{image: image, ref: imageRef(registry, c.Tag, image, version), name: c.Name(), cu: &c.ComputeUnit, entryPoint: c.Dockerfile})
+	}
+	for _, j := range jobs {
+		if j.ref == "" {
+			return nil, fmt.Errorf("image %s: set a registry to push to with --registry or the build_registry config", j.image)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].image < jobs[j].image })
+
+	// log in to each registry once
+	auths := map[string]*containerengine.RegistryAuth{}
+	pushed := []string{}
+	for _, j := range jobs {
+		host := containerengine.RegistryHost(j.ref)
+		auth, ok := auths[host]
+		if !ok {
+			auth, err = containerengine.RegistryLogin(host)
+			if err != nil {
+				return pushed, err
+			}
+			auths[host] = auth
+		}
+		if j.ref != j.image {
+			if err := cr.Tag(j.image, j.ref); err != nil {
+				return pushed, err
+			}
+		}
+		if err := cr.Push(j.ref, auth); err != nil {
+			return pushed, errors.WithMessage(err, j.ref)
+		}
+		if attest {
+			p, err := newProvenance(s, t, git, j.cu, j.name, j.entryPoint)
+			if err != nil {
+				return pushed, err
+			}
+			if err := attestProvenance(j.ref, p, auth); err != nil {
+				return pushed, errors.WithMessage(err, j.ref)
+			}
+		}
+		pushed = append(pushed, j.ref)
+	}
+	return pushed, nil
+}
+
+// pushVersion returns the tag of the images built from the commit, suffixed with -dirty when there are
+// uncommitted changes so they can't be mistaken for the images of the commit itself
+func pushVersion(git *utils.GitInfo) string {
+	version := git.Commit
+	if len(version) > 12 {
+		version = version[0:12]
+	}
+	if git.Dirty {
+		version += "-dirty"
+	}
+	return version
+}
+
+// pushJob is an image to push, with the compute unit it was built from for its provenance
+type pushJob struct {
+	image      string
+	ref        string
+	name       string
+	cu         *stack.ComputeUnit
+	entryPoint string
+}
+
+// imageRef returns the reference an image is pushed as, custom tags are used as they are
+func imageRef(registry, customTag, image, version string) string {
+	if customTag != "" {
+		return customTag
+	}
+	if registry == "" {
+		return ""
+	}
+	return strings.TrimSuffix(registry, "/") + "/" + image + ":" + version
+}
@@ -17,22 +17,131 @@
 package build
 
 import (
+	"errors"
+	"sync"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 
 	mock_containerengine "github.com/nitrictech/newcli/mocks/containerengine"
 	"github.com/nitrictech/newcli/pkg/containerengine"
+	"github.com/nitrictech/newcli/pkg/utils"
 )
 
 func TestCreateBaseDev(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	me := mock_containerengine.NewMockContainerEngine(ctrl)
-	me.EXPECT().Build(gomock.Any(), "path/to/stack", "nitric-ts-dev", map[string]string{})
+	me.EXPECT().Build(gomock.Any(), "path/to/stack", "nitric-ts-dev", containerengine.BuildOptions{})
 
 	containerengine.MockEngine = me
 
-	if err := CreateBaseDev("path/to/stack", map[string]string{"ts": "nitric-ts-dev"}); err != nil {
+	if err := CreateBaseDev("path/to/stack", map[string]string{"ts": "nitric-ts-dev"}, nil); err != nil {
 		t.Errorf("CreateBaseDev() error = %v", err)
 	}
 }
+
+func Test_runJobs(t *testing.T) {
+	tests := []struct {
+		name        string
+		parallelism int
+		failing     []string
+		wantErr     string
+	}{
+		{
+			name:        "sequential",
+			parallelism: 1,
+		},
+		{
+			name:        "parallel",
+			parallelism: 3,
+		},
+		{
+			name:        "failures are aggregated",
+			parallelism: 2,
+			failing:     []string{"function b", "function d"},
+			wantErr:     "2 of 4 builds failed:\nfunction b: boom\nfunction d: boom",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			built := map[string]bool{}
+			lock := sync.Mutex{}
+			jobs := []buildJob{}
+			for _, name := range []string{"function a", "function b", "function c", "function d"} {
+				name := name
+				jobs = append(jobs, buildJob{name: name, build: func() error {
+					lock.Lock()
+					built[name] = true
+					lock.Unlock()
+					for _, f := range tt.failing {
+						if f == name {
+							return errors.New("boom")
+						}
+					}
+					return nil
+				}})
+			}
+
+			err := runJobs(jobs, tt.parallelism)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("runJobs() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("runJobs() error = %v, want %s", err, tt.wantErr)
+			}
+			if len(built) != len(jobs) {
+				t.Errorf("runJobs() built %d jobs, want %d", len(built), len(jobs))
+			}
+		})
+	}
+}
+
+func Test_pushVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		git  utils.GitInfo
+		want string
+	}{
+		{name: "clean", git: utils.GitInfo{Commit: "1a2b3c4d5e6f7a8b9c0d"}, want: "1a2b3c4d5e6f"},
+		{name: "dirty", git: utils.GitInfo{Commit: "1a2b3c4d5e6f7a8b9c0d", Dirty: true}, want: "1a2b3c4d5e6f-dirty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pushVersion(&tt.git); got != tt.want {
+				t.Errorf("pushVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_imageRef(t *testing.T) {
+	tests := []struct {
+		name      string
+		registry  string
+		customTag string
+		want      string
+	}{
+		{
+			name:     "registry",
+			registry: "123456789012.dkr.ecr.us-east-1.amazonaws.com/shop/",
+			want:     "123456789012.dkr.ecr.us-east-1.amazonaws.com/shop/shop-orders-aws:1a2b3c",
+		},
+		{
+			name:      "custom tag",
+			registry:  "gcr.io/project",
+			customTag: "ghcr.io/org/orders:v1",
+			want:      "ghcr.io/org/orders:v1",
+		},
+		{
+			name: "no registry",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := imageRef(tt.registry, tt.customTag, "shop-orders-aws", "1a2b3c"); got != tt.want {
+				t.Errorf("imageRef() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"github.com/nitrictech/newcli/pkg/containerengine"
+	"github.com/nitrictech/newcli/pkg/stack"
+	"github.com/nitrictech/newcli/pkg/target"
+	"github.com/nitrictech/newcli/pkg/utils"
+)
+
+// provenanceBuildType identifies how nitric builds images, the parameters are those of containerengine.BuildOptions
+const provenanceBuildType = "https://nitric.io/build/image@v1"
+
+// provenance is a SLSA v0.2 provenance predicate, see https://slsa.dev/provenance/v0.2
+type provenance struct {
+	Builder    provenanceBuilder    `json:"builder"`
+	BuildType  string               `json:"buildType"`
+	Invocation provenanceInvocation `json:"invocation"`
+	Metadata   provenanceMetadata   `json:"metadata"`
+	Materials  []provenanceMaterial `json:"materials"`
+}
+
+type provenanceBuilder struct {
+	ID string `json:"id"`
+}
+
+type provenanceInvocation struct {
+	ConfigSource provenanceMaterial     `json:"configSource"`
+	Parameters   map[string]interface{} `json:"parameters"`
+}
+
+type provenanceMetadata struct {
+	BuildInvocationID string                 `json:"buildInvocationId,omitempty"`
+	Completeness      provenanceCompleteness `json:"completeness"`
+	Reproducible      bool                   `json:"reproducible"`
+}
+
+type provenanceCompleteness struct {
+	Parameters  bool `json:"parameters"`
+	Environment bool `json:"environment"`
+	Materials   bool `json:"materials"`
+}
+
+type provenanceMaterial struct {
+	URI        string            `json:"uri"`
+	Digest     map[string]string `json:"digest"`
+	EntryPoint string            `json:"entryPoint,omitempty"`
+}
+
+// checkProvenanceSource returns an error unless the stack is committed to a git repository with a remote,
+// so the provenance references the exact source that was built. err is that of utils.GitMetadata.
+func checkProvenanceSource(git *utils.GitInfo, err error) error {
+	if err != nil {
+		return errors.WithMessage(err, "provenance needs the stack to be in a git repository")
+	}
+	if git.Dirty {
+		return errors.New("provenance can't reference uncommitted changes, commit them first")
+	}
+	if git.RemoteURL == "" {
+		return errors.New("provenance needs the git repository to have an origin remote to reference the source")
+	}
+	return nil
+}
+
+// newProvenance describes the build of a compute unit's image from the git source, see checkProvenanceSource
+func newProvenance(s *stack.Stack, t *target.Target, git *utils.GitInfo, cu *stack.ComputeUnit, name, entryPoint string) (*provenance, error) {
+	opts, err := buildOptions(s, t, cu, name)
+	if err != nil {
+		return nil, err
+	}
+	builder, invocationID := ciBuild()
+	return provenanceFor(git, builder, invocationID, entryPoint, opts), nil
+}
+
+func provenanceFor(git *utils.GitInfo, builder, invocationID, entryPoint string, opts containerengine.BuildOptions) *provenance {
+	source := provenanceMaterial{
+		URI:    "git+" + git.RemoteURL,
+		Digest: map[string]string{"sha1": git.Commit},
+	}
+	if git.Branch != "" {
+		source.URI += "@refs/heads/" + git.Branch
+	}
+	configSource := source
+	configSource.EntryPoint = entryPoint
+
+	params := map[string]interface{}{
+		"buildArgs": opts.BuildArgs,
+	}
+	if opts.Platform != "" {
+		params["platform"] = opts.Platform
+	}
+
+	return &provenance{
+		Builder:   provenanceBuilder{ID: builder},
+		BuildType: provenanceBuildType,
+		Invocation: provenanceInvocation{
+			ConfigSource: configSource,
+			Parameters:   params,
+		},
+		Metadata: provenanceMetadata{
+			BuildInvocationID: invocationID,
+			// every build parameter is recorded, but not the base images or host environment
+			Completeness: provenanceCompleteness{Parameters: true},
+		},
+		Materials: []provenanceMaterial{source},
+	}
+}
+
+// ciBuild identifies the CI run that built the image, or the host for local builds
+func ciBuild() (builder, invocationID string) {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		return fmt.Sprintf("%s/%s/actions/runs/%s", os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID")), os.Getenv("GITHUB_RUN_ID")
+	case os.Getenv("GITLAB_CI") == "true":
+		return os.Getenv("CI_JOB_URL"), os.Getenv("CI_JOB_ID")
+	}
+	host, _ := os.Hostname()
+	return "nitric-cli://" + host, ""
+}
+
+// attestProvenance signs the provenance with cosign and pushes it to the registry alongside the image.
+// cosign signs keyless (e.g. with the CI's OIDC identity) unless the build_provenance_key config names a key.
+func attestProvenance(ref string, p *provenance, auth *containerengine.RegistryAuth) error {
+	dir, err := os.MkdirTemp("", "nitric-provenance-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	predicate := filepath.Join(dir, "provenance.json")
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(predicate, b, 0o600); err != nil {
+		return err
+	}
+
+	args := []string{"attest", "--yes", "--type", "slsaprovenance", "--predicate", predicate}
+	if key := viper.GetString("build_provenance_key"); key != "" {
+		args = append(args, "--key", key)
+	}
+	args = append(args, ref)
+
+	out := &strings.Builder{}
+	cmd := exec.Command("cosign", args...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	others := []containerengine.RegistryAuth{}
+	if auth != nil {
+		others = append(others, *auth)
+	}
+	cleanup, err := containerengine.WithRegistryAuth(cmd, others...)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if err := cmd.Run(); err != nil {
+		return errors.WithMessagef(err, "cosign attest: %s", strings.TrimSpace(out.String()))
+	}
+	return nil
+}
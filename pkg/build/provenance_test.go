@@ -0,0 +1,89 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nitrictech/newcli/pkg/containerengine"
+	"github.com/nitrictech/newcli/pkg/utils"
+)
+
+func Test_checkProvenanceSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		git     *utils.GitInfo
+		err     error
+		wantErr string
+	}{
+		{name: "committed", git: &utils.GitInfo{Commit: "1a2b3c", RemoteURL: "https://github.com/org/shop"}},
+		{name: "not in git", err: errors.New("exit status 128"), wantErr: "git repository"},
+		{name: "uncommitted changes", git: &utils.GitInfo{Commit: "1a2b3c", RemoteURL: "https://github.com/org/shop", Dirty: true}, wantErr: "uncommitted changes"},
+		{name: "no remote", git: &utils.GitInfo{Commit: "1a2b3c"}, wantErr: "origin remote"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkProvenanceSource(tt.git, tt.err)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkProvenanceSource() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkProvenanceSource() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_provenanceFor(t *testing.T) {
+	git := &utils.GitInfo{Commit: "1a2b3c", Branch: "main", RemoteURL: "https://github.com/org/shop"}
+	opts := containerengine.BuildOptions{BuildArgs: map[string]string{"PROVIDER": "aws"}, Platform: "linux/arm64"}
+
+	got := provenanceFor(git, "https://github.com/org/shop/actions/runs/42", "42", "functions/orders.ts", opts)
+
+	source := provenanceMaterial{
+		URI:    "git+https://github.com/org/shop@refs/heads/main",
+		Digest: map[string]string{"sha1": "1a2b3c"},
+	}
+	configSource := source
+	configSource.EntryPoint = "functions/orders.ts"
+	want := &provenance{
+		Builder:   provenanceBuilder{ID: "https://github.com/org/shop/actions/runs/42"},
+		BuildType: provenanceBuildType,
+		Invocation: provenanceInvocation{
+			ConfigSource: configSource,
+			Parameters: map[string]interface{}{
+				"buildArgs": map[string]string{"PROVIDER": "aws"},
+				"platform":  "linux/arm64",
+			},
+		},
+		Metadata: provenanceMetadata{
+			BuildInvocationID: "42",
+			Completeness:      provenanceCompleteness{Parameters: true},
+		},
+		Materials: []provenanceMaterial{source},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
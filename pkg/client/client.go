@@ -0,0 +1,245 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+
+	v1 "github.com/nitrictech/nitric/pkg/api/nitric/v1"
+)
+
+const usage = `commands:
+  get|post|put|patch|delete <api> <path> [body]   call a route of an api
+  publish <topic> [payload]                       publish a JSON payload to a topic
+  docs <collection> [id]                          list the documents of a collection, or get one
+  help                                            show this help
+  exit                                            leave the client`
+
+type Options struct {
+	// Base URL of the gateway, apis are called at <GatewayURL>/apis/<api>
+	GatewayURL string
+	// Address of the membrane that serves collections
+	MembraneAddress string
+	// Bearer token sent with api calls
+	Token   string
+	Timeout time.Duration
+	Out     io.Writer
+}
+
+// Client calls the apis, topics and collections of a running stack
+type Client struct {
+	opts      Options
+	http      *http.Client
+	documents v1.DocumentServiceClient
+}
+
+func New(opts Options) *Client {
+	return &Client{
+		opts: opts,
+		http: &http.Client{Timeout: opts.Timeout},
+	}
+}
+
+// Run reads commands from in until it is exhausted or exit is entered, errors are printed and don't stop it
+func (c *Client) Run(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	fmt.Fprintln(c.opts.Out, "nitric client, enter help for the list of commands")
+	for {
+		fmt.Fprint(c.opts.Out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(c.opts.Out)
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "exit" || line == "quit" {
+			return nil
+		}
+		if err := c.Exec(line); err != nil {
+			fmt.Fprintln(c.opts.Out, "error:", err)
+		}
+	}
+}
+
+// Exec runs a single command
+func (c *Client) Exec(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	switch cmd := strings.ToLower(fields[0]); cmd {
+	case "help":
+		fmt.Fprintln(c.opts.Out, usage)
+		return nil
+	case "get", "post", "put", "patch", "delete":
+		args := splitArgs(line, 4)
+		if len(args) < 3 {
+			return fmt.Errorf("usage: %s <api> <path> [body]", cmd)
+		}
+		return c.call(strings.ToUpper(cmd), args[1], args[2], arg(args, 3))
+	case "publish":
+		args := splitArgs(line, 3)
+		if len(args) < 2 {
+			return fmt.Errorf("usage: publish <topic> [payload]")
+		}
+		return c.publish(args[1], arg(args, 2))
+	case "docs":
+		if len(fields) < 2 || len(fields) > 3 {
+			return fmt.Errorf("usage: docs <collection> [id]")
+		}
+		return c.docs(fields[1], arg(fields, 2))
+	default:
+		return fmt.Errorf("unknown command %s, enter help for the list of commands", fields[0])
+	}
+}
+
+// splitArgs splits line into at most n whitespace separated arguments, the last one keeps its spaces
+func splitArgs(line string, n int) []string {
+	args := []string{}
+	rest := strings.TrimSpace(line)
+	for len(args) < n-1 && rest != "" {
+		i := strings.IndexAny(rest, " \t")
+		if i < 0 {
+			break
+		}
+		args = append(args, rest[:i])
+		rest = strings.TrimSpace(rest[i:])
+	}
+	if rest != "" {
+		args = append(args, rest)
+	}
+	return args
+}
+
+func arg(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+	return ""
+}
+
+func (c *Client) call(method, api, path, body string) error {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	url := strings.TrimSuffix(c.opts.GatewayURL, "/") + "/apis/" + api + path
+	return c.do(method, url, body)
+}
+
+func (c *Client) publish(topic, payload string) error {
+	if payload == "" {
+		payload = "{}"
+	}
+	if !json.Valid([]byte(payload)) {
+		return fmt.Errorf("payload is not valid JSON")
+	}
+	return c.do(http.MethodPost, strings.TrimSuffix(c.opts.GatewayURL, "/")+"/topic/"+topic, payload)
+}
+
+func (c *Client) do(method, url, body string) error {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != "" && json.Valid([]byte(body)) {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.opts.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.opts.Token)
+	}
+
+	start := time.Now()
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.opts.Out, "%s (%s)\n", resp.Status, time.Since(start).Round(time.Millisecond))
+	if len(b) > 0 {
+		fmt.Fprintln(c.opts.Out, formatBody(b))
+	}
+	return nil
+}
+
+// formatBody indents JSON bodies, other bodies are returned as they are
+func formatBody(b []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return string(b)
+	}
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return string(b)
+	}
+	return string(out)
+}
+
+func (c *Client) docs(collection, id string) error {
+	if c.documents == nil {
+		conn, err := grpc.Dial(c.opts.MembraneAddress, grpc.WithInsecure())
+		if err != nil {
+			return errors.WithMessage(err, "connecting to the membrane")
+		}
+		c.documents = v1.NewDocumentServiceClient(conn)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.Timeout)
+	defer cancel()
+
+	col := &v1.Collection{Name: collection}
+	docs := []*v1.Document{}
+	if id != "" {
+		resp, err := c.documents.Get(ctx, &v1.DocumentGetRequest{Key: &v1.Key{Collection: col, Id: id}})
+		if err != nil {
+			return err
+		}
+		docs = append(docs, resp.Document)
+	} else {
+		resp, err := c.documents.Query(ctx, &v1.DocumentQueryRequest{Collection: col, Limit: 100})
+		if err != nil {
+			return err
+		}
+		docs = resp.Documents
+	}
+
+	for _, d := range docs {
+		content, err := json.MarshalIndent(d.Content.AsMap(), "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.opts.Out, "%s: %s\n", d.Key.GetId(), content)
+	}
+	if len(docs) == 0 {
+		fmt.Fprintf(c.opts.Out, "collection %s has no documents\n", collection)
+	}
+	return nil
+}
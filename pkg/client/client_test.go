@@ -0,0 +1,97 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		n    int
+		want []string
+	}{
+		{line: "get main /orders", n: 4, want: []string{"get", "main", "/orders"}},
+		{line: `post main /orders {"item": "a b"}`, n: 4, want: []string{"post", "main", "/orders", `{"item": "a b"}`}},
+		{line: "publish  created   {}", n: 3, want: []string{"publish", "created", "{}"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			if got := splitArgs(tt.line, tt.n); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExec(t *testing.T) {
+	var got struct{ method, path, body, auth string }
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		got.method, got.path, got.body, got.auth = r.Method, r.URL.Path, string(b), r.Header.Get("Authorization")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		line       string
+		wantMethod string
+		wantPath   string
+		wantBody   string
+		wantErr    bool
+	}{
+		{name: "api call", line: "get main orders", wantMethod: "GET", wantPath: "/apis/main/orders"},
+		{name: "api call with body", line: `POST main /orders {"id": 1}`, wantMethod: "POST", wantPath: "/apis/main/orders", wantBody: `{"id": 1}`},
+		{name: "publish", line: "publish created", wantMethod: "POST", wantPath: "/topic/created", wantBody: "{}"},
+		{name: "invalid payload", line: "publish created {", wantErr: true},
+		{name: "missing path", line: "get main", wantErr: true},
+		{name: "unknown command", line: "fetch main /", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got.method, got.path, got.body = "", "", ""
+			out := &bytes.Buffer{}
+			c := New(Options{GatewayURL: srv.URL, Token: "tkn", Timeout: time.Second, Out: out})
+
+			err := c.Exec(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Exec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.method != tt.wantMethod || got.path != tt.wantPath || got.body != tt.wantBody {
+				t.Errorf("Exec() sent %s %s %s, want %s %s %s", got.method, got.path, got.body, tt.wantMethod, tt.wantPath, tt.wantBody)
+			}
+			if got.auth != "Bearer tkn" {
+				t.Errorf("Exec() sent Authorization %q", got.auth)
+			}
+			if !strings.Contains(out.String(), `"ok": true`) {
+				t.Errorf("Exec() printed %s, want the indented body", out.String())
+			}
+		})
+	}
+}
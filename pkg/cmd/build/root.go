@@ -17,12 +17,20 @@
 package build
 
 import (
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
 	"github.com/nitrictech/newcli/pkg/build"
 	"github.com/nitrictech/newcli/pkg/output"
 	"github.com/nitrictech/newcli/pkg/stack"
 	"github.com/nitrictech/newcli/pkg/target"
+	"github.com/nitrictech/newcli/pkg/utils"
+)
+
+var (
+	push       bool
+	provenance bool
 )
 
 var buildCmd = &cobra.Command{
@@ -36,12 +44,39 @@ var buildCmd = &cobra.Command{
 var buildCreateCmd = &cobra.Command{
 	Use:   "create",
 	Short: "create a new application build",
-	Long:  `Creates a new Nitric application build.`,
+	Long: `Creates a new Nitric application build.
+
+Use --push to push the images to a registry after they are built, e.g. to build in CI separately from
+deploying. They are tagged with the git commit of the stack (suffixed with -dirty when there are uncommitted
+changes), credentials for ECR, GCR (and Artifact
+Registry) and ACR are fetched with the aws, gcloud and az cli, other registries use docker login or the
+registries config, e.g.
+	nitric build create --push --registry 123456789012.dkr.ecr.us-east-1.amazonaws.com/shop
+
+Functions can be built from private or mirrored base images with the stack's baseImages, keyed by the
+default image or its repository, the credentials for pulling them are those of the registries config or
+docker login, e.g.
+	baseImages:
+	  node:alpine: registry.example.com/mirror/node:16-alpine
+	  python: registry.example.com/mirror/python
+
+Use --provenance as well to push a SLSA provenance attestation with each image, recording the CI run that
+built it, the git repository and commit of its source and the build parameters. The attestations are signed
+and pushed with cosign, keyless unless the build_provenance_key config names a signing key.
+`,
 	Run: func(cmd *cobra.Command, args []string) {
 		t := target.FromOptions()
 		s, err := stack.FromOptions()
 		cobra.CheckErr(err)
-		cobra.CheckErr(build.Create(s, t))
+		if provenance && !push {
+			cobra.CheckErr(errors.New("provenance is pushed alongside the images, use it with --push"))
+		}
+		cobra.CheckErr(utils.WithHint(build.Create(s, t)))
+		if push {
+			pushed, err := build.Push(s, t, viper.GetString("build_registry"), provenance)
+			cobra.CheckErr(utils.WithHint(err))
+			output.Print(pushed)
+		}
 	},
 	Args: cobra.MaximumNArgs(0),
 }
@@ -64,6 +99,16 @@ func RootCommand() *cobra.Command {
 	buildCmd.AddCommand(buildCreateCmd)
 	target.AddOptions(buildCreateCmd, true)
 	stack.AddOptions(buildCreateCmd)
+	buildCreateCmd.Flags().Int("parallel", 1, "the number of images to build at once (defaults to the build_parallelism config)")
+	cobra.CheckErr(viper.BindPFlag("build_parallelism", buildCreateCmd.Flags().Lookup("parallel")))
+	buildCreateCmd.Flags().StringArray("cache-from", nil, "a BuildKit cache to import, e.g. type=registry,ref=ghcr.io/org/cache:{image} ({image} is replaced with the image name)")
+	cobra.CheckErr(viper.BindPFlag("build_cache_from", buildCreateCmd.Flags().Lookup("cache-from")))
+	buildCreateCmd.Flags().StringArray("cache-to", nil, "a BuildKit cache to export to, e.g. type=local,dest=.nitric/cache/{image} ({image} is replaced with the image name)")
+	cobra.CheckErr(viper.BindPFlag("build_cache_to", buildCreateCmd.Flags().Lookup("cache-to")))
+	buildCreateCmd.Flags().BoolVar(&push, "push", false, "push the images to the registry once they are built")
+	buildCreateCmd.Flags().String("registry", "", "the registry repository to push to, e.g. gcr.io/my-project (defaults to the build_registry config)")
+	cobra.CheckErr(viper.BindPFlag("build_registry", buildCreateCmd.Flags().Lookup("registry")))
+	buildCreateCmd.Flags().BoolVar(&provenance, "provenance", false, "push a signed SLSA provenance attestation with each image")
 	buildCmd.AddCommand(buildListCmd)
 	stack.AddOptions(buildListCmd)
 	return buildCmd
@@ -0,0 +1,78 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changed
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nitrictech/newcli/pkg/output"
+	"github.com/nitrictech/newcli/pkg/stack"
+	"github.com/nitrictech/newcli/pkg/utils"
+)
+
+var since string
+
+var changedCmd = &cobra.Command{
+	Use:   "changed [stack...]",
+	Short: "list the functions and stacks affected by the changes since a git ref",
+	Long: `Reports the functions, containers and apis of each stack that are affected by the files changed
+since a git ref, including uncommitted changes, so CI pipelines can build and deploy only what changed, e.g.
+	nitric changed --since origin/main -o json
+	nitric changed --since origin/main apps/shop apps/billing -o json
+
+A function is affected by the files of its context directory that aren't excluded from its build, including
+shared modules and manifests such as package.json or go.mod, or only by its watch paths when they are set,
+which are directories, files or globs and can include shared modules outside the stack (e.g. ../libs/**). A change to the stack file affects
+everything in the stack. The stacks default to the one given with --stack.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		stacks := []*stack.Stack{}
+		if len(args) == 0 {
+			s, err := stack.FromOptions()
+			cobra.CheckErr(err)
+			stacks = append(stacks, s)
+		}
+		for _, a := range args {
+			if info, err := os.Stat(a); err == nil && info.IsDir() {
+				a = filepath.Join(a, "nitric.yaml")
+			}
+			s, err := stack.FromFile(a)
+			cobra.CheckErr(err)
+			stacks = append(stacks, s)
+		}
+
+		changes := []*stack.Changes{}
+		for _, s := range stacks {
+			files, err := utils.GitChangedFiles(s.Path(), since)
+			cobra.CheckErr(err)
+			c, err := s.Changed(files)
+			cobra.CheckErr(err)
+			changes = append(changes, c)
+		}
+		output.Print(changes)
+	},
+}
+
+func RootCommand() *cobra.Command {
+	stack.AddOptions(changedCmd)
+	changedCmd.Flags().StringVar(&since, "since", "", "the git ref to compare with, e.g. origin/main")
+	cobra.CheckErr(changedCmd.MarkFlagRequired("since"))
+	return changedCmd
+}
@@ -0,0 +1,74 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nitrictech/newcli/pkg/client"
+)
+
+var (
+	gatewayURL      string
+	membraneAddress string
+	token           string
+	timeout         time.Duration
+)
+
+var clientCmd = &cobra.Command{
+	Use:   "client [command]",
+	Short: "call the apis, topics and collections of a running stack",
+	Long: `Starts an interactive client for quick manual testing of a running stack, e.g.
+	nitric client
+	> get main /orders
+	> post main /orders {"item": "book"}
+	> publish created {"id": 1}
+	> docs orders
+
+A single command can be given as arguments instead, e.g.
+	nitric client get main /orders
+
+By default the local 'nitric run' gateway and membrane are used, use --url to call a deployment
+and --token to authenticate with apis that require a JWT.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c := client.New(client.Options{
+			GatewayURL:      gatewayURL,
+			MembraneAddress: membraneAddress,
+			Token:           token,
+			Timeout:         timeout,
+			Out:             os.Stdout,
+		})
+		if len(args) > 0 {
+			cobra.CheckErr(c.Exec(strings.Join(args, " ")))
+			return
+		}
+		cobra.CheckErr(c.Run(os.Stdin))
+	},
+}
+
+func RootCommand() *cobra.Command {
+	clientCmd.Flags().StringVar(&gatewayURL, "url", "http://localhost:9001", "the base URL of the gateway")
+	clientCmd.Flags().StringVar(&membraneAddress, "membrane", "localhost:50051", "the address of the membrane, for collections")
+	clientCmd.Flags().StringVar(&token, "token", "", "a bearer token sent with api calls")
+	clientCmd.Flags().DurationVar(&timeout, "timeout", 10*time.Second, "timeout for each command")
+	return clientCmd
+}
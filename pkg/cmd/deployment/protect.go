@@ -0,0 +1,158 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployment
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	"golang.org/x/term"
+
+	"github.com/nitrictech/newcli/pkg/approval"
+	"github.com/nitrictech/newcli/pkg/output"
+	"github.com/nitrictech/newcli/pkg/stack"
+	"github.com/nitrictech/newcli/pkg/target"
+	"github.com/nitrictech/newcli/pkg/utils"
+)
+
+// confirmProduction guards changes to production targets, it returns an error when the stack has
+// uncommitted changes, otherwise it prints the preview and asks for the stack's name to be typed,
+// which --confirm can give instead when there is no terminal (e.g. CI)
+func confirmProduction(t *target.Target, s *stack.Stack, action string, preview interface{}) error {
+	if !t.Production {
+		return nil
+	}
+
+	git, err := utils.GitMetadata(s.Path())
+	if err != nil {
+		return errors.WithMessagef(err, "target %s is production, the stack must be in a git repository", t.Name)
+	}
+	if git.Dirty {
+		return fmt.Errorf("target %s is production, commit or stash the changes to the stack first", t.Name)
+	}
+
+	warn := color.New(color.Bold, color.FgRed).PrintlnFunc()
+	warn(fmt.Sprintf("%s production target %s, from commit %s", action, t.Name, git.Commit))
+	output.Print(preview)
+
+	typed := confirmName
+	if typed == "" {
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return fmt.Errorf("target %s is production, confirm with --confirm %s", t.Name, s.Name)
+		}
+		err := survey.AskOne(&survey.Input{Message: fmt.Sprintf("Type the name of the stack (%s) to continue", s.Name)}, &typed)
+		if err != nil {
+			return err
+		}
+	}
+	if typed != s.Name {
+		return fmt.Errorf("%s does not match the stack name %s, nothing was changed", typed, s.Name)
+	}
+	return nil
+}
+
+// askConfirm asks a yes/no question, replaced in tests
+var askConfirm = func(message string) (bool, error) {
+	confirmed := false
+	err := survey.AskOne(&survey.Confirm{Message: message}, &confirmed)
+	return confirmed, err
+}
+
+// confirmDelete prints the resources a delete removes and asks for confirmation, it returns false when
+// there is nothing to delete or the delete was declined. Structured output has no prompt, so --yes
+// must be given instead.
+func confirmDelete(plan interface{}, yes bool) (bool, error) {
+	if emptyPlan(plan) {
+		fmt.Fprintln(os.Stderr, "Nothing to delete")
+		return false, nil
+	}
+	if output.Structured() && !yes {
+		return false, errors.New("--preview with structured output can't prompt, confirm the delete with --yes")
+	}
+
+	output.Print(plan)
+	if yes {
+		return true, nil
+	}
+	return askConfirm("Delete these resources?")
+}
+
+func emptyPlan(plan interface{}) bool {
+	if plan == nil {
+		return true
+	}
+	v := reflect.ValueOf(plan)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	}
+	return false
+}
+
+// applyPreview lists the resources that an apply changes
+func applyPreview(s *stack.Stack, targets []string) []string {
+	if len(targets) > 0 {
+		return targets
+	}
+	resources := []string{}
+	for _, n := range s.Graph().Nodes {
+		resources = append(resources, n.ID)
+	}
+	return resources
+}
+
+// requireApproval posts the deployment to the target's approval webhook and waits for it to be approved
+func requireApproval(t *target.Target, s *stack.Stack, deploymentName string, resources []string) error {
+	if t.Approval == nil {
+		return nil
+	}
+
+	timeout := time.Hour
+	if t.Approval.Timeout != "" {
+		var err error
+		timeout, err = time.ParseDuration(t.Approval.Timeout)
+		if err != nil {
+			return errors.WithMessagef(err, "approval timeout of target %s", t.Name)
+		}
+	}
+
+	commit := ""
+	if git, err := utils.GitMetadata(s.Path()); err == nil {
+		commit = git.Commit
+	}
+	req, err := approval.NewRequest(s.Name, t.Name, deploymentName, commit, resources)
+	if err != nil {
+		return err
+	}
+	d, err := approval.Ask(approval.Options{
+		Webhook:   t.Approval.Webhook,
+		StatusURL: t.Approval.StatusURL,
+		Secret:    os.ExpandEnv(t.Approval.Secret),
+		Timeout:   timeout,
+		Out:       os.Stdout,
+	}, req)
+	if err != nil {
+		return errors.WithMessagef(err, "target %s requires approval", t.Name)
+	}
+	fmt.Printf("Request %s approved by %s\n", req.ID, d.Approver)
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployment
+
+import (
+	"testing"
+
+	"github.com/nitrictech/newcli/pkg/output"
+)
+
+func TestConfirmDelete(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		plan    interface{}
+		yes     bool
+		answer  bool
+		want    bool
+		wantAsk bool
+		wantErr bool
+	}{
+		{name: "nothing to delete", format: "table", plan: []string{}},
+		{name: "confirmed", format: "table", plan: []string{"function:orders"}, answer: true, want: true, wantAsk: true},
+		{name: "declined", format: "table", plan: []string{"function:orders"}, wantAsk: true},
+		{name: "yes", format: "table", plan: []string{"function:orders"}, yes: true, want: true},
+		{name: "structured without yes", format: "json", plan: []string{"function:orders"}, wantErr: true},
+		{name: "structured with yes", format: "json", plan: []string{"function:orders"}, yes: true, want: true},
+		{name: "structured and nothing to delete", format: "json", plan: []string{}},
+	}
+	defer func() {
+		if err := output.OutputTypeFlag.Set("table"); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := output.OutputTypeFlag.Set(tt.format); err != nil {
+				t.Fatal(err)
+			}
+			asked := false
+			askConfirm = func(string) (bool, error) {
+				asked = true
+				return tt.answer, nil
+			}
+
+			got, err := confirmDelete(tt.plan, tt.yes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("confirmDelete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("confirmDelete() = %v, want %v", got, tt.want)
+			}
+			if asked != tt.wantAsk {
+				t.Errorf("confirmDelete() asked = %v, want %v", asked, tt.wantAsk)
+			}
+		})
+	}
+}
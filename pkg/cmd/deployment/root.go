@@ -17,12 +17,20 @@
 package deployment
 
 import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
+	"github.com/nitrictech/newcli/pkg/environment"
 	"github.com/nitrictech/newcli/pkg/output"
 	"github.com/nitrictech/newcli/pkg/provider"
 	"github.com/nitrictech/newcli/pkg/stack"
 	"github.com/nitrictech/newcli/pkg/target"
+	"github.com/nitrictech/newcli/pkg/utils"
 )
 
 var deploymentCmd = &cobra.Command{
@@ -36,32 +44,115 @@ var deploymentCmd = &cobra.Command{
 `,
 }
 
+var (
+	applyTargets  []string
+	applyEnv      string
+	confirmName   string
+	deletePreview bool
+	deleteYes     bool
+)
+
 var deploymentCreateCmd = &cobra.Command{
 	Use:   "apply [name]",
 	Short: "Create or Update a new application deployment",
-	Long:  `Applies a Nitric application deployment.`,
+	Long: `Applies a Nitric application deployment.
+
+The stack is validated before anything is deployed, see 'nitric stack lint'.
+
+Resources are labelled with the git commit, branch and dirty flag of the stack so they can be traced
+back to their source, set git_metadata to false in the configuration to opt out.
+
+Deploying to a production target requires the stack to have no uncommitted changes and its name to be
+typed (or given with --confirm) after the resources are previewed.
+
+Targets with an approval webhook post the resources to deploy to it and wait until the request is
+approved at the target's status url, e.g. by a reviewer in Slack, before anything is deployed.
+Approvals are verified with the target's approval secret.
+
+Use --env to deploy an environment of the stack (see 'nitric env'), its target, deployment name
+and env vars are used, e.g.
+	nitric deployment apply --env staging
+
+Use --resource to update only some resources of an existing deployment, e.g.
+	nitric deployment apply dev --resource function:api --resource api:main
+
+With -o json the progress is written as a JSON object per line, with the resource, op (create,
+replace or delete), phase and, for the final deployment event, its outputs, e.g.
+	{"resource":"deployment:dev","op":"create","phase":"succeeded","durationMs":5210,"outputs":{"api:main":"http://localhost:49152"}}
+`,
 	Run: func(cmd *cobra.Command, args []string) {
 		t := target.FromOptions()
 		s, err := stack.FromOptions()
 		cobra.CheckErr(err)
+
+		deploymentName := ""
+		if len(args) > 0 {
+			deploymentName = args[0]
+		}
+		if applyEnv != "" {
+			e, err := environment.Load(s.Path(), applyEnv)
+			cobra.CheckErr(err)
+			t, err = target.FromName(e.Target)
+			cobra.CheckErr(err)
+			s.SetDefaultEnv(e.Env)
+			if deploymentName == "" {
+				deploymentName = e.DeploymentName()
+			}
+		}
+		if deploymentName == "" {
+			cobra.CheckErr(errors.New("provide the name of the deployment or an environment with --env"))
+		}
+
+		cobra.CheckErr(s.Validate())
+		cobra.CheckErr(confirmProduction(t, s, "Deploying "+deploymentName+" to", applyPreview(s, applyTargets)))
+		cobra.CheckErr(requireApproval(t, s, deploymentName, applyPreview(s, applyTargets)))
 		p, err := provider.NewProvider(s, t)
-		cobra.CheckErr(err)
-		cobra.CheckErr(p.Apply(args[0]))
+		cobra.CheckErr(utils.WithHint(err))
+		if len(applyTargets) > 0 {
+			warn := color.New(color.Bold, color.FgYellow).FprintlnFunc()
+			warn(os.Stderr, fmt.Sprintf("Only updating %s, the rest of the deployment will not be changed", strings.Join(applyTargets, ", ")))
+		}
+		cobra.CheckErr(utils.WithHint(p.Apply(deploymentName, applyTargets)))
 	},
-	Args: cobra.ExactArgs(1),
+	Args: cobra.MaximumNArgs(1),
 }
 
 var deploymentDeleteCmd = &cobra.Command{
 	Use:   "delete [name]",
 	Short: "Delete an application deployment",
-	Long:  `Delete a Nitric application deployment.`,
+	Long: `Delete a Nitric application deployment.
+
+Use --preview to list the resources that will be removed and confirm before deleting them,
+with -o json or yaml there is no prompt and --yes confirms the delete instead.
+This is required for production targets, which also require the stack's name to be typed (or given
+with --confirm) and the stack to have no uncommitted changes.
+
+With -o json each removed resource is written as a JSON object per line, see 'nitric deployment apply --help'.
+`,
 	Run: func(cmd *cobra.Command, args []string) {
 		t := target.FromOptions()
 		s, err := stack.FromOptions()
 		cobra.CheckErr(err)
 		p, err := provider.NewProvider(s, t)
-		cobra.CheckErr(err)
-		cobra.CheckErr(p.Delete(args[0]))
+		cobra.CheckErr(utils.WithHint(err))
+		if t.Production {
+			plan, err := p.DeletePlan(args[0])
+			cobra.CheckErr(err)
+			if emptyPlan(plan) {
+				fmt.Fprintln(os.Stderr, "Nothing to delete")
+				return
+			}
+			cobra.CheckErr(confirmProduction(t, s, "Deleting "+args[0]+" from", plan))
+		} else if deletePreview {
+			plan, err := p.DeletePlan(args[0])
+			cobra.CheckErr(err)
+			confirmed, err := confirmDelete(plan, deleteYes)
+			cobra.CheckErr(err)
+			if !confirmed {
+				return
+			}
+		}
+		cobra.CheckErr(utils.WithHint(p.Delete(args[0])))
 	},
 	Args: cobra.ExactArgs(1),
 }
@@ -75,7 +166,7 @@ var deploymentListCmd = &cobra.Command{
 		s, err := stack.FromOptions()
 		cobra.CheckErr(err)
 		p, err := provider.NewProvider(s, t)
-		cobra.CheckErr(err)
+		cobra.CheckErr(utils.WithHint(err))
 		deps, err := p.List()
 		cobra.CheckErr(err)
 		output.Print(deps)
@@ -87,10 +178,17 @@ func RootCommand() *cobra.Command {
 	deploymentCmd.AddCommand(deploymentCreateCmd)
 	target.AddOptions(deploymentCreateCmd, false)
 	stack.AddOptions(deploymentCreateCmd)
+	stack.AddEnvOptions(deploymentCreateCmd)
+	deploymentCreateCmd.Flags().StringVar(&applyEnv, "env", "", "deploy this environment of the stack")
+	deploymentCreateCmd.Flags().StringVar(&confirmName, "confirm", "", "the stack's name, confirms changes to production targets without a prompt")
+	deploymentCreateCmd.Flags().StringArrayVar(&applyTargets, "resource", []string{}, "only update these resources (<type>:<name>, e.g. function:api)")
 
 	deploymentCmd.AddCommand(deploymentDeleteCmd)
 	target.AddOptions(deploymentDeleteCmd, false)
 	stack.AddOptions(deploymentDeleteCmd)
+	deploymentDeleteCmd.Flags().StringVar(&confirmName, "confirm", "", "the stack's name, confirms changes to production targets without a prompt")
+	deploymentDeleteCmd.Flags().BoolVar(&deletePreview, "preview", false, "list the resources that will be removed and ask for confirmation")
+	deploymentDeleteCmd.Flags().BoolVar(&deleteYes, "yes", false, "confirm the --preview without a prompt, required with structured output")
 
 	deploymentCmd.AddCommand(deploymentListCmd)
 	stack.AddOptions(deploymentListCmd)
@@ -0,0 +1,52 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nitrictech/newcli/pkg/doctor"
+	"github.com/nitrictech/newcli/pkg/output"
+	"github.com/nitrictech/newcli/pkg/stack"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "check the environment for the tools nitric needs",
+	Long: `Checks for a container engine, provider credentials and, when run in a stack,
+the toolchains of the stack's runtimes. Exits non-zero when a required tool is missing.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		s, err := stack.FromOptions()
+		if err != nil && !stack.IsNotFound(err) {
+			cobra.CheckErr(err)
+		}
+		// s is nil when not in a stack, the toolchain checks are skipped
+		results := doctor.Check(s)
+		output.Print(results)
+		if doctor.Failed(results) {
+			os.Exit(1)
+		}
+	},
+	Args: cobra.MaximumNArgs(0),
+}
+
+func RootCommand() *cobra.Command {
+	stack.AddOptions(doctorCmd)
+	return doctorCmd
+}
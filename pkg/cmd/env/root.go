@@ -0,0 +1,174 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nitrictech/newcli/pkg/environment"
+	"github.com/nitrictech/newcli/pkg/output"
+	"github.com/nitrictech/newcli/pkg/stack"
+	"github.com/nitrictech/newcli/pkg/target"
+)
+
+var (
+	envTarget  string
+	deployment string
+	copyFrom   string
+	keep       []string
+	dryRun     bool
+)
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "work with the environments of a stack",
+	Long: `Environments are the stages of a stack (e.g. dev, staging and prod), each is deployed to a
+target of the configuration with its own env vars and is stored with the stack in environments/<name>.yaml, e.g.
+	nitric env create staging --target aws-staging
+	nitric env list
+	nitric env diff staging prod
+	nitric env promote staging prod
+	nitric deployment apply --env staging
+`,
+}
+
+var envListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the environments of the stack",
+	Long:  `Lists the environments of the stack with their target and deployment.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		s, err := stack.FromOptions()
+		cobra.CheckErr(err)
+		envs, err := environment.List(s.Path())
+		cobra.CheckErr(err)
+
+		summaries := []environmentSummary{}
+		for _, e := range envs {
+			summaries = append(summaries, environmentSummary{
+				Name:       e.Name(),
+				Target:     e.Target,
+				Deployment: e.DeploymentName(),
+				EnvVars:    len(e.Env),
+			})
+		}
+		output.Print(summaries)
+	},
+	Args: cobra.ExactArgs(0),
+}
+
+type environmentSummary struct {
+	Name       string `yaml:"name"`
+	Target     string `yaml:"target"`
+	Deployment string `yaml:"deployment"`
+	EnvVars    int    `yaml:"envVars"`
+}
+
+var envCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "create an environment of the stack",
+	Long: `Creates an environment of the stack, deployed to the target (local by default).
+Use --from to copy the env vars of another environment, e.g.
+	nitric env create prod --target aws-prod --from staging
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		s, err := stack.FromOptions()
+		cobra.CheckErr(err)
+		_, err = target.FromName(envTarget)
+		cobra.CheckErr(err)
+
+		e, err := environment.New(args[0], envTarget)
+		cobra.CheckErr(err)
+		e.Deployment = deployment
+		if copyFrom != "" {
+			from, err := environment.Load(s.Path(), copyFrom)
+			cobra.CheckErr(err)
+			for k, v := range from.Env {
+				e.Env[k] = v
+			}
+		}
+		cobra.CheckErr(e.Save(s.Path(), false))
+		fmt.Printf("Environment %s created, set its env vars in %s/%s.yaml\n", e.Name(), environment.Dir, e.Name())
+	},
+	Args: cobra.ExactArgs(1),
+}
+
+var envDiffCmd = &cobra.Command{
+	Use:   "diff [from] [to]",
+	Short: "show the differences between two environments",
+	Long:  `Shows the target, deployment and env vars that differ between two environments.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		from, to := loadPair(args)
+		output.Print(environment.Diff(from, to))
+	},
+	Args: cobra.ExactArgs(2),
+}
+
+var envPromoteCmd = &cobra.Command{
+	Use:   "promote [from] [to]",
+	Short: "copy the env vars of an environment to the next",
+	Long: `Copies the env vars of an environment to another, e.g. staging to prod, printing the values that change.
+The target and deployment of the environment promoted to are not changed, nor are its env vars
+that the other environment doesn't set. Use --keep for values that must stay different, e.g.
+	nitric env promote staging prod --keep DATABASE_URL
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		s, err := stack.FromOptions()
+		cobra.CheckErr(err)
+		from, to := loadPair(args)
+
+		changes := environment.Promote(from, to, keep)
+		output.Print(changes)
+		if dryRun || len(changes) == 0 {
+			return
+		}
+		cobra.CheckErr(to.Save(s.Path(), true))
+		fmt.Printf("Environment %s updated, deploy it with 'nitric deployment apply --env %s'\n", to.Name(), to.Name())
+	},
+	Args: cobra.ExactArgs(2),
+}
+
+func loadPair(args []string) (*environment.Environment, *environment.Environment) {
+	s, err := stack.FromOptions()
+	cobra.CheckErr(err)
+	from, err := environment.Load(s.Path(), args[0])
+	cobra.CheckErr(err)
+	to, err := environment.Load(s.Path(), args[1])
+	cobra.CheckErr(err)
+	return from, to
+}
+
+func RootCommand() *cobra.Command {
+	stack.AddOptions(envListCmd)
+	envCmd.AddCommand(envListCmd)
+
+	envCreateCmd.Flags().StringVarP(&envTarget, "target", "t", "local", "the target of the configuration the environment is deployed to")
+	envCreateCmd.Flags().StringVar(&deployment, "deployment", "", "the name of the deployment (defaults to the environment's name)")
+	envCreateCmd.Flags().StringVar(&copyFrom, "from", "", "copy the env vars of this environment")
+	stack.AddOptions(envCreateCmd)
+	envCmd.AddCommand(envCreateCmd)
+
+	stack.AddOptions(envDiffCmd)
+	envCmd.AddCommand(envDiffCmd)
+
+	envPromoteCmd.Flags().StringArrayVar(&keep, "keep", []string{}, "an env var that isn't copied, can be repeated")
+	envPromoteCmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print the values that would change")
+	stack.AddOptions(envPromoteCmd)
+	envCmd.AddCommand(envPromoteCmd)
+	return envCmd
+}
@@ -0,0 +1,140 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/spf13/cobra"
+
+	"github.com/nitrictech/newcli/pkg/loadtest"
+	"github.com/nitrictech/newcli/pkg/output"
+	"github.com/nitrictech/newcli/pkg/stack"
+)
+
+var (
+	baseURL     string
+	method      string
+	rps         int
+	duration    time.Duration
+	payloadFile string
+	timeout     time.Duration
+)
+
+var loadtestCmd = &cobra.Command{
+	Use:   "loadtest [api] [path]",
+	Short: "generate load against an api route",
+	Long: `Sends requests at a steady rate to an api route and reports latency percentiles and the error rate, e.g.
+	nitric loadtest main /orders --rps 50 --duration 30s
+	nitric loadtest main /orders/{id} --method POST --payload order.json.tmpl
+
+By default requests are sent to the api on the local 'nitric run' gateway, use --url to target a deployment.
+The payload file is a go template, {{.Index}} is the number of the request.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		apiName, route := args[0], args[1]
+		if !strings.HasPrefix(route, "/") {
+			route = "/" + route
+		}
+
+		// check the route against the api document when the stack is available
+		s, err := stack.FromOptions()
+		if err != nil && !stack.IsNotFound(err) {
+			cobra.CheckErr(err)
+		}
+		if s != nil {
+			doc, ok := s.ApiDoc(apiName)
+			if !ok {
+				cobra.CheckErr(fmt.Errorf("api %s not found in the stack", apiName))
+			}
+			cobra.CheckErr(checkRoute(doc, strings.ToUpper(method), route))
+		}
+
+		payload := ""
+		if payloadFile != "" {
+			b, err := ioutil.ReadFile(payloadFile)
+			cobra.CheckErr(err)
+			payload = string(b)
+		}
+
+		url := baseURL
+		if url == "" {
+			url = "http://localhost:9001/apis/" + apiName
+		}
+
+		res, err := loadtest.Run(loadtest.Options{
+			URL:      strings.TrimSuffix(url, "/") + route,
+			Method:   strings.ToUpper(method),
+			RPS:      rps,
+			Duration: duration,
+			Payload:  payload,
+			Timeout:  timeout,
+		})
+		cobra.CheckErr(err)
+		output.Print(*res)
+	},
+	Args: cobra.ExactArgs(2),
+}
+
+// checkRoute returns an error listing the available routes when the route
+// doesn't match any path of the api document, path params match any segment.
+func checkRoute(doc *openapi3.T, method, route string) error {
+	routes := []string{}
+	for p, item := range doc.Paths {
+		for m := range item.Operations() {
+			if m == method && matchPath(p, route) {
+				return nil
+			}
+			routes = append(routes, m+" "+p)
+		}
+	}
+	sort.Strings(routes)
+	return fmt.Errorf("%s %s is not a route of the api, available routes are:\n\t%s", method, route, strings.Join(routes, "\n\t"))
+}
+
+func matchPath(template, route string) bool {
+	tParts := strings.Split(strings.Trim(template, "/"), "/")
+	rParts := strings.Split(strings.Trim(strings.SplitN(route, "?", 2)[0], "/"), "/")
+	if len(tParts) != len(rParts) {
+		return false
+	}
+	for i := range tParts {
+		if strings.HasPrefix(tParts[i], "{") && strings.HasSuffix(tParts[i], "}") {
+			continue
+		}
+		if tParts[i] != rParts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func RootCommand() *cobra.Command {
+	loadtestCmd.Flags().StringVar(&baseURL, "url", "", "the base URL of the api (defaults to the local run gateway)")
+	loadtestCmd.Flags().StringVarP(&method, "method", "X", "GET", "the HTTP method of the route")
+	loadtestCmd.Flags().IntVar(&rps, "rps", 10, "requests per second")
+	loadtestCmd.Flags().DurationVarP(&duration, "duration", "d", 10*time.Second, "how long to generate load for")
+	loadtestCmd.Flags().StringVar(&payloadFile, "payload", "", "a template file for the request body")
+	loadtestCmd.Flags().DurationVar(&timeout, "timeout", 10*time.Second, "timeout for each request")
+	stack.AddOptions(loadtestCmd)
+	return loadtestCmd
+}
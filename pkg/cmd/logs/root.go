@@ -0,0 +1,56 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logs
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/nitrictech/newcli/pkg/provider"
+	"github.com/nitrictech/newcli/pkg/provider/types"
+	"github.com/nitrictech/newcli/pkg/stack"
+	"github.com/nitrictech/newcli/pkg/target"
+	"github.com/nitrictech/newcli/pkg/utils"
+)
+
+var logOpts = types.LogOptions{}
+
+var logsCmd = &cobra.Command{
+	Use:   "logs [deployment]",
+	Short: "show the logs of a deployment's functions",
+	Long: `Shows the logs of the functions in a deployment, e.g.
+	nitric logs dev
+	nitric logs dev --function api --follow --since 10m
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		t := target.FromOptions()
+		s, err := stack.FromOptions()
+		cobra.CheckErr(err)
+		p, err := provider.NewProvider(s, t)
+		cobra.CheckErr(utils.WithHint(err))
+		cobra.CheckErr(utils.WithHint(p.Logs(args[0], logOpts)))
+	},
+	Args: cobra.ExactArgs(1),
+}
+
+func RootCommand() *cobra.Command {
+	logsCmd.Flags().StringVar(&logOpts.Function, "function", "", "only show the logs of this function")
+	logsCmd.Flags().BoolVarP(&logOpts.Follow, "follow", "f", false, "keep streaming new logs")
+	logsCmd.Flags().StringVar(&logOpts.Since, "since", "", "only show logs since a timestamp (e.g. 2021-12-01T13:00:00Z) or relative duration (e.g. 10m)")
+	target.AddOptions(logsCmd, false)
+	stack.AddOptions(logsCmd)
+	return logsCmd
+}
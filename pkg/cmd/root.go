@@ -28,7 +28,13 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/nitrictech/newcli/pkg/cmd/build"
+	"github.com/nitrictech/newcli/pkg/cmd/changed"
+	"github.com/nitrictech/newcli/pkg/cmd/client"
 	"github.com/nitrictech/newcli/pkg/cmd/deployment"
+	"github.com/nitrictech/newcli/pkg/cmd/doctor"
+	"github.com/nitrictech/newcli/pkg/cmd/env"
+	"github.com/nitrictech/newcli/pkg/cmd/loadtest"
+	"github.com/nitrictech/newcli/pkg/cmd/logs"
 	"github.com/nitrictech/newcli/pkg/cmd/provider"
 	"github.com/nitrictech/newcli/pkg/cmd/run"
 	"github.com/nitrictech/newcli/pkg/cmd/stack"
@@ -64,7 +70,26 @@ ${HOME}/.config/nitric/.nitric-config.yaml
 
 An example of the format is:
   aliases:
-    new: stack create
+    new: stack init
+    lint: stack lint
+
+  build_parallelism: 4
+  build_cache_from:
+    - type=local,src=.nitric/cache/{image}
+  build_cache_to:
+    - type=local,dest=.nitric/cache/{image},mode=max
+  build_registry: 123456789012.dkr.ecr.us-east-1.amazonaws.com/my-app
+  build_provenance_key: awskms:///alias/build-signing
+  native: true
+  watch_delay: 500ms
+  watch_poll: false
+  enforce_limits: true
+  git_metadata: true
+
+  registries:
+    registry.example.com:
+      username: builder
+      password: ${REGISTRY_PASSWORD}
 
   targets:
     local:
@@ -73,6 +98,16 @@ An example of the format is:
       region: eastus
       provider: aws
       name: myApp
+    prod-app:
+      region: eastus
+      provider: aws
+      name: myApp
+      production: true
+      approval:
+        webhook: https://hooks.slack.com/services/T000/B000/XXXX
+        statusUrl: https://approvals.example.com/status
+        secret: ${APPROVAL_SECRET}
+        timeout: 30m
   `,
 }
 
@@ -90,7 +125,13 @@ func init() {
 	rootCmd.AddCommand(provider.RootCommand())
 	rootCmd.AddCommand(stack.RootCommand())
 	rootCmd.AddCommand(target.RootCommand())
+	rootCmd.AddCommand(env.RootCommand())
 	rootCmd.AddCommand(run.RootCommand())
+	rootCmd.AddCommand(loadtest.RootCommand())
+	rootCmd.AddCommand(client.RootCommand())
+	rootCmd.AddCommand(logs.RootCommand())
+	rootCmd.AddCommand(doctor.RootCommand())
+	rootCmd.AddCommand(changed.RootCommand())
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(configHelpTopic)
 	addAliases()
@@ -124,7 +165,12 @@ func ensureConfigDefaults() {
 	aliases := viper.GetStringMap("aliases")
 	if _, ok := aliases["new"]; !ok {
 		needsWrite = true
-		aliases["new"] = "stack create"
+		aliases["new"] = "stack init"
+		viper.Set("aliases", aliases)
+	}
+	if _, ok := aliases["lint"]; !ok {
+		needsWrite = true
+		aliases["lint"] = "stack lint"
 		viper.Set("aliases", aliases)
 	}
 
@@ -141,6 +187,11 @@ func ensureConfigDefaults() {
 		viper.Set("build_timeout", 5*time.Minute)
 	}
 
+	if !viper.IsSet("git_metadata") {
+		needsWrite = true
+		viper.Set("git_metadata", true)
+	}
+
 	if needsWrite {
 		fmt.Println("updating configfile to include defaults")
 		viper.WriteConfig()
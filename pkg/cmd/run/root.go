@@ -24,22 +24,40 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
 	"github.com/nitrictech/newcli/pkg/build"
 	"github.com/nitrictech/newcli/pkg/provider/run"
+	"github.com/nitrictech/newcli/pkg/stack"
+	"github.com/nitrictech/newcli/pkg/utils"
 	"github.com/nitrictech/nitric/pkg/membrane"
 	boltdb_service "github.com/nitrictech/nitric/pkg/plugins/document/boltdb"
 	minio "github.com/nitrictech/nitric/pkg/plugins/storage/minio"
 	"github.com/nitrictech/nitric/pkg/worker"
 )
 
+var (
+	dashboard     bool
+	dashboardPort int
+)
+
 var runCmd = &cobra.Command{
 	Use:   "run [entrypointsGlob]",
 	Short: "run a nitric stack",
 	Long: `Run a nitric stack locally for
 	development/testing
+
+Secrets, queues and events have no local emulator, calls to them are logged
+and answered by stubs. Canned responses can be set in the stack, e.g.
+	stubs:
+	  secrets:
+	    api-key: test-key
+	  queues:
+	    work:
+	      - id: 1
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		term := make(chan os.Signal, 1)
@@ -49,16 +67,39 @@ var runCmd = &cobra.Command{
 		ctx, err := filepath.Abs(".")
 		cobra.CheckErr(err)
 
-		files, err := filepath.Glob(filepath.Join(ctx, args[0]))
+		handlers, err := utils.Glob(ctx, args[0])
 		cobra.CheckErr(err)
+		files := []string{}
+		for _, h := range handlers {
+			files = append(files, filepath.Join(ctx, h))
+		}
 
-		build.CreateBaseDev(ctx, map[string]string{
-			"ts": "nitric-ts-dev",
-		})
-		cobra.CheckErr(err)
+		// A stack file is optional when running, see below
+		s, err := stack.FromOptions()
+		if err != nil && !stack.IsNotFound(err) {
+			cobra.CheckErr(err)
+		}
+
+		// build the dev images for the runtimes of the handlers that run in containers
+		native := viper.GetBool("native")
+		images := map[string]string{}
+		for _, f := range files {
+			rt, err := utils.NewRunTimeFromFilename(f)
+			cobra.CheckErr(err)
+			if _, ok := utils.NativeCommand(ctx, f); native && ok {
+				continue
+			}
+			images[rt.String()] = rt.DevImageName()
+		}
+		var baseImages map[string]string
+		if s != nil {
+			baseImages = s.BaseImages
+		}
+		err = build.CreateBaseDev(ctx, images, baseImages)
+		cobra.CheckErr(utils.WithHint(err))
 
 		mio, err := run.NewMinio("./.nitric/run", "test-run")
-		cobra.CheckErr(err)
+		cobra.CheckErr(utils.WithHint(err))
 
 		// start minio
 		mio.Start()
@@ -83,8 +124,46 @@ var runCmd = &cobra.Command{
 			MaxWorkers: 100,
 		})
 
+		// A stack file is optional when running, but if present
+		// the payloads published to its topics will be validated
+		// the CORS, auth and rate limits of its apis will be applied and their request metrics labelled with the functions of their routes
+		// and the functions it declares restart only for their watch paths
+		// and have their memory, cpu and timeout limits applied (or warned about), their env vars set and files mounted
+		// its stubs are the canned responses of services without a local emulator
+		topicSchemas := map[string]*openapi3.Schema{}
+		apiRoutes := map[string][]stack.ApiRoute{}
+		apiPolicies := map[string]stack.ApiPolicy{}
+		apiTimeouts := map[string]time.Duration{}
+		stackDir := ctx
+		var stubConfig *stack.Stubs
+		topics := []string{}
+		if s != nil {
+			topicSchemas = s.TopicSchemas()
+			apiRoutes = s.ApiRoutes()
+			apiPolicies = s.ApiPolicies
+			apiTimeouts = s.ApiTimeouts()
+			stackDir = s.Path()
+			stubConfig = s.Stubs
+			for name := range s.Topics {
+				topics = append(topics, name)
+			}
+		}
+
+		// Stub the secrets, queues and events services, these log their calls
+		stubs := run.NewStubs(stubConfig, topics, topicSchemas, os.Stdout)
+
 		// Start a new gateway plugin
-		gw, err := run.NewGateway()
+		activity := run.NewActivity()
+		metrics := run.NewMetrics()
+		gw, err := run.NewGateway(run.GatewayOptions{
+			TopicSchemas: topicSchemas,
+			Policies:     apiPolicies,
+			Timeouts:     apiTimeouts,
+			StackDir:     stackDir,
+			Activity:     activity,
+			Routes:       apiRoutes,
+			Metrics:      metrics,
+		})
 		cobra.CheckErr(err)
 
 		// Prepare development membrane to start
@@ -96,6 +175,9 @@ var runCmd = &cobra.Command{
 			StoragePlugin:           sp,
 			DocumentPlugin:          dp,
 			GatewayPlugin:           gw,
+			SecretPlugin:            stubs.SecretPlugin(),
+			QueuePlugin:             stubs.QueuePlugin(),
+			EventsPlugin:            stubs.EventsPlugin(),
 			Pool:                    pool,
 			TolerateMissingServices: true,
 		})
@@ -108,19 +190,40 @@ var runCmd = &cobra.Command{
 
 		time.Sleep(time.Second * time.Duration(2))
 
-		functions, err := run.FunctionsFromHandlers(ctx, files)
-		cobra.CheckErr(err)
+		functions, err := run.FunctionsFromHandlers(ctx, files, native)
+		cobra.CheckErr(utils.WithHint(err))
 
 		for _, f := range functions {
+			f.SetMetrics(metrics)
+			if s != nil {
+				if fn, ok := s.FunctionByHandler(f.HandlerPath()); ok {
+					f.SetWatch(fn.Watch)
+					f.SetLimits(fn.Memory, fn.CPU)
+					f.SetEnv(fn.EnvVars(s))
+					f.SetFiles(fn.FileMounts(s))
+				}
+			}
 			err = f.Start()
+			cobra.CheckErr(utils.WithHint(err))
+		}
+
+		var dash *run.Dashboard
+		if dashboard {
+			dash, err = run.NewDashboard(fmt.Sprintf("localhost:%d", dashboardPort), run.GatewayURL(), s, functions, activity)
 			cobra.CheckErr(err)
+			go func() {
+				if err := dash.Start(); err != nil {
+					fmt.Println(errors.WithMessage(err, "dashboard error"))
+				}
+			}()
+			fmt.Printf("Dashboard running at %s\n", dash.URL())
 		}
 
 		fmt.Println("Local running, use ctrl-C to stop")
 
 		select {
 		case membraneError := <-memerr:
-			fmt.Println(errors.WithMessage(membraneError, "membrane error, exiting"))
+			fmt.Println(utils.WithHint(errors.WithMessage(membraneError, "membrane error, exiting")))
 		case sigTerm := <-term:
 			fmt.Printf("Received %v, exiting\n", sigTerm)
 		}
@@ -129,6 +232,10 @@ var runCmd = &cobra.Command{
 			f.Stop()
 		}
 
+		if dash != nil {
+			dash.Stop()
+		}
+
 		// Stop the membrane
 		mem.Stop()
 		// Stop the minio server
@@ -138,5 +245,17 @@ var runCmd = &cobra.Command{
 }
 
 func RootCommand() *cobra.Command {
+	runCmd.Flags().Bool("native", false, "run javascript, typescript and go handlers with the host's toolchain instead of in containers, when it is installed (native handlers are not restarted when they change, restart nitric run instead)")
+	cobra.CheckErr(viper.BindPFlag("native", runCmd.Flags().Lookup("native")))
+	runCmd.Flags().Duration("watch-delay", 0, "batch file changes within this duration into a single restart, e.g. 500ms (defaults to the watch_delay config)")
+	cobra.CheckErr(viper.BindPFlag("watch_delay", runCmd.Flags().Lookup("watch-delay")))
+	runCmd.Flags().Bool("poll", false, "poll for file changes, for projects on network filesystems where change events aren't delivered")
+	cobra.CheckErr(viper.BindPFlag("watch_poll", runCmd.Flags().Lookup("poll")))
+	runCmd.Flags().Bool("enforce-limits", false, "apply the memory, cpu and timeout limits of functions, as they are when deployed")
+	cobra.CheckErr(viper.BindPFlag("enforce_limits", runCmd.Flags().Lookup("enforce-limits")))
+	stack.AddOptions(runCmd)
+	runCmd.Flags().BoolVar(&dashboard, "dashboard", false, "serve a local dashboard to call apis and publish to topics")
+	runCmd.Flags().IntVar(&dashboardPort, "dashboard-port", 49152, "the port of the local dashboard")
+	stack.AddEnvOptions(runCmd)
 	return runCmd
 }
@@ -17,27 +17,43 @@
 package stack
 
 import (
-	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
 
 	"github.com/nitrictech/newcli/pkg/build"
 	"github.com/nitrictech/newcli/pkg/codeconfig"
 	"github.com/nitrictech/newcli/pkg/output"
+	"github.com/nitrictech/newcli/pkg/pflagext"
+	"github.com/nitrictech/newcli/pkg/scaffold"
 	"github.com/nitrictech/newcli/pkg/stack"
 	"github.com/nitrictech/newcli/pkg/templates"
+	"github.com/nitrictech/newcli/pkg/utils"
 )
 
 var (
-	force       bool
-	nameRegex   = regexp.MustCompile(`^([a-zA-Z0-9-])*$`)
-	stackNameQu = survey.Question{
+	force         bool
+	snapshotCheck bool
+	noCache       bool
+	native        bool
+	graphFormat   string
+	graphFormats  = []string{"dot", "mermaid", "svg"}
+	nameRegex     = regexp.MustCompile(`^([a-zA-Z0-9-])*$`)
+	stackNameQu   = survey.Question{
 		Name:     "stackName",
 		Prompt:   &survey.Input{Message: "What is the name of the stack?"},
 		Validate: validateName,
@@ -52,6 +68,7 @@ var stackCmd = &cobra.Command{
 	Short: "work with stack objects",
 	Long: `Choose an action to perform on a stack, e.g.
 nitric stack create
+nitric stack init
 `,
 }
 
@@ -151,11 +168,11 @@ var stackDescribeCmd = &cobra.Command{
 		stackPath, err := filepath.Abs(stack.StackPath())
 		cobra.CheckErr(err)
 
-		cc, err := codeconfig.New(stackPath, args[0])
+		cc, err := codeconfig.New(stackPath, args[0], codeconfig.Options{NoCache: noCache, Native: native || viper.GetBool("native")})
 		cobra.CheckErr(err)
 
 		// Generate dev images to run on
-		err = build.CreateBaseDev(stackPath, cc.ImagesToBuild())
+		err = build.CreateBaseDev(stackPath, cc.ImagesToBuild(), baseImages())
 		cobra.CheckErr(err)
 
 		err = cc.Collect()
@@ -168,12 +185,221 @@ var stackDescribeCmd = &cobra.Command{
 	Args: cobra.ExactArgs(1),
 }
 
+var stackInitCmd = &cobra.Command{
+	Use:   "init [stackName]",
+	Short: "scaffold a new project",
+	Long: `Creates a new Nitric project, asking for the runtime and an example to start from.
+The project's nitric.yaml, handlers and dependency manifests are written to a new directory named after the stack.
+Projects are deployed with the local target, local is the only provider this version supports.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		answers := struct {
+			StackName string
+			Runtime   string
+			Example   string
+		}{}
+
+		qs := []*survey.Question{}
+		if len(args) > 0 && validateName(args[0]) == nil {
+			answers.StackName = args[0]
+		} else {
+			qs = append(qs, &stackNameQu)
+		}
+		qs = append(qs,
+			&survey.Question{
+				Name:   "runtime",
+				Prompt: &survey.Select{Message: "Choose a runtime:", Options: scaffold.Runtimes, Default: scaffold.Runtimes[0]},
+			},
+			&survey.Question{
+				Name:   "example",
+				Prompt: &survey.Select{Message: "Choose an example:", Options: scaffold.Examples, Default: scaffold.ExampleAPI},
+			},
+		)
+		cobra.CheckErr(survey.Ask(qs, &answers))
+
+		err := scaffold.Write("./"+answers.StackName, scaffold.Options{
+			Name:    answers.StackName,
+			Runtime: utils.Runtime(answers.Runtime),
+			Example: answers.Example,
+		}, force)
+		cobra.CheckErr(err)
+
+		fmt.Printf("created %s, to run it locally:\n\tcd %s\n\tnitric run 'functions/*'\n", answers.StackName, answers.StackName)
+		fmt.Print("to deploy it:\n\tnitric deployment apply -t local\n")
+	},
+	Args: cobra.MaximumNArgs(1),
+}
+
+type scheduleSummary struct {
+	Name     string `yaml:"name"`
+	Cron     string `yaml:"cron"`
+	Timezone string `yaml:"timezone"`
+	NextRuns string `yaml:"nextRuns"`
+}
+
+var stackLintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "lint the stack",
+	Long: `Validates the stack, checking that its files exist, the resources it references are declared,
+its api documents are valid and its compute unit names are unique, then prints the next 5 times
+each schedule will run.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		s, err := stack.FromOptions()
+		cobra.CheckErr(err)
+
+		errs := utils.NewErrorList()
+		errs.Add(s.Validate())
+		summaries := []scheduleSummary{}
+		for name, sched := range s.Schedules {
+			cron, loc, err := utils.ExpressionToCron(sched.Expression)
+			if err != nil {
+				// reported by Validate
+				continue
+			}
+			next, err := utils.NextCronTimes(cron, time.Now().In(loc), 5)
+			if err != nil {
+				errs.Add(fmt.Errorf("schedule %s: %v", name, err))
+				continue
+			}
+			runs := []string{}
+			for _, t := range next {
+				runs = append(runs, t.Format(time.RFC1123))
+			}
+			summaries = append(summaries, scheduleSummary{
+				Name:     name,
+				Cron:     cron,
+				Timezone: loc.String(),
+				NextRuns: strings.Join(runs, "\n"),
+			})
+		}
+		sort.Slice(summaries, func(i, j int) bool {
+			return summaries[i].Name < summaries[j].Name
+		})
+
+		output.Print(summaries)
+		cobra.CheckErr(errs.Aggregate())
+	},
+	Args: cobra.MaximumNArgs(0),
+}
+
+var stackSnapshotCmd = &cobra.Command{
+	Use:   "snapshot [file]",
+	Short: "snapshot the stack's resource graph",
+	Long: `Writes the stack's resources and their relationships to a snapshot file (nitric.snapshot.yaml by default).
+Commit the snapshot and run with --check in CI to fail when the resource graph changes unexpectedly, e.g.
+	nitric stack snapshot
+	nitric stack snapshot --check
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		s, err := stack.FromOptions()
+		cobra.CheckErr(err)
+
+		file := path.Join(s.Path(), "nitric.snapshot.yaml")
+		if len(args) > 0 {
+			file = args[0]
+		}
+
+		b, err := yaml.Marshal(s.Graph())
+		cobra.CheckErr(err)
+
+		if !snapshotCheck {
+			cobra.CheckErr(ioutil.WriteFile(file, b, 0o644))
+			return
+		}
+
+		existing, err := ioutil.ReadFile(file)
+		cobra.CheckErr(err)
+		if diff := cmp.Diff(strings.Split(string(existing), "\n"), strings.Split(string(b), "\n")); diff != "" {
+			fmt.Printf("the resource graph does not match %s (-snapshot +stack):\n%s", file, diff)
+			os.Exit(1)
+		}
+	},
+	Args: cobra.MaximumNArgs(1),
+}
+
+var stackGraphCmd = &cobra.Command{
+	Use:   "graph [handler pattern]",
+	Short: "render the stack's resource graph",
+	Long: `Renders the stack's resources and their relationships as graphviz DOT, a mermaid flowchart or SVG (which needs graphviz installed).
+The graph is read from the stack file, or collected from the handlers when a handler pattern is given, e.g.
+	nitric stack graph --format mermaid
+	nitric stack graph "functions/*.ts" --format svg > graph.svg
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var s *stack.Stack
+		var err error
+		if len(args) > 0 {
+			stackPath, err := filepath.Abs(stack.StackPath())
+			cobra.CheckErr(err)
+
+			cc, err := codeconfig.New(stackPath, args[0], codeconfig.Options{NoCache: noCache, Native: native || viper.GetBool("native")})
+			cobra.CheckErr(err)
+
+			err = build.CreateBaseDev(stackPath, cc.ImagesToBuild(), baseImages())
+			cobra.CheckErr(err)
+
+			err = cc.Collect()
+			cobra.CheckErr(err)
+
+			s, err = cc.ToStack()
+			cobra.CheckErr(err)
+		} else {
+			s, err = stack.FromOptions()
+			cobra.CheckErr(err)
+		}
+
+		g := s.Graph()
+		switch graphFormat {
+		case "mermaid":
+			fmt.Print(g.Mermaid())
+		case "svg":
+			dot := exec.Command("dot", "-Tsvg")
+			dot.Stdin = strings.NewReader(g.DOT(s.Name))
+			dot.Stdout = os.Stdout
+			dot.Stderr = os.Stderr
+			cobra.CheckErr(errors.WithMessage(dot.Run(), "rendering svg needs graphviz installed"))
+		default:
+			fmt.Print(g.DOT(s.Name))
+		}
+	},
+	Args: cobra.MaximumNArgs(1),
+}
+
+// baseImages returns the base image overrides of the stack, the stack file is optional
+func baseImages() map[string]string {
+	s, err := stack.FromOptions()
+	if err != nil {
+		if !stack.IsNotFound(err) {
+			cobra.CheckErr(err)
+		}
+		return nil
+	}
+	return s.BaseImages
+}
+
 func RootCommand() *cobra.Command {
 	stackCreateCmd.Flags().BoolVarP(&force, "force", "f", false, "force stack creation, even in non-empty directories.")
 	stackCmd.AddCommand(stackCreateCmd)
 
+	stackInitCmd.Flags().BoolVarP(&force, "force", "f", false, "write the project, even into a non-empty directory.")
+	stackCmd.AddCommand(stackInitCmd)
+
+	stackDescribeCmd.Flags().BoolVar(&noCache, "no-cache", false, "collect the resources of every handler, ignoring the results of previous runs")
+	stackDescribeCmd.Flags().BoolVar(&native, "native", false, "collect javascript, typescript and go handlers with the host's toolchain instead of in containers, when it is installed")
 	stack.AddOptions(stackDescribeCmd)
 	stackCmd.AddCommand(stackDescribeCmd)
+
+	stack.AddOptions(stackLintCmd)
+	stackCmd.AddCommand(stackLintCmd)
+
+	stackSnapshotCmd.Flags().BoolVar(&snapshotCheck, "check", false, "compare the resource graph to the snapshot instead of writing it, exiting non-zero when they differ")
+	stack.AddOptions(stackSnapshotCmd)
+	stackCmd.AddCommand(stackSnapshotCmd)
+
+	stackGraphCmd.Flags().Var(pflagext.NewStringEnumVar(&graphFormat, graphFormats, "dot"), "format", "the format to render, one of "+strings.Join(graphFormats, ", "))
+	stackGraphCmd.Flags().BoolVar(&noCache, "no-cache", false, "collect the resources of every handler, ignoring the results of previous runs")
+	stackGraphCmd.Flags().BoolVar(&native, "native", false, "collect javascript, typescript and go handlers with the host's toolchain instead of in containers, when it is installed")
+	stack.AddOptions(stackGraphCmd)
+	stackCmd.AddCommand(stackGraphCmd)
 	return stackCmd
 }
 
@@ -0,0 +1,253 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codeconfig
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/nitrictech/nitric/pkg/api/nitric/v1"
+)
+
+const cacheFile = ".nitric/collect-cache.json"
+
+// files that change the dependencies of every handler, so they are part of every handler's hash
+var manifestFiles = map[string]bool{
+	"package.json":      true,
+	"package-lock.json": true,
+	"yarn.lock":         true,
+	"requirements.txt":  true,
+	"Pipfile.lock":      true,
+	"go.mod":            true,
+	"go.sum":            true,
+}
+
+var ignoredDirs = map[string]bool{
+	"node_modules": true,
+	".nitric":      true,
+	".git":         true,
+	"__pycache__":  true,
+	"vendor":       true,
+}
+
+// cacheEntry is the collected dependencies of a handler, the resources are stored in their protobuf encoding
+type cacheEntry struct {
+	Hash          string            `json:"hash"`
+	ApiWorkers    [][]byte          `json:"apiWorkers,omitempty"`
+	Subscriptions [][]byte          `json:"subscriptions,omitempty"`
+	Schedules     [][]byte          `json:"schedules,omitempty"`
+	Buckets       map[string][]byte `json:"buckets,omitempty"`
+	Topics        map[string][]byte `json:"topics,omitempty"`
+	Collections   map[string][]byte `json:"collections,omitempty"`
+	Queues        map[string][]byte `json:"queues,omitempty"`
+	Policies      [][]byte          `json:"policies,omitempty"`
+}
+
+// sourceHash hashes the handler, the manifests and any source files shared between handlers (files
+// with the handler's extension that aren't handlers themselves), so editing one handler only invalidates that handler
+func sourceHash(stackPath, handler string, handlers map[string]bool) (string, error) {
+	h := sha256.New()
+	ext := filepath.Ext(handler)
+	err := filepath.Walk(stackPath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if ignoredDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(stackPath, p)
+		if err != nil {
+			return err
+		}
+		if rel != handler && !manifestFiles[info.Name()] && (filepath.Ext(rel) != ext || handlers[rel]) {
+			return nil
+		}
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		h.Write([]byte(rel))
+		h.Write(b)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func loadCache(stackPath string) map[string]*cacheEntry {
+	cache := map[string]*cacheEntry{}
+	b, err := ioutil.ReadFile(filepath.Join(stackPath, cacheFile))
+	if err != nil {
+		return cache
+	}
+	// a corrupt cache is the same as no cache
+	if json.Unmarshal(b, &cache) != nil {
+		return map[string]*cacheEntry{}
+	}
+	return cache
+}
+
+func saveCache(stackPath string, cache map[string]*cacheEntry) error {
+	b, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	p := filepath.Join(stackPath, cacheFile)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p, b, 0o644)
+}
+
+// cachedFunction returns the cached dependencies of the handler, if they were collected from the same sources
+func cachedFunction(cache map[string]*cacheEntry, handler, hash string) (*FunctionDependencies, bool) {
+	entry, ok := cache[handler]
+	if !ok || entry.Hash != hash {
+		return nil, false
+	}
+	// an entry that can't be decoded is collected again
+	f, err := entry.function()
+	if err != nil {
+		return nil, false
+	}
+	return f, true
+}
+
+func marshalMessage(m proto.Message) []byte {
+	// marshalling a message that was received over grpc can't fail
+	b, _ := proto.Marshal(m)
+	return b
+}
+
+func newCacheEntry(hash string, f *FunctionDependencies) *cacheEntry {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	e := &cacheEntry{
+		Hash:        hash,
+		Buckets:     map[string][]byte{},
+		Topics:      map[string][]byte{},
+		Collections: map[string][]byte{},
+		Queues:      map[string][]byte{},
+	}
+	for _, a := range f.apis {
+		for _, w := range a.workers {
+			e.ApiWorkers = append(e.ApiWorkers, marshalMessage(w))
+		}
+	}
+	for _, s := range f.subscriptions {
+		e.Subscriptions = append(e.Subscriptions, marshalMessage(s))
+	}
+	for _, s := range f.schedules {
+		e.Schedules = append(e.Schedules, marshalMessage(s))
+	}
+	for k, v := range f.buckets {
+		e.Buckets[k] = marshalMessage(v)
+	}
+	for k, v := range f.topics {
+		e.Topics[k] = marshalMessage(v)
+	}
+	for k, v := range f.collections {
+		e.Collections[k] = marshalMessage(v)
+	}
+	for k, v := range f.queues {
+		e.Queues[k] = marshalMessage(v)
+	}
+	for _, p := range f.policies {
+		e.Policies = append(e.Policies, marshalMessage(p))
+	}
+	return e
+}
+
+// function rebuilds the collected dependencies of the handler
+func (e *cacheEntry) function() (*FunctionDependencies, error) {
+	f := NewFunction()
+	for _, b := range e.ApiWorkers {
+		w := &pb.ApiWorker{}
+		if err := proto.Unmarshal(b, w); err != nil {
+			return nil, err
+		}
+		if err := f.AddApiHandler(w); err != nil {
+			return nil, err
+		}
+	}
+	for _, b := range e.Subscriptions {
+		s := &pb.SubscriptionWorker{}
+		if err := proto.Unmarshal(b, s); err != nil {
+			return nil, err
+		}
+		if err := f.AddSubscriptionHandler(s); err != nil {
+			return nil, err
+		}
+	}
+	for _, b := range e.Schedules {
+		s := &pb.ScheduleWorker{}
+		if err := proto.Unmarshal(b, s); err != nil {
+			return nil, err
+		}
+		if err := f.AddScheduleHandler(s); err != nil {
+			return nil, err
+		}
+	}
+	for k, b := range e.Buckets {
+		r := &pb.BucketResource{}
+		if err := proto.Unmarshal(b, r); err != nil {
+			return nil, err
+		}
+		f.AddBucket(k, r)
+	}
+	for k, b := range e.Topics {
+		r := &pb.TopicResource{}
+		if err := proto.Unmarshal(b, r); err != nil {
+			return nil, err
+		}
+		f.AddTopic(k, r)
+	}
+	for k, b := range e.Collections {
+		r := &pb.CollectionResource{}
+		if err := proto.Unmarshal(b, r); err != nil {
+			return nil, err
+		}
+		f.AddCollection(k, r)
+	}
+	for k, b := range e.Queues {
+		r := &pb.QueueResource{}
+		if err := proto.Unmarshal(b, r); err != nil {
+			return nil, err
+		}
+		f.AddQueue(k, r)
+	}
+	for _, b := range e.Policies {
+		p := &pb.PolicyResource{}
+		if err := proto.Unmarshal(b, p); err != nil {
+			return nil, err
+		}
+		f.AddPolicy(p)
+	}
+	return f, nil
+}
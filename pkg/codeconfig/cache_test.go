@@ -0,0 +1,139 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codeconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "github.com/nitrictech/nitric/pkg/api/nitric/v1"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSourceHash(t *testing.T) {
+	handlers := map[string]bool{"functions/orders.ts": true, "functions/carts.ts": true}
+	tests := []struct {
+		name        string
+		change      map[string]string
+		wantChanged bool
+	}{
+		{name: "unchanged"},
+		{name: "handler", change: map[string]string{"functions/orders.ts": "// edited"}, wantChanged: true},
+		{name: "other handler", change: map[string]string{"functions/carts.ts": "// edited"}},
+		{name: "shared source", change: map[string]string{"common/db.ts": "// edited"}, wantChanged: true},
+		{name: "manifest", change: map[string]string{"package.json": "{}"}, wantChanged: true},
+		{name: "other runtime", change: map[string]string{"scripts/seed.py": "# edited"}},
+		{name: "ignored dir", change: map[string]string{"node_modules/dep/index.ts": "// edited"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{
+				"functions/orders.ts":       "// orders",
+				"functions/carts.ts":        "// carts",
+				"common/db.ts":              "// db",
+				"package.json":              "{\"name\": \"shop\"}",
+				"scripts/seed.py":           "# seed",
+				"node_modules/dep/index.ts": "// dep",
+			})
+			before, err := sourceHash(dir, "functions/orders.ts", handlers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeFiles(t, dir, tt.change)
+			after, err := sourceHash(dir, "functions/orders.ts", handlers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed := before != after; changed != tt.wantChanged {
+				t.Errorf("sourceHash() changed = %v, want %v", changed, tt.wantChanged)
+			}
+		})
+	}
+}
+
+func TestCachedFunction(t *testing.T) {
+	f := NewFunction()
+	if err := f.AddSubscriptionHandler(&pb.SubscriptionWorker{Topic: "created"}); err != nil {
+		t.Fatal(err)
+	}
+	f.AddBucket("images", &pb.BucketResource{})
+
+	cache := map[string]*cacheEntry{
+		"functions/orders.ts": newCacheEntry("abc", f),
+		"functions/carts.ts":  {Hash: "def", Subscriptions: [][]byte{[]byte("not a message")}},
+	}
+
+	tests := []struct {
+		name    string
+		handler string
+		hash    string
+		wantHit bool
+	}{
+		{name: "hit", handler: "functions/orders.ts", hash: "abc", wantHit: true},
+		{name: "sources changed", handler: "functions/orders.ts", hash: "xyz"},
+		{name: "not cached", handler: "functions/list.ts", hash: "abc"},
+		{name: "corrupt entry", handler: "functions/carts.ts", hash: "def"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, hit := cachedFunction(cache, tt.handler, tt.hash)
+			if hit != tt.wantHit {
+				t.Fatalf("cachedFunction() hit = %v, want %v", hit, tt.wantHit)
+			}
+			if !hit {
+				return
+			}
+			if got.subscriptions["created"] == nil || got.buckets["images"] == nil {
+				t.Errorf("cachedFunction() did not restore the dependencies, got %+v", got)
+			}
+		})
+	}
+}
+
+func TestLoadCache(t *testing.T) {
+	dir := t.TempDir()
+	if got := loadCache(dir); len(got) != 0 {
+		t.Errorf("loadCache() without a cache file = %v, want empty", got)
+	}
+
+	want := map[string]*cacheEntry{"functions/orders.ts": newCacheEntry("abc", NewFunction())}
+	if err := saveCache(dir, want); err != nil {
+		t.Fatal(err)
+	}
+	if got := loadCache(dir); got["functions/orders.ts"] == nil || got["functions/orders.ts"].Hash != "abc" {
+		t.Errorf("loadCache() = %v, want the saved entry", got)
+	}
+
+	writeFiles(t, dir, map[string]string{cacheFile: "{not json"})
+	if got := loadCache(dir); len(got) != 0 {
+		t.Errorf("loadCache() with a corrupt cache file = %v, want empty", got)
+	}
+}
@@ -17,8 +17,11 @@
 package codeconfig
 
 import (
+	"bytes"
 	"fmt"
 	"net"
+	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
 	"runtime"
@@ -51,11 +54,31 @@ type codeConfig struct {
 	functions map[string]*FunctionDependencies
 	stackPath string
 	files     []string
+	names     map[string]string // function names keyed by handler
+	opts      Options
 	lock      sync.RWMutex
 }
 
-func New(stackPath string, globString string) (CodeConfig, error) {
-	files, err := filepath.Glob(filepath.Join(stackPath, globString))
+type Options struct {
+	// Collect every handler, instead of reusing the cached dependencies of unchanged handlers
+	NoCache bool
+
+	// Run handlers with the host's toolchain when it is installed, instead of in containers
+	Native bool
+}
+
+// New - creates the code config of the handlers matching the glob, collected dependencies are cached
+// in the stack's .nitric directory and reused for unchanged handlers
+func New(stackPath string, globString string, opts Options) (CodeConfig, error) {
+	handlers, err := utils.Glob(stackPath, globString)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{}
+	for _, h := range handlers {
+		files = append(files, filepath.Join(stackPath, h))
+	}
+	names, err := utils.HandlerNames(handlers)
 	if err != nil {
 		return nil, err
 	}
@@ -63,6 +86,8 @@ func New(stackPath string, globString string) (CodeConfig, error) {
 	return &codeConfig{
 		stackPath: stackPath,
 		files:     files,
+		names:     names,
+		opts:      opts,
 		functions: map[string]*FunctionDependencies{},
 		lock:      sync.RWMutex{},
 	}, nil
@@ -71,6 +96,9 @@ func New(stackPath string, globString string) (CodeConfig, error) {
 func (c *codeConfig) ImagesToBuild() map[string]string {
 	imagesToBuild := map[string]string{}
 	for _, h := range c.files {
+		if _, ok := utils.NativeCommand(c.stackPath, h); c.opts.Native && ok {
+			continue
+		}
 		rt, _ := utils.NewRunTimeFromFilename(h)
 		imagesToBuild[rt.String()] = rt.DevImageName()
 	}
@@ -82,29 +110,69 @@ func (c *codeConfig) Collect() error {
 	wg := sync.WaitGroup{}
 	errList := utils.NewErrorList()
 
+	handlers := map[string]bool{}
 	for _, f := range c.files {
+		rel, err := filepath.Rel(c.stackPath, f)
+		if err != nil {
+			return err
+		}
+		handlers[rel] = true
+	}
+
+	cache := map[string]*cacheEntry{}
+	if !c.opts.NoCache {
+		cache = loadCache(c.stackPath)
+	}
+	cacheLock := sync.Mutex{}
+
+	for handler := range handlers {
 		wg.Add(1)
 
 		// run files in parallel
-		go func(file string) {
+		go func(handler string) {
 			defer wg.Done()
-			rel, err := filepath.Rel(c.stackPath, file)
+			hash, err := sourceHash(c.stackPath, handler, handlers)
 			if err != nil {
 				errList.Add(err)
 				return
 			}
 
-			err = c.collectOne(rel)
+			cacheLock.Lock()
+			fun, ok := cachedFunction(cache, handler, hash)
+			cacheLock.Unlock()
+			if ok {
+				c.addFunction(fun, handler)
+				return
+			}
+
+			err = c.collectOne(handler)
 			if err != nil {
 				errList.Add(err)
 				return
 			}
-		}(f)
+
+			c.lock.RLock()
+			entry := newCacheEntry(hash, c.functions[handler])
+			c.lock.RUnlock()
+			cacheLock.Lock()
+			cache[handler] = entry
+			cacheLock.Unlock()
+		}(handler)
 	}
 
 	wg.Wait()
 
-	return errList.Aggregate()
+	if err := errList.Aggregate(); err != nil {
+		return err
+	}
+
+	// forget handlers that no longer match
+	for handler := range cache {
+		if !handlers[handler] {
+			delete(cache, handler)
+		}
+	}
+	return saveCache(c.stackPath, cache)
 }
 
 type apiHandler struct {
@@ -132,7 +200,7 @@ func (c *codeConfig) apiSpec(api string) (*openapi3.T, error) {
 	for handler, f := range c.functions {
 		for _, w := range f.apis[api].workers {
 			workers = append(workers, &apiHandler{
-				target: containerNameFromHandler(handler),
+				target: c.names[handler],
 				worker: w,
 			})
 		}
@@ -185,7 +253,7 @@ func (c *codeConfig) apiSpec(api string) (*openapi3.T, error) {
 					Extensions: map[string]interface{}{
 						"x-nitric-target": map[string]interface{}{
 							"type": "function",
-							"name": containerNameFromHandler(w.target),
+							"name": w.target,
 						},
 					},
 				},
@@ -220,6 +288,19 @@ func (c *codeConfig) collectOne(handler string) error {
 		errChan <- grpcSrv.Serve(lis)
 	}(errChan)
 
+	if cmd, ok := utils.NativeCommand(c.stackPath, handler); c.opts.Native && ok {
+		err = c.runNative(cmd, port)
+		grpcSrv.Stop()
+		if srvErr := <-errChan; err == nil {
+			err = srvErr
+		}
+		if err != nil {
+			return err
+		}
+		c.addFunction(fun, handler)
+		return nil
+	}
+
 	// run the handler in a container
 	// Specify the service bind as the port with the docker gateway IP (running in bridge mode)
 	ce, err := containerengine.Discover()
@@ -252,7 +333,7 @@ func (c *codeConfig) collectOne(handler string) error {
 		// Set the address to the bound port
 		Env: []string{fmt.Sprintf("SERVICE_ADDRESS=host.docker.internal:%d", port)},
 		Cmd: strslice.StrSlice{"-T", handler},
-	}, hostConfig, nil, containerNameFromHandler(handler))
+	}, hostConfig, nil, c.names[handler])
 	if err != nil {
 		return err
 	}
@@ -286,8 +367,19 @@ func (c *codeConfig) collectOne(handler string) error {
 	return errs.Aggregate()
 }
 
-func containerNameFromHandler(handler string) string {
-	return strings.Replace(path.Base(handler), path.Ext(handler), "", 1)
+// runNative runs the handler with the host's toolchain until it exits
+func (c *codeConfig) runNative(cmd []string, port int) error {
+	proc := exec.Command(cmd[0], cmd[1:]...)
+	proc.Dir = c.stackPath
+	proc.Env = append(os.Environ(), fmt.Sprintf("SERVICE_ADDRESS=localhost:%d", port))
+	out := &bytes.Buffer{}
+	proc.Stdout = out
+	proc.Stderr = out
+	err := proc.Run()
+	if err != nil {
+		return fmt.Errorf("error executing %s: %v\n%s", strings.Join(cmd, " "), err, out.String())
+	}
+	return nil
 }
 
 func (c *codeConfig) addFunction(fun *FunctionDependencies, handler string) {
@@ -313,7 +405,7 @@ func (c *codeConfig) ToStack() (*stack.Stack, error) {
 	}
 	errs := utils.NewErrorList()
 	for handler, f := range c.functions {
-		name := containerNameFromHandler(handler)
+		name := c.names[handler]
 		topicTriggers := make([]string, 0, len(f.subscriptions)+len(f.schedules))
 
 		for k := range f.apis {
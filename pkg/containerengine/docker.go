@@ -25,6 +25,7 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -65,7 +66,11 @@ func newDocker() (ContainerEngine, error) {
 	return &docker{cli: cli}, err
 }
 
-func (d *docker) Build(dockerfile, srcPath, imageTag string, buildArgs map[string]string) error {
+func (d *docker) Build(dockerfile, srcPath, imageTag string, buildOpts BuildOptions) error {
+	if len(buildOpts.CacheFrom) > 0 || len(buildOpts.CacheTo) > 0 {
+		return d.buildx(dockerfile, srcPath, imageTag, buildOpts)
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), buildTimeout())
 	defer cancel()
 
@@ -100,10 +105,29 @@ func (d *docker) Build(dockerfile, srcPath, imageTag string, buildArgs map[strin
 	opts := types.ImageBuildOptions{
 		SuppressOutput: false,
 		Dockerfile:     dockerfile,
-		Tags:           []string{imageTag},
+		Tags:           []string{},
 		Remove:         true,
 		ForceRemove:    true,
 		PullParent:     true,
+		Platform:       buildOpts.Platform,
+		Labels:         buildOpts.Labels,
+		BuildArgs:      map[string]*string{},
+	}
+	if imageTag != "" {
+		opts.Tags = append(opts.Tags, imageTag)
+	}
+	for k, v := range buildOpts.BuildArgs {
+		v := v
+		opts.BuildArgs[k] = &v
+	}
+	// credentials for private base images
+	auths, err := registryAuths()
+	if err != nil {
+		return err
+	}
+	opts.AuthConfigs = map[string]types.AuthConfig{}
+	for _, a := range auths {
+		opts.AuthConfigs[a.ServerAddress] = a.authConfig()
 	}
 	res, err := d.cli.ImageBuild(ctx, &dockerBuildContext, opts)
 	if err != nil {
@@ -114,6 +138,46 @@ func (d *docker) Build(dockerfile, srcPath, imageTag string, buildArgs map[strin
 	return print(res.Body)
 }
 
+// buildx builds with the buildx cli plugin, the build API can't import or export BuildKit caches
+func (d *docker) buildx(dockerfile, srcPath, imageTag string, buildOpts BuildOptions) error {
+	ctx, cancel := context.WithTimeout(context.Background(), buildTimeout())
+	defer cancel()
+
+	if !filepath.IsAbs(dockerfile) {
+		dockerfile = filepath.Join(srcPath, dockerfile)
+	}
+	args := []string{"buildx", "build", "--load", "--pull", "-f", dockerfile}
+	if imageTag != "" {
+		args = append(args, "-t", imageTag)
+	}
+	if buildOpts.Platform != "" {
+		args = append(args, "--platform", buildOpts.Platform)
+	}
+	for k, v := range buildOpts.BuildArgs {
+		args = append(args, "--build-arg", k+"="+v)
+	}
+	for k, v := range buildOpts.Labels {
+		args = append(args, "--label", k+"="+v)
+	}
+	for _, c := range buildOpts.CacheFrom {
+		args = append(args, "--cache-from", c)
+	}
+	for _, c := range buildOpts.CacheTo {
+		args = append(args, "--cache-to", c)
+	}
+	args = append(args, srcPath)
+
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cleanup, err := WithRegistryAuth(cmd)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	return errors.WithMessage(cmd.Run(), "docker buildx build")
+}
+
 type ErrorLine struct {
 	Error       string      `json:"error"`
 	ErrorDetail ErrorDetail `json:"errorDetail"`
@@ -173,7 +237,18 @@ func (d *docker) ListImages(stackName, containerName string) ([]Image, error) {
 }
 
 func (d *docker) Pull(rawImage string) error {
-	resp, err := d.cli.ImagePull(context.Background(), rawImage, types.ImagePullOptions{})
+	opts := types.ImagePullOptions{}
+	auth, err := RegistryCredentials(RegistryHost(rawImage))
+	if err != nil {
+		return errors.WithMessage(err, "Pull")
+	}
+	if auth != nil {
+		opts.RegistryAuth, err = auth.encoded()
+		if err != nil {
+			return err
+		}
+	}
+	resp, err := d.cli.ImagePull(context.Background(), rawImage, opts)
 	if err != nil {
 		return errors.WithMessage(err, "Pull")
 	}
@@ -182,6 +257,34 @@ func (d *docker) Pull(rawImage string) error {
 	return nil
 }
 
+func (d *docker) Tag(image, target string) error {
+	return d.cli.ImageTag(context.Background(), image, target)
+}
+
+// Push pushes the image with the credentials, without them the docker cli pushes it with the credentials of docker login
+func (d *docker) Push(image string, auth *RegistryAuth) error {
+	if auth == nil {
+		cmd := exec.Command("docker", "push", image)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		return errors.WithMessage(cmd.Run(), "docker push")
+	}
+	return d.push(image, auth)
+}
+
+func (d *docker) push(image string, auth *RegistryAuth) error {
+	encoded, err := auth.encoded()
+	if err != nil {
+		return err
+	}
+	resp, err := d.cli.ImagePush(context.Background(), image, types.ImagePushOptions{RegistryAuth: encoded})
+	if err != nil {
+		return errors.WithMessage(err, "Push")
+	}
+	defer resp.Close()
+	return print(resp)
+}
+
 func (d *docker) NetworkCreate(name string) error {
 	_, err := d.cli.NetworkInspect(context.Background(), name, types.NetworkInspectOptions{})
 	if err == nil {
@@ -224,19 +327,21 @@ func (d *docker) ContainersListByLabel(match map[string]string) ([]types.Contain
 	return d.cli.ContainerList(context.Background(), opts)
 }
 
-func (d *docker) RemoveByLabel(name, value string) error {
+func (d *docker) RemoveByLabel(match map[string]string) error {
 	opts := types.ContainerListOptions{
 		All:     true,
 		Filters: filters.NewArgs(),
 	}
-	opts.Filters.Add("label", fmt.Sprintf("%s=%s", name, value))
+	for k, v := range match {
+		opts.Filters.Add("label", fmt.Sprintf("%s=%s", k, v))
+	}
 
 	res, err := d.cli.ContainerList(context.Background(), opts)
 	if err != nil {
 		return err
 	}
 	for _, con := range res {
-		err = d.cli.ContainerRemove(context.Background(), con.ID, types.ContainerRemoveOptions{Force: true})
+		err = d.RemoveContainer(con.ID)
 		if err != nil {
 			return err
 		}
@@ -244,6 +349,14 @@ func (d *docker) RemoveByLabel(name, value string) error {
 	return nil
 }
 
+func (d *docker) RemoveContainer(nameOrID string) error {
+	return d.cli.ContainerRemove(context.Background(), nameOrID, types.ContainerRemoveOptions{Force: true})
+}
+
+func (d *docker) Logs(nameOrID string, opts types.ContainerLogsOptions) (io.ReadCloser, error) {
+	return d.cli.ContainerLogs(context.Background(), nameOrID, opts)
+}
+
 func (d *docker) ContainerWait(containerID string, condition container.WaitCondition) (<-chan container.ContainerWaitOKBody, <-chan error) {
 	return d.cli.ContainerWait(context.Background(), containerID, condition)
 }
@@ -0,0 +1,576 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containerengine
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/go-connections/nat"
+	"github.com/pkg/errors"
+)
+
+// nerdctl drives containerd through the nerdctl cli, as shipped with Rancher Desktop and Lima.
+// containerd doesn't serve a docker compatible API so, unlike podman, the docker client can't be reused.
+type nerdctl struct {
+	bin string
+}
+
+var _ ContainerEngine = &nerdctl{}
+
+func newNerdctl() (ContainerEngine, error) {
+	bin, err := exec.LookPath("nerdctl")
+	if err != nil {
+		return nil, err
+	}
+
+	err = exec.Command(bin, "ps").Run()
+	if err != nil {
+		fmt.Println("containerd not running, please start it (e.g. Rancher Desktop or 'limactl start')..")
+		return nil, err
+	}
+	fmt.Println("nerdctl found")
+
+	return &nerdctl{bin: bin}, nil
+}
+
+// run executes a nerdctl command and returns its stdout, the stderr is included in any error.
+func (n *nerdctl) run(ctx context.Context, args ...string) ([]byte, error) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, n.bin, args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	err := cmd.Run()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "nerdctl %s: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// stream executes a nerdctl command with its output going to the terminal, nerdctl reads the credentials of
+// private registries (e.g. for base images) from the docker config
+func (n *nerdctl) stream(ctx context.Context, args ...string) error {
+	return n.streamWithAuth(ctx, nil, args...)
+}
+
+// streamWithAuth is stream with additional registry credentials, these are only written to the temporary docker config
+func (n *nerdctl) streamWithAuth(ctx context.Context, auths []RegistryAuth, args ...string) error {
+	cmd := exec.CommandContext(ctx, n.bin, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cleanup, err := WithRegistryAuth(cmd, auths...)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	return errors.WithMessagef(cmd.Run(), "nerdctl %s", args[0])
+}
+
+func (n *nerdctl) Build(dockerfile, srcPath, imageTag string, buildOpts BuildOptions) error {
+	ctx, cancel := context.WithTimeout(context.Background(), buildTimeout())
+	defer cancel()
+
+	if !filepath.IsAbs(dockerfile) {
+		dockerfile = filepath.Join(srcPath, dockerfile)
+	}
+	return n.stream(ctx, nerdctlBuildArgs(dockerfile, srcPath, imageTag, buildOpts)...)
+}
+
+func nerdctlBuildArgs(dockerfile, srcPath, imageTag string, buildOpts BuildOptions) []string {
+	args := []string{"build", "-f", dockerfile}
+	if imageTag != "" {
+		args = append(args, "-t", imageTag)
+	}
+	if buildOpts.Platform != "" {
+		args = append(args, "--platform", buildOpts.Platform)
+	}
+	for _, k := range sortedKeys(buildOpts.BuildArgs) {
+		args = append(args, "--build-arg", k+"="+buildOpts.BuildArgs[k])
+	}
+	for _, k := range sortedKeys(buildOpts.Labels) {
+		args = append(args, "--label", k+"="+buildOpts.Labels[k])
+	}
+	for _, c := range buildOpts.CacheFrom {
+		args = append(args, "--cache-from", c)
+	}
+	for _, c := range buildOpts.CacheTo {
+		args = append(args, "--cache-to", c)
+	}
+	return append(args, srcPath)
+}
+
+type nerdctlImage struct {
+	ID         string `json:"ID"`
+	Repository string `json:"Repository"`
+	Tag        string `json:"Tag"`
+	CreatedAt  string `json:"CreatedAt"`
+}
+
+func (n *nerdctl) ListImages(stackName, containerName string) ([]Image, error) {
+	out, err := n.run(context.Background(), "images", "--format", "{{json .}}", "--filter", fmt.Sprintf("reference=%s-%s-*", stackName, containerName))
+	if err != nil {
+		return nil, err
+	}
+
+	imgs := []Image{}
+	err = eachJSONLine(out, func(line []byte) error {
+		i := nerdctlImage{}
+		if err := json.Unmarshal(line, &i); err != nil {
+			return err
+		}
+		imgs = append(imgs, Image{
+			ID:         strings.TrimPrefix(i.ID, "sha256:"),
+			Repository: i.Repository,
+			Tag:        i.Tag,
+			CreatedAt:  i.CreatedAt,
+		})
+		return nil
+	})
+	return imgs, err
+}
+
+func (n *nerdctl) Pull(rawImage string) error {
+	return errors.WithMessage(n.stream(context.Background(), "pull", rawImage), "Pull")
+}
+
+func (n *nerdctl) Tag(image, target string) error {
+	_, err := n.run(context.Background(), "tag", image, target)
+	return err
+}
+
+// Push uses the credentials when given, otherwise the credentials of nerdctl login are used.
+// The credentials are only written to a temporary docker config, never the user's own
+func (n *nerdctl) Push(image string, auth *RegistryAuth) error {
+	auths := []RegistryAuth{}
+	if auth != nil {
+		auths = append(auths, *auth)
+	}
+	return n.streamWithAuth(context.Background(), auths, "push", image)
+}
+
+func (n *nerdctl) NetworkCreate(name string) error {
+	_, err := n.run(context.Background(), "network", "inspect", name)
+	if err == nil {
+		// it already exists, no need to create.
+		return nil
+	}
+	_, err = n.run(context.Background(), "network", "create", name)
+	return err
+}
+
+func (n *nerdctl) ContainerCreate(config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, name string) (string, error) {
+	args, err := nerdctlCreateArgs(config, hostConfig, networkingConfig, name)
+	if err != nil {
+		return "", errors.WithMessage(err, "ContainerCreate")
+	}
+	out, err := n.run(context.Background(), args...)
+	if err != nil {
+		return "", errors.WithMessage(err, "ContainerCreate")
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// nerdctlCreateArgs translates the docker API container configuration into nerdctl create arguments,
+// only the options used by nitric are supported.
+func nerdctlCreateArgs(config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, name string) ([]string, error) {
+	args := []string{"create"}
+	if name != "" {
+		args = append(args, "--name", name)
+	}
+	for _, e := range config.Env {
+		args = append(args, "-e", e)
+	}
+	for _, k := range sortedKeys(config.Labels) {
+		args = append(args, "--label", k+"="+config.Labels[k])
+	}
+	if len(config.Entrypoint) > 0 {
+		if len(config.Entrypoint) > 1 {
+			return nil, fmt.Errorf("nerdctl only supports a single entrypoint argument, got %v", config.Entrypoint)
+		}
+		args = append(args, "--entrypoint", config.Entrypoint[0])
+	}
+
+	if hostConfig != nil {
+		if hostConfig.AutoRemove {
+			args = append(args, "--rm")
+		}
+		ports := make([]string, 0, len(hostConfig.PortBindings))
+		for p := range hostConfig.PortBindings {
+			ports = append(ports, string(p))
+		}
+		sort.Strings(ports)
+		for _, p := range ports {
+			for _, b := range hostConfig.PortBindings[nat.Port(p)] {
+				hp := b.HostPort
+				if b.HostIP != "" {
+					hp = b.HostIP + ":" + hp
+				}
+				args = append(args, "-p", hp+":"+p)
+			}
+		}
+		for _, m := range hostConfig.Mounts {
+			if m.Type != "bind" {
+				return nil, fmt.Errorf("nerdctl: unsupported mount type %s", m.Type)
+			}
+			v := m.Source + ":" + m.Target
+			if m.ReadOnly {
+				v += ":ro"
+			}
+			args = append(args, "-v", v)
+		}
+		for _, b := range hostConfig.Binds {
+			args = append(args, "-v", b)
+		}
+		for _, h := range hostConfig.ExtraHosts {
+			args = append(args, "--add-host", h)
+		}
+		if hostConfig.NetworkMode != "" {
+			args = append(args, "--network", string(hostConfig.NetworkMode))
+		}
+		if hostConfig.Memory > 0 {
+			args = append(args, "--memory", strconv.FormatInt(hostConfig.Memory, 10))
+		}
+		if hostConfig.NanoCPUs > 0 {
+			args = append(args, "--cpus", strconv.FormatFloat(float64(hostConfig.NanoCPUs)/1e9, 'f', -1, 64))
+		}
+		for _, d := range hostConfig.DeviceRequests {
+			gpus, err := nerdctlGPUs(d)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, "--gpus", gpus)
+		}
+	}
+
+	if networkingConfig != nil {
+		for net, es := range networkingConfig.EndpointsConfig {
+			if hostConfig == nil || string(hostConfig.NetworkMode) != net {
+				args = append(args, "--network", net)
+			}
+			if es != nil && len(es.Aliases) > 0 {
+				// nerdctl has no network aliases, but containers on the same network can resolve each other's hostname.
+				args = append(args, "--hostname", es.Aliases[0])
+			}
+		}
+	}
+
+	args = append(args, config.Image)
+	return append(args, config.Cmd...), nil
+}
+
+func (n *nerdctl) Start(nameOrID string) error {
+	_, err := n.run(context.Background(), "start", nameOrID)
+	return err
+}
+
+func (n *nerdctl) Stop(nameOrID string, timeout *time.Duration) error {
+	args := []string{"stop"}
+	if timeout != nil {
+		args = append(args, "--time", strconv.Itoa(int(timeout.Seconds())))
+	}
+	_, err := n.run(context.Background(), append(args, nameOrID)...)
+	return err
+}
+
+func (n *nerdctl) ContainerWait(containerID string, condition container.WaitCondition) (<-chan container.ContainerWaitOKBody, <-chan error) {
+	resC := make(chan container.ContainerWaitOKBody, 1)
+	errC := make(chan error, 1)
+
+	go func() {
+		out, err := n.run(context.Background(), "wait", containerID)
+		if err != nil {
+			errC <- err
+			return
+		}
+		code, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+		if err != nil {
+			errC <- errors.WithMessage(err, "nerdctl wait")
+			return
+		}
+		resC <- container.ContainerWaitOKBody{StatusCode: code}
+	}()
+
+	return resC, errC
+}
+
+func (n *nerdctl) CopyFromArchive(nameOrID string, path string, reader io.Reader) error {
+	// nerdctl cp doesn't read archives from stdin, so extract it to a temporary directory first.
+	dir, err := os.MkdirTemp("", "nitric-cp-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	cmd := exec.Command("tar", "-x", "-C", dir)
+	cmd.Stdin = reader
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return errors.WithMessagef(err, "extracting archive: %s", strings.TrimSpace(string(out)))
+	}
+
+	_, err = n.run(context.Background(), "cp", dir+"/.", nameOrID+":"+path)
+	return err
+}
+
+// nerdctlGPUs translates a device request into the value of the --gpus option, only GPU requests are supported
+func nerdctlGPUs(d container.DeviceRequest) (string, error) {
+	gpu := false
+	for _, caps := range d.Capabilities {
+		for _, c := range caps {
+			gpu = gpu || c == "gpu"
+		}
+	}
+	if !gpu {
+		return "", fmt.Errorf("nerdctl: unsupported device request %v, only gpus are supported", d.Capabilities)
+	}
+	switch {
+	case len(d.DeviceIDs) > 0:
+		return "device=" + strings.Join(d.DeviceIDs, ","), nil
+	case d.Count < 0:
+		return "all", nil
+	case d.Count > 0:
+		return strconv.Itoa(d.Count), nil
+	default:
+		return "", fmt.Errorf("nerdctl: gpu request without a count or device ids")
+	}
+}
+
+type nerdctlContainer struct {
+	ID     string `json:"ID"`
+	Names  string `json:"Names"`
+	Image  string `json:"Image"`
+	Status string `json:"Status"`
+	// State is only reported by newer versions of nerdctl
+	State  string `json:"State"`
+	Ports  string `json:"Ports"`
+	Labels string `json:"Labels"`
+}
+
+func (n *nerdctl) ContainersListByLabel(match map[string]string) ([]types.Container, error) {
+	args := []string{"ps", "-a", "--no-trunc", "--format", "{{json .}}"}
+	for _, k := range sortedKeys(match) {
+		args = append(args, "--filter", fmt.Sprintf("label=%s=%s", k, match[k]))
+	}
+	out, err := n.run(context.Background(), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	cons := []types.Container{}
+	err = eachJSONLine(out, func(line []byte) error {
+		c := nerdctlContainer{}
+		if err := json.Unmarshal(line, &c); err != nil {
+			return err
+		}
+		cons = append(cons, types.Container{
+			ID:     c.ID,
+			Names:  []string{"/" + c.Names},
+			Image:  c.Image,
+			Status: c.Status,
+			State:  nerdctlState(c),
+			Ports:  nerdctlParsePorts(c.Ports),
+			Labels: nerdctlParseLabels(c.Labels),
+		})
+		return nil
+	})
+	return cons, err
+}
+
+// nerdctlState returns the docker state of the container (e.g. running or exited),
+// derived from its status (e.g. Up 2 minutes or Exited (0) 2 minutes ago) when nerdctl doesn't report it
+func nerdctlState(c nerdctlContainer) string {
+	if c.State != "" {
+		return strings.ToLower(c.State)
+	}
+	status := strings.ToLower(c.Status)
+	switch {
+	case status == "":
+		return ""
+	case strings.HasPrefix(status, "up"):
+		if strings.Contains(status, "(paused)") {
+			return "paused"
+		}
+		return "running"
+	default:
+		return strings.SplitN(status, " ", 2)[0]
+	}
+}
+
+// nerdctlParsePorts parses the ports column of nerdctl ps, e.g. 0.0.0.0:8080->9001/tcp, 0.0.0.0:8081->9002/tcp
+func nerdctlParsePorts(s string) []types.Port {
+	ports := []types.Port{}
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		port := types.Port{Type: "tcp"}
+		if i := strings.LastIndex(p, "/"); i >= 0 {
+			port.Type = p[i+1:]
+			p = p[:i]
+		}
+		private := p
+		if parts := strings.SplitN(p, "->", 2); len(parts) == 2 {
+			private = parts[1]
+			host := parts[0]
+			if i := strings.LastIndex(host, ":"); i >= 0 {
+				port.IP = host[:i]
+				host = host[i+1:]
+			}
+			pub, _ := strconv.Atoi(host)
+			port.PublicPort = uint16(pub)
+		}
+		priv, _ := strconv.Atoi(private)
+		port.PrivatePort = uint16(priv)
+		ports = append(ports, port)
+	}
+	return ports
+}
+
+// nerdctlParseLabels parses the labels column of nerdctl ps, e.g. x-nitric-stack=demo,x-nitric-type=function
+func nerdctlParseLabels(s string) map[string]string {
+	labels := map[string]string{}
+	for _, l := range strings.Split(s, ",") {
+		parts := strings.SplitN(l, "=", 2)
+		if len(parts) == 2 {
+			labels[parts[0]] = parts[1]
+		}
+	}
+	return labels
+}
+
+func (n *nerdctl) RemoveByLabel(match map[string]string) error {
+	res, err := n.ContainersListByLabel(match)
+	if err != nil {
+		return err
+	}
+	for _, con := range res {
+		err = n.RemoveContainer(con.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *nerdctl) RemoveContainer(nameOrID string) error {
+	_, err := n.run(context.Background(), "rm", "-f", nameOrID)
+	return err
+}
+
+func (n *nerdctl) ContainerExec(containerName string, cmd []string, workingDir string) error {
+	args := []string{"exec"}
+	if workingDir != "" {
+		args = append(args, "--workdir", workingDir)
+	}
+	args = append(args, containerName)
+	_, err := n.run(context.Background(), append(args, cmd...)...)
+	if err != nil {
+		return fmt.Errorf("%s %v failed: %v", containerName, cmd, err)
+	}
+	return nil
+}
+
+// Logs returns the container logs multiplexed in the docker stream format, so they can be read with stdcopy
+// the same way as the logs of the other engines.
+func (n *nerdctl) Logs(nameOrID string, opts types.ContainerLogsOptions) (io.ReadCloser, error) {
+	args := []string{"logs"}
+	if opts.Follow {
+		args = append(args, "--follow")
+	}
+	if opts.Since != "" {
+		args = append(args, "--since", opts.Since)
+	}
+	if opts.Tail != "" {
+		args = append(args, "--tail", opts.Tail)
+	}
+	if opts.Timestamps {
+		args = append(args, "--timestamps")
+	}
+	args = append(args, nameOrID)
+
+	pr, pw := io.Pipe()
+	cmd := exec.Command(n.bin, args...)
+	if opts.ShowStdout {
+		cmd.Stdout = stdcopy.NewStdWriter(pw, stdcopy.Stdout)
+	}
+	if opts.ShowStderr {
+		cmd.Stderr = stdcopy.NewStdWriter(pw, stdcopy.Stderr)
+	}
+	err := cmd.Start()
+	if err != nil {
+		return nil, errors.WithMessage(err, "nerdctl logs")
+	}
+	go func() {
+		pw.CloseWithError(cmd.Wait())
+	}()
+
+	return &cmdReadCloser{ReadCloser: pr, cmd: cmd}, nil
+}
+
+// cmdReadCloser kills the command it reads from when closed, e.g. to stop following logs.
+type cmdReadCloser struct {
+	io.ReadCloser
+	cmd *exec.Cmd
+}
+
+func (c *cmdReadCloser) Close() error {
+	if c.cmd.ProcessState == nil && c.cmd.Process != nil {
+		_ = c.cmd.Process.Kill()
+	}
+	return c.ReadCloser.Close()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// eachJSONLine calls fn with each non empty line of the --format '{{json .}}' output of nerdctl.
+func eachJSONLine(out []byte, fn func([]byte) error) error {
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
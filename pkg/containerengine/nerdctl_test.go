@@ -0,0 +1,239 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containerengine
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+)
+
+func TestNerdctlBuildArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		imageTag string
+		opts     BuildOptions
+		want     []string
+	}{
+		{
+			name:     "tagged",
+			imageTag: "demo-hello",
+			opts:     BuildOptions{Platform: "linux/arm64", BuildArgs: map[string]string{"PROVIDER": "dev"}},
+			want:     []string{"build", "-f", "Dockerfile", "-t", "demo-hello", "--platform", "linux/arm64", "--build-arg", "PROVIDER=dev", "."},
+		},
+		{
+			name: "untagged",
+			want: []string{"build", "-f", "Dockerfile", "."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nerdctlBuildArgs("Dockerfile", ".", tt.imageTag, tt.opts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("nerdctlBuildArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNerdctlCreateArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     *container.Config
+		hostConfig *container.HostConfig
+		netConfig  *network.NetworkingConfig
+		want       []string
+		wantErr    bool
+	}{
+		{
+			name: "function",
+			config: &container.Config{
+				Image:  "demo-hello",
+				Env:    []string{"A=1"},
+				Labels: map[string]string{"b": "2", "a": "1"},
+			},
+			hostConfig: &container.HostConfig{
+				PortBindings: nat.PortMap{"9001/tcp": []nat.PortBinding{{HostPort: "8080"}}},
+				Mounts:       []mount.Mount{{Type: mount.TypeBind, Source: "/tmp/run", Target: "/nitric"}},
+				NetworkMode:  "demo-net",
+				Resources:    container.Resources{Memory: 512 * 1024 * 1024, NanoCPUs: 500000000},
+			},
+			netConfig: &network.NetworkingConfig{
+				EndpointsConfig: map[string]*network.EndpointSettings{"demo-net": {Aliases: []string{"hello"}}},
+			},
+			want: []string{
+				"create", "--name", "demo-hello-dev", "-e", "A=1", "--label", "a=1", "--label", "b=2",
+				"-p", "8080:9001/tcp", "-v", "/tmp/run:/nitric", "--network", "demo-net",
+				"--memory", "536870912", "--cpus", "0.5", "--hostname", "hello", "demo-hello",
+			},
+		},
+		{
+			name:       "entrypoint and cmd",
+			config:     &container.Config{Image: "nitric/dev", Entrypoint: []string{"sh"}, Cmd: []string{"-c", "true"}},
+			hostConfig: &container.HostConfig{AutoRemove: true, ExtraHosts: []string{"host.docker.internal:172.17.0.1"}},
+			want: []string{
+				"create", "--name", "demo-hello-dev", "--entrypoint", "sh", "--rm",
+				"--add-host", "host.docker.internal:172.17.0.1", "nitric/dev", "-c", "true",
+			},
+		},
+		{
+			name:   "gpus",
+			config: &container.Config{Image: "demo-hello"},
+			hostConfig: &container.HostConfig{Resources: container.Resources{DeviceRequests: []container.DeviceRequest{
+				{Count: 2, Capabilities: [][]string{{"gpu"}}},
+				{Count: -1, Capabilities: [][]string{{"gpu"}}},
+				{DeviceIDs: []string{"0", "1"}, Capabilities: [][]string{{"gpu", "utility"}}},
+			}}},
+			want: []string{
+				"create", "--name", "demo-hello-dev", "--gpus", "2", "--gpus", "all", "--gpus", "device=0,1", "demo-hello",
+			},
+		},
+		{
+			name:       "only gpu device requests are supported",
+			config:     &container.Config{Image: "demo-hello"},
+			hostConfig: &container.HostConfig{Resources: container.Resources{DeviceRequests: []container.DeviceRequest{{Count: 1, Capabilities: [][]string{{"tpu"}}}}}},
+			wantErr:    true,
+		},
+		{
+			name:       "volume mounts are not supported",
+			config:     &container.Config{Image: "nitric/dev"},
+			hostConfig: &container.HostConfig{Mounts: []mount.Mount{{Type: mount.TypeVolume, Source: "data", Target: "/data"}}},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nerdctlCreateArgs(tt.config, tt.hostConfig, tt.netConfig, "demo-hello-dev")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("nerdctlCreateArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("nerdctlCreateArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNerdctlState(t *testing.T) {
+	tests := []struct {
+		container nerdctlContainer
+		want      string
+	}{
+		{container: nerdctlContainer{Status: "Up 2 minutes"}, want: "running"},
+		{container: nerdctlContainer{Status: "Up"}, want: "running"},
+		{container: nerdctlContainer{Status: "Up 5 seconds (Paused)"}, want: "paused"},
+		{container: nerdctlContainer{Status: "Exited (0) 2 minutes ago"}, want: "exited"},
+		{container: nerdctlContainer{Status: "Created"}, want: "created"},
+		{container: nerdctlContainer{Status: "Up 2 minutes", State: "Running"}, want: "running"},
+		{container: nerdctlContainer{}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.container.Status, func(t *testing.T) {
+			if got := nerdctlState(tt.container); got != tt.want {
+				t.Errorf("nerdctlState() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNerdctlParsePorts(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []types.Port
+	}{
+		{
+			name: "none",
+			in:   "",
+			want: []types.Port{},
+		},
+		{
+			name: "published",
+			in:   "0.0.0.0:8080->9001/tcp, 0.0.0.0:8081->9002/udp",
+			want: []types.Port{
+				{IP: "0.0.0.0", PublicPort: 8080, PrivatePort: 9001, Type: "tcp"},
+				{IP: "0.0.0.0", PublicPort: 8081, PrivatePort: 9002, Type: "udp"},
+			},
+		},
+		{
+			name: "exposed only",
+			in:   "9001/tcp",
+			want: []types.Port{{PrivatePort: 9001, Type: "tcp"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nerdctlParsePorts(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("nerdctlParsePorts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNerdctlPushAuth(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake nerdctl is a shell script")
+	}
+	dir := t.TempDir()
+	userConfig := filepath.Join(dir, "user")
+	if err := os.Mkdir(userConfig, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(userConfig, "config.json"), []byte(`{"auths": {}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("DOCKER_CONFIG", userConfig)
+	defer os.Unsetenv("DOCKER_CONFIG")
+
+	// the fake nerdctl records the docker config it was given
+	pushed := filepath.Join(dir, "pushed.json")
+	bin := filepath.Join(dir, "nerdctl")
+	script := "#!/bin/sh\ncp \"$DOCKER_CONFIG/config.json\" " + pushed + "\n"
+	if err := ioutil.WriteFile(bin, []byte(script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	n := &nerdctl{bin: bin}
+	err := n.Push("registry.example.com/app:v1", &RegistryAuth{Username: "user", Password: "token", ServerAddress: "registry.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(pushed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "registry.example.com") {
+		t.Errorf("push config = %s, want the registry credentials", b)
+	}
+	b, err = ioutil.ReadFile(filepath.Join(userConfig, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"auths": {}}` {
+		t.Errorf("user config = %s, want it unchanged", b)
+	}
+}
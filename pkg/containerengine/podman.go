@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
 	"strings"
 	"time"
@@ -32,7 +33,8 @@ import (
 	"github.com/pkg/errors"
 )
 
-// use docker client to podman socket.
+// use docker client to podman socket, podman serves a docker compatible API
+// so neither the docker cli nor the podman-docker package are required.
 type podman struct {
 	*docker
 }
@@ -46,28 +48,31 @@ func newPodman() (ContainerEngine, error) {
 		return nil, err
 	}
 
-	// make sure that the podman-docker package has been installed.
+	// the docker cli is not required, but if the actual docker cli is installed as well prefer docker.
 	out := &bytes.Buffer{}
 	cmd = exec.Command("docker", "--version")
 	cmd.Stdout = out
-	err = cmd.Run()
-	if err != nil {
-		return nil, errors.WithMessage(err, "the podman-docker package is required")
-	}
-	if !strings.Contains(out.String(), "podman") {
-		// this is the actual docker cli installed as well, return an error here and just use docker.
+	if cmd.Run() == nil && !strings.Contains(out.String(), "podman") {
 		return nil, errors.New("both podman and docker found, will use docker")
 	}
 
-	//export DOCKER_HOST=unix:///run/user/1000/podman/podman.sock
-	cli, err := client.NewClientWithOpts(client.FromEnv)
+	host, err := podmanHost()
+	if err != nil {
+		return nil, err
+	}
+
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithHost(host), client.WithAPIVersionNegotiation())
 	if err != nil {
 		return nil, err
 	}
 	// Test the connection
 	_, err = cli.ContainerList(context.Background(), types.ContainerListOptions{})
 	if err != nil {
-		fmt.Println("podman socket not running, please execute 'sudo systemctl start podman.socket'")
+		if strings.Contains(host, "/run/user/") {
+			fmt.Println("podman socket not running, please execute 'systemctl --user start podman.socket'")
+		} else {
+			fmt.Println("podman socket not running, please execute 'sudo systemctl start podman.socket'")
+		}
 		return nil, err
 	}
 	fmt.Println("podman found")
@@ -75,8 +80,37 @@ func newPodman() (ContainerEngine, error) {
 	return &podman{docker: &docker{cli: cli}}, err
 }
 
-func (p *podman) Build(dockerfile, path, imageTag string, buildArgs map[string]string) error {
-	return p.docker.Build(dockerfile, path, imageTag, buildArgs)
+// podmanHost returns the address of the podman API socket, which serves the docker compatible API.
+// DOCKER_HOST takes precedence, otherwise podman is asked for its socket (rootless or rootful).
+func podmanHost() (string, error) {
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		return host, nil
+	}
+
+	out := &bytes.Buffer{}
+	cmd := exec.Command("podman", "info", "--format", "{{.Host.RemoteSocket.Path}}")
+	cmd.Stdout = out
+	err := cmd.Run()
+	if err != nil {
+		return "", errors.WithMessage(err, "could not find the podman socket")
+	}
+
+	path := strings.TrimSpace(out.String())
+	if path == "" {
+		return "", errors.New("could not find the podman socket")
+	}
+	if !strings.Contains(path, "://") {
+		path = "unix://" + path
+	}
+	return path, nil
+}
+
+func (p *podman) Build(dockerfile, path, imageTag string, opts BuildOptions) error {
+	// the docker engine imports and exports BuildKit caches with docker buildx, which can't build against podman
+	if len(opts.CacheFrom) > 0 || len(opts.CacheTo) > 0 {
+		return errors.New("build caches are not supported with podman, remove the --cache-from and --cache-to options or the build_cache_from and build_cache_to config")
+	}
+	return p.docker.Build(dockerfile, path, imageTag, opts)
 }
 
 func (p *podman) ListImages(stackName, containerName string) ([]Image, error) {
@@ -115,10 +149,29 @@ func (p *podman) ContainersListByLabel(match map[string]string) ([]types.Contain
 	return p.docker.ContainersListByLabel(match)
 }
 
-func (p *podman) RemoveByLabel(name, value string) error {
-	return p.docker.RemoveByLabel(name, value)
+func (p *podman) RemoveByLabel(match map[string]string) error {
+	return p.docker.RemoveByLabel(match)
+}
+
+func (p *podman) RemoveContainer(nameOrID string) error {
+	return p.docker.RemoveContainer(nameOrID)
 }
 
 func (p *podman) ContainerExec(containerName string, cmd []string, workingDir string) error {
 	return p.docker.ContainerExec(containerName, cmd, workingDir)
 }
+
+func (p *podman) Tag(image, target string) error {
+	return p.docker.Tag(image, target)
+}
+
+// Push pushes the image with the credentials, without them podman pushes it with the credentials of podman login
+func (p *podman) Push(image string, auth *RegistryAuth) error {
+	if auth == nil {
+		cmd := exec.Command("podman", "push", image)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		return errors.WithMessage(cmd.Run(), "podman push")
+	}
+	return p.docker.push(image, auth)
+}
@@ -0,0 +1,93 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containerengine
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPodmanHost(t *testing.T) {
+	tests := []struct {
+		name       string
+		dockerHost string
+		socket     string
+		noPodman   bool
+		want       string
+		wantErr    bool
+	}{
+		{name: "DOCKER_HOST", dockerHost: "tcp://localhost:2375", socket: "/run/podman/podman.sock", want: "tcp://localhost:2375"},
+		{name: "rootless socket", socket: "/run/user/1000/podman/podman.sock", want: "unix:///run/user/1000/podman/podman.sock"},
+		{name: "socket address", socket: "unix:///run/podman/podman.sock", want: "unix:///run/podman/podman.sock"},
+		{name: "no socket", socket: "", wantErr: true},
+		{name: "podman not installed", noPodman: true, wantErr: true},
+	}
+	for _, e := range []string{"PATH", "DOCKER_HOST"} {
+		old, ok := os.LookupEnv(e)
+		defer func(e string) {
+			if ok {
+				os.Setenv(e, old)
+			} else {
+				os.Unsetenv(e)
+			}
+		}(e)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// a fake podman on the PATH that prints the socket path
+			dir := t.TempDir()
+			if !tt.noPodman {
+				script := "#!/bin/sh\necho '" + tt.socket + "'\n"
+				if err := ioutil.WriteFile(filepath.Join(dir, "podman"), []byte(script), 0o700); err != nil {
+					t.Fatal(err)
+				}
+			}
+			os.Setenv("PATH", dir)
+			os.Setenv("DOCKER_HOST", tt.dockerHost)
+
+			got, err := podmanHost()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("podmanHost() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("podmanHost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPodmanBuildCache(t *testing.T) {
+	tests := []struct {
+		name string
+		opts BuildOptions
+	}{
+		{name: "cache from", opts: BuildOptions{CacheFrom: []string{"type=local,src=.nitric/cache/api"}}},
+		{name: "cache to", opts: BuildOptions{CacheTo: []string{"type=registry,ref=ghcr.io/org/cache:api"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &podman{docker: &docker{}}
+			err := p.Build("Dockerfile", ".", "api", tt.opts)
+			if err == nil || !strings.Contains(err.Error(), "not supported with podman") {
+				t.Errorf("Build() error = %v, want the build cache to be rejected", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containerengine
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// dockerHubAuthKey is the key of Docker Hub's credentials in a docker config
+const dockerHubAuthKey = "https://index.docker.io/v1/"
+
+// RegistryAuth are the credentials used to pull from or push to a registry
+type RegistryAuth struct {
+	Username      string `mapstructure:"username"`
+	Password      string `mapstructure:"password"`
+	ServerAddress string `mapstructure:"-"`
+}
+
+// authConfig is the docker API form of the credentials
+func (a *RegistryAuth) authConfig() types.AuthConfig {
+	return types.AuthConfig{
+		Username:      a.Username,
+		Password:      a.Password,
+		ServerAddress: a.ServerAddress,
+	}
+}
+
+// encoded is the credentials in the X-Registry-Auth header form of the docker API
+func (a *RegistryAuth) encoded() (string, error) {
+	b, err := json.Marshal(a.authConfig())
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(b), nil
+}
+
+// RegistryHost returns the host of an image repository, e.g. gcr.io for gcr.io/project/image,
+// repositories without a host are on Docker Hub
+func RegistryHost(repository string) string {
+	parts := strings.SplitN(repository, "/", 2)
+	if len(parts) == 1 || (!strings.ContainsAny(parts[0], ".:") && parts[0] != "localhost") {
+		return "docker.io"
+	}
+	return parts[0]
+}
+
+// RegistryLogin gets a short lived token for ECR, GCR (and Artifact Registry) or ACR from the provider's cli,
+// other registries use the credentials of RegistryCredentials, or the container engine's own login (e.g. docker login)
+// when there are none
+func RegistryLogin(host string) (*RegistryAuth, error) {
+	switch {
+	case strings.HasSuffix(host, ".amazonaws.com") && strings.Contains(host, ".dkr.ecr."):
+		// <account>.dkr.ecr.<region>.amazonaws.com
+		region := strings.Split(host, ".")[3]
+		token, err := registryToken("aws", "ecr", "get-login-password", "--region", region)
+		if err != nil {
+			return nil, err
+		}
+		return &RegistryAuth{Username: "AWS", Password: token, ServerAddress: host}, nil
+	case host == "gcr.io" || strings.HasSuffix(host, ".gcr.io") || strings.HasSuffix(host, "-docker.pkg.dev"):
+		token, err := registryToken("gcloud", "auth", "print-access-token")
+		if err != nil {
+			return nil, err
+		}
+		return &RegistryAuth{Username: "oauth2accesstoken", Password: token, ServerAddress: host}, nil
+	case strings.HasSuffix(host, ".azurecr.io"):
+		name := strings.TrimSuffix(host, ".azurecr.io")
+		token, err := registryToken("az", "acr", "login", "--name", name, "--expose-token", "--output", "tsv", "--query", "accessToken")
+		if err != nil {
+			return nil, err
+		}
+		// acr tokens are accepted with this fixed username
+		return &RegistryAuth{Username: "00000000-0000-0000-0000-000000000000", Password: token, ServerAddress: host}, nil
+	}
+	return RegistryCredentials(host)
+}
+
+func registryToken(name string, args ...string) (string, error) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return "", errors.WithMessagef(err, "registry login with '%s %s': %s", name, strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+	token := strings.TrimSpace(stdout.String())
+	if token == "" {
+		return "", fmt.Errorf("registry login with '%s %s' returned no token", name, strings.Join(args, " "))
+	}
+	return token, nil
+}
+
+// RegistryCredentials returns the credentials for a registry host, nil when there are none
+func RegistryCredentials(host string) (*RegistryAuth, error) {
+	auths, err := registryAuths()
+	if err != nil {
+		return nil, err
+	}
+	if auth, ok := auths[host]; ok {
+		return &auth, nil
+	}
+	return nil, nil
+}
+
+// registryAuths returns the credentials of each registry from the registries config and the docker config
+// (e.g. from docker login), the registries config takes precedence
+func registryAuths() (map[string]RegistryAuth, error) {
+	configured, err := configuredRegistryAuths()
+	if err != nil {
+		return nil, err
+	}
+	return registryAuthsFrom(configured, filepath.Join(dockerConfigDir(), "config.json"))
+}
+
+// configuredRegistryAuths returns the credentials of the registries config, keyed by registry host,
+// passwords can reference env vars, e.g. ${REGISTRY_PASSWORD}
+func configuredRegistryAuths() (map[string]RegistryAuth, error) {
+	configured := map[string]RegistryAuth{}
+	if err := mapstructure.Decode(viper.GetStringMap("registries"), &configured); err != nil {
+		return nil, errors.WithMessage(err, "registries config")
+	}
+	for host, a := range configured {
+		a.Password = os.ExpandEnv(a.Password)
+		a.ServerAddress = host
+		if host == "docker.io" {
+			a.ServerAddress = dockerHubAuthKey
+		}
+		configured[host] = a
+	}
+	return configured, nil
+}
+
+func registryAuthsFrom(configured map[string]RegistryAuth, dockerConfig string) (map[string]RegistryAuth, error) {
+	auths := map[string]RegistryAuth{}
+
+	cfg := struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}{}
+	b, err := ioutil.ReadFile(dockerConfig)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(b, &cfg); err != nil {
+			return nil, errors.WithMessage(err, dockerConfig)
+		}
+	}
+	for key, a := range cfg.Auths {
+		// helpers (credsStore) leave auth empty, the container engine's cli uses those itself
+		decoded, err := base64.StdEncoding.DecodeString(a.Auth)
+		if err != nil || a.Auth == "" {
+			continue
+		}
+		parts := strings.SplitN(string(decoded), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		host := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(key, "https://"), "http://"), "/")
+		if key == dockerHubAuthKey {
+			host = "docker.io"
+		}
+		auths[host] = RegistryAuth{Username: parts[0], Password: parts[1], ServerAddress: key}
+	}
+
+	for host, a := range configured {
+		auths[host] = a
+	}
+	return auths, nil
+}
+
+func dockerConfigDir() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return dir
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".docker")
+}
+
+// WithRegistryAuth gives a cli tool that reads the docker config (docker buildx, nerdctl, cosign) the credentials
+// of the registries config and any others given, through a copy of the docker config rather than its arguments.
+// The returned func removes the copy once the command has finished.
+func WithRegistryAuth(cmd *exec.Cmd, others ...RegistryAuth) (func(), error) {
+	configured, err := configuredRegistryAuths()
+	if err != nil {
+		return nil, err
+	}
+	auths := others
+	for _, a := range configured {
+		auths = append(auths, a)
+	}
+	if len(auths) == 0 {
+		return func() {}, nil
+	}
+
+	dir, err := dockerConfigWith(auths)
+	if err != nil {
+		return nil, err
+	}
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, "DOCKER_CONFIG="+dir)
+	return func() { os.RemoveAll(dir) }, nil
+}
+
+// dockerConfigWith writes a copy of the user's docker config with the credentials added to a temporary directory
+func dockerConfigWith(auths []RegistryAuth) (string, error) {
+	cfg := map[string]interface{}{}
+	b, err := ioutil.ReadFile(filepath.Join(dockerConfigDir(), "config.json"))
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if err == nil {
+		if err := json.Unmarshal(b, &cfg); err != nil {
+			return "", err
+		}
+	}
+	entries, _ := cfg["auths"].(map[string]interface{})
+	if entries == nil {
+		entries = map[string]interface{}{}
+	}
+	helpers, _ := cfg["credHelpers"].(map[string]interface{})
+	if helpers == nil {
+		helpers = map[string]interface{}{}
+	}
+	for _, a := range auths {
+		entries[a.ServerAddress] = map[string]string{
+			"auth": base64.StdEncoding.EncodeToString([]byte(a.Username + ":" + a.Password)),
+		}
+		// docker prefers credential helpers and the credential store to the auths, an empty helper makes it
+		// read the explicit credentials from the auths while other registries keep using the credential store
+		helpers[a.ServerAddress] = ""
+	}
+	cfg["auths"] = entries
+	if len(helpers) > 0 {
+		cfg["credHelpers"] = helpers
+	}
+
+	dir, err := ioutil.TempDir("", "nitric-docker-config-")
+	if err != nil {
+		return "", err
+	}
+	b, err = json.Marshal(cfg)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "config.json"), b, 0o600); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
@@ -0,0 +1,124 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containerengine
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRegistryHost(t *testing.T) {
+	tests := []struct {
+		repository string
+		want       string
+	}{
+		{repository: "nitrictech/cli", want: "docker.io"},
+		{repository: "orders", want: "docker.io"},
+		{repository: "gcr.io/project/orders", want: "gcr.io"},
+		{repository: "localhost:5000/orders", want: "localhost:5000"},
+		{repository: "123456789012.dkr.ecr.us-east-1.amazonaws.com/shop/orders", want: "123456789012.dkr.ecr.us-east-1.amazonaws.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.repository, func(t *testing.T) {
+			if got := RegistryHost(tt.repository); got != tt.want {
+				t.Errorf("RegistryHost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistryAuthsFrom(t *testing.T) {
+	dir := t.TempDir()
+	dockerConfig := filepath.Join(dir, "config.json")
+	auth := func(user, pass string) string {
+		return base64.StdEncoding.EncodeToString([]byte(user + ":" + pass))
+	}
+	err := ioutil.WriteFile(dockerConfig, []byte(`{"auths": {
+		"https://index.docker.io/v1/": {"auth": "`+auth("hubuser", "hubpass")+`"},
+		"registry.example.com": {"auth": "`+auth("user", "old")+`"},
+		"ghcr.io": {}
+	}, "credsStore": "desktop"}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configured := map[string]RegistryAuth{
+		"registry.example.com": {Username: "user", Password: "new", ServerAddress: "registry.example.com"},
+	}
+	want := map[string]RegistryAuth{
+		"docker.io":            {Username: "hubuser", Password: "hubpass", ServerAddress: dockerHubAuthKey},
+		"registry.example.com": {Username: "user", Password: "new", ServerAddress: "registry.example.com"},
+	}
+	got, err := registryAuthsFrom(configured, dockerConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+
+	got, err = registryAuthsFrom(nil, filepath.Join(dir, "missing.json"))
+	if err != nil || len(got) != 0 {
+		t.Errorf("registryAuthsFrom() without a docker config = %v, %v", got, err)
+	}
+}
+
+func TestDockerConfigWith(t *testing.T) {
+	dir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "config.json"), []byte(`{
+		"credsStore": "desktop",
+		"credHelpers": {"registry.example.com": "ecr-login", "gcr.io": "gcloud"},
+		"experimental": "enabled"
+	}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("DOCKER_CONFIG", dir)
+	defer os.Unsetenv("DOCKER_CONFIG")
+
+	out, err := dockerConfigWith([]RegistryAuth{{Username: "user", Password: "pass", ServerAddress: "registry.example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(out)
+
+	b, err := ioutil.ReadFile(filepath.Join(out, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]interface{}{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"auths": map[string]interface{}{
+			"registry.example.com": map[string]interface{}{"auth": base64.StdEncoding.EncodeToString([]byte("user:pass"))},
+		},
+		"credHelpers":  map[string]interface{}{"registry.example.com": "", "gcr.io": "gcloud"},
+		"credsStore":   "desktop",
+		"experimental": "enabled",
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
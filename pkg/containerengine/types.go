@@ -36,8 +36,23 @@ type Image struct {
 	CreatedAt  string `yaml:"createdAt,omitempty"`
 }
 
+// BuildOptions are the optional settings of an image build
+type BuildOptions struct {
+	// Build time variables, referenced by ARG in the dockerfile
+	BuildArgs map[string]string
+	// The platform to build for, e.g. linux/arm64, defaults to the host platform
+	Platform string
+	// Labels added to the image
+	Labels map[string]string
+	// BuildKit caches to import (not supported with podman), e.g. type=registry,ref=ghcr.io/org/cache or type=local,src=/tmp/cache
+	CacheFrom []string
+	// BuildKit caches to export to (not supported with podman), e.g. type=registry,ref=ghcr.io/org/cache,mode=max or type=local,dest=/tmp/cache
+	CacheTo []string
+}
+
 type ContainerEngine interface {
-	Build(dockerfile, path, imageTag string, buildArgs map[string]string) error
+	// Build builds an image from the dockerfile, it is left untagged when imageTag is empty
+	Build(dockerfile, path, imageTag string, opts BuildOptions) error
 	ListImages(stackName, containerName string) ([]Image, error)
 	Pull(rawImage string) error
 	NetworkCreate(name string) error
@@ -47,8 +62,12 @@ type ContainerEngine interface {
 	ContainerWait(containerID string, condition container.WaitCondition) (<-chan container.ContainerWaitOKBody, <-chan error)
 	CopyFromArchive(nameOrID string, path string, reader io.Reader) error
 	ContainersListByLabel(match map[string]string) ([]types.Container, error)
-	RemoveByLabel(name, value string) error
+	RemoveByLabel(match map[string]string) error
+	RemoveContainer(nameOrID string) error
 	ContainerExec(containerName string, cmd []string, workingDir string) error
+	Logs(nameOrID string, opts types.ContainerLogsOptions) (io.ReadCloser, error)
+	Tag(image, target string) error
+	Push(image string, auth *RegistryAuth) error
 }
 
 func Discover() (ContainerEngine, error) {
@@ -64,7 +83,11 @@ func Discover() (ContainerEngine, error) {
 	if err == nil {
 		return dk, nil
 	}
-	return nil, errors.New("neither podman nor docker found")
+	nc, err := newNerdctl()
+	if err == nil {
+		return nc, nil
+	}
+	return nil, errors.New("none of podman, docker or nerdctl found")
 }
 
 func buildTimeout() time.Duration {
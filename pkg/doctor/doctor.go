@@ -0,0 +1,146 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/nitrictech/newcli/pkg/stack"
+	"github.com/nitrictech/newcli/pkg/utils"
+)
+
+const (
+	StatusPass = "pass"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+type Result struct {
+	Check   string `json:"check"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// toolchains needed to run the build scripts and tests of each runtime outside of containers
+var toolchains = map[utils.Runtime][]string{
+	utils.RuntimeJavascript: {"node", "--version"},
+	utils.RuntimeTypescript: {"node", "--version"},
+	utils.RuntimePython:     {"python3", "--version"},
+	utils.RuntimeGolang:     {"go", "version"},
+	utils.RuntimeJava:       {"java", "-version"},
+	utils.RuntimeDotnet:     {"dotnet", "--version"},
+}
+
+// Check diagnoses the environment, s may be nil when there is no stack to check the toolchains of
+func Check(s *stack.Stack) []Result {
+	results := []Result{containerEngine()}
+
+	results = append(results,
+		command("pulumi", StatusWarn, "needed to deploy to cloud providers, install it from https://www.pulumi.com/docs/get-started/install/", "pulumi", "version"),
+		credentials("aws credentials", []string{"AWS_ACCESS_KEY_ID", "AWS_PROFILE"}, ".aws/credentials", "run 'aws configure' to deploy to aws"),
+		credentials("gcp credentials", []string{"GOOGLE_APPLICATION_CREDENTIALS"}, ".config/gcloud/application_default_credentials.json", "run 'gcloud auth application-default login' to deploy to gcp"),
+		credentials("azure credentials", []string{"AZURE_CLIENT_ID"}, ".azure/azureProfile.json", "run 'az login' to deploy to azure"),
+	)
+
+	if s != nil {
+		runtimes := map[utils.Runtime]bool{}
+		for _, f := range s.Functions {
+			if rt, err := utils.NewRunTimeFromFilename(f.Handler); err == nil {
+				runtimes[rt] = true
+			}
+		}
+		names := []string{}
+		for rt := range runtimes {
+			names = append(names, rt.String())
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			tc := toolchains[utils.Runtime(name)]
+			results = append(results, command(name+" toolchain", StatusFail, "install "+tc[0]+" to build the stack's "+name+" functions", tc...))
+		}
+	}
+	return results
+}
+
+// Failed returns true when any of the results failed
+func Failed(results []Result) bool {
+	for _, r := range results {
+		if r.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+func containerEngine() Result {
+	for _, engine := range []string{"docker", "podman"} {
+		out, err := run(engine, "version", "--format", "{{.Server.Version}}")
+		if err == nil {
+			return Result{Check: "container engine", Status: StatusPass, Message: engine + " " + out}
+		}
+	}
+	// nerdctl reports containerd as a server component rather than a server version
+	if out, err := run("nerdctl", "version", "--format", "{{.Client.Version}}"); err == nil {
+		if _, err := run("nerdctl", "ps", "-q"); err == nil {
+			return Result{Check: "container engine", Status: StatusPass, Message: "nerdctl " + out}
+		}
+	}
+	if _, err := exec.LookPath("docker"); err == nil {
+		return Result{Check: "container engine", Status: StatusFail, Message: "docker is installed but the daemon is not running, please start it"}
+	}
+	return Result{Check: "container engine", Status: StatusFail, Message: "docker, podman or nerdctl is required, install one of them"}
+}
+
+func command(check, failStatus, hint string, cmd ...string) Result {
+	out, err := run(cmd[0], cmd[1:]...)
+	if err != nil {
+		return Result{Check: check, Status: failStatus, Message: hint}
+	}
+	return Result{Check: check, Status: StatusPass, Message: out}
+}
+
+func credentials(check string, envVars []string, homeFile, hint string) Result {
+	for _, e := range envVars {
+		if os.Getenv(e) != "" {
+			return Result{Check: check, Status: StatusPass, Message: "found " + e}
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err == nil {
+		if _, err := os.Stat(path.Join(home, homeFile)); err == nil {
+			return Result{Check: check, Status: StatusPass, Message: "found ~/" + homeFile}
+		}
+	}
+	return Result{Check: check, Status: StatusWarn, Message: hint}
+}
+
+// run returns the first line of the command's output
+func run(name string, args ...string) (string, error) {
+	out := &bytes.Buffer{}
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	return strings.SplitN(strings.TrimSpace(out.String()), "\n", 2)[0], nil
+}
@@ -0,0 +1,131 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFailed(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []Result
+		want    bool
+	}{
+		{name: "none", want: false},
+		{name: "warnings", results: []Result{{Status: StatusPass}, {Status: StatusWarn}}, want: false},
+		{name: "failure", results: []Result{{Status: StatusPass}, {Status: StatusFail}}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Failed(tt.results); got != tt.want {
+				t.Errorf("Failed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  []string
+		want Result
+	}{
+		{
+			name: "first line of the output",
+			cmd:  []string{"sh", "-c", "echo v1.2.3; echo more"},
+			want: Result{Check: "tool", Status: StatusPass, Message: "v1.2.3"},
+		},
+		{
+			name: "failed",
+			cmd:  []string{"sh", "-c", "exit 1"},
+			want: Result{Check: "tool", Status: StatusWarn, Message: "install tool"},
+		},
+		{
+			name: "not installed",
+			cmd:  []string{"nitric-doctor-missing-tool"},
+			want: Result{Check: "tool", Status: StatusWarn, Message: "install tool"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := command("tool", StatusWarn, "install tool", tt.cmd...)
+			if !cmp.Equal(tt.want, got) {
+				t.Error(cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	home := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(home, ".aws"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(home, ".aws", "credentials"), []byte(""), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []string{"HOME", "NITRIC_DOCTOR_TEST_KEY"} {
+		old, ok := os.LookupEnv(e)
+		defer func(e string) {
+			if ok {
+				os.Setenv(e, old)
+			} else {
+				os.Unsetenv(e)
+			}
+		}(e)
+	}
+	os.Setenv("HOME", home)
+
+	tests := []struct {
+		name     string
+		env      string
+		homeFile string
+		want     Result
+	}{
+		{
+			name:     "env var",
+			env:      "key",
+			homeFile: ".azure/azureProfile.json",
+			want:     Result{Check: "creds", Status: StatusPass, Message: "found NITRIC_DOCTOR_TEST_KEY"},
+		},
+		{
+			name:     "home file",
+			homeFile: ".aws/credentials",
+			want:     Result{Check: "creds", Status: StatusPass, Message: "found ~/.aws/credentials"},
+		},
+		{
+			name:     "missing",
+			homeFile: ".azure/azureProfile.json",
+			want:     Result{Check: "creds", Status: StatusWarn, Message: "log in"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("NITRIC_DOCTOR_TEST_KEY", tt.env)
+			got := credentials("creds", []string{"NITRIC_DOCTOR_TEST_KEY"}, tt.homeFile, "log in")
+			if !cmp.Equal(tt.want, got) {
+				t.Error(cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package environment
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Dir is the directory of the environment files, relative to the stack
+const Dir = "environments"
+
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Environment is a stage of a stack (e.g. dev, staging or prod), stored with the stack in environments/<name>.yaml
+type Environment struct {
+	name string `yaml:"-"`
+
+	// The target of the CLI configuration the environment is deployed to
+	Target string `yaml:"target"`
+
+	// The name of the deployment, defaults to the environment's name
+	Deployment string `yaml:"deployment,omitempty"`
+
+	// Env vars set for every compute unit of the stack in this environment
+	Env map[string]string `yaml:"env,omitempty"`
+}
+
+// Difference is a value that isn't the same in two environments, missing values are empty
+type Difference struct {
+	Key  string `yaml:"key"`
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+func New(name, target string) (*Environment, error) {
+	if !validName.MatchString(name) {
+		return nil, fmt.Errorf("invalid environment name %s, use lowercase letters, numbers and dashes", name)
+	}
+	return &Environment{name: name, Target: target, Env: map[string]string{}}, nil
+}
+
+func (e *Environment) Name() string {
+	return e.name
+}
+
+// DeploymentName returns the name of the environment's deployment
+func (e *Environment) DeploymentName() string {
+	if e.Deployment != "" {
+		return e.Deployment
+	}
+	return e.name
+}
+
+func file(stackDir, name string) string {
+	return filepath.Join(stackDir, Dir, name+".yaml")
+}
+
+// Load reads the named environment of the stack
+func Load(stackDir, name string) (*Environment, error) {
+	// the name is part of the file path, it must not point outside of the environments dir
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") {
+		return nil, fmt.Errorf("invalid environment name %s", name)
+	}
+	b, err := ioutil.ReadFile(file(stackDir, name))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("environment %s not found, create it with 'nitric env create %s'", name, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	e := &Environment{name: name}
+	if err := yaml.Unmarshal(b, e); err != nil {
+		return nil, fmt.Errorf("environment %s: %v", name, err)
+	}
+	if e.Env == nil {
+		e.Env = map[string]string{}
+	}
+	return e, nil
+}
+
+// List reads all the environments of the stack, ordered by name
+func List(stackDir string) ([]*Environment, error) {
+	files, err := ioutil.ReadDir(filepath.Join(stackDir, Dir))
+	if os.IsNotExist(err) {
+		return []*Environment{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	envs := []*Environment{}
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".yaml" {
+			continue
+		}
+		e, err := Load(stackDir, strings.TrimSuffix(f.Name(), ".yaml"))
+		if err != nil {
+			return nil, err
+		}
+		envs = append(envs, e)
+	}
+	sort.Slice(envs, func(i, j int) bool { return envs[i].name < envs[j].name })
+	return envs, nil
+}
+
+// Save writes the environment to the stack, fails when it exists unless overwrite is set
+func (e *Environment) Save(stackDir string, overwrite bool) error {
+	f := file(stackDir, e.name)
+	if _, err := os.Stat(f); err == nil && !overwrite {
+		return fmt.Errorf("environment %s already exists", e.name)
+	}
+	b, err := yaml.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f), 0o755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(f, b, 0o644)
+}
+
+// Diff returns the settings and env vars that differ between the environments
+func Diff(from, to *Environment) []Difference {
+	diffs := []Difference{}
+	if from.Target != to.Target {
+		diffs = append(diffs, Difference{Key: "target", From: from.Target, To: to.Target})
+	}
+	if from.DeploymentName() != to.DeploymentName() {
+		diffs = append(diffs, Difference{Key: "deployment", From: from.DeploymentName(), To: to.DeploymentName()})
+	}
+
+	keys := map[string]bool{}
+	for k := range from.Env {
+		keys[k] = true
+	}
+	for k := range to.Env {
+		keys[k] = true
+	}
+	envDiffs := []Difference{}
+	for k := range keys {
+		if from.Env[k] != to.Env[k] {
+			envDiffs = append(envDiffs, Difference{Key: "env." + k, From: from.Env[k], To: to.Env[k]})
+		}
+	}
+	sort.Slice(envDiffs, func(i, j int) bool { return envDiffs[i].Key < envDiffs[j].Key })
+	return append(diffs, envDiffs...)
+}
+
+// Promote copies the env vars of from to to, except those listed in keep, and returns the values that changed.
+// The target and deployment of to are left as they are.
+func Promote(from, to *Environment, keep []string) []Difference {
+	kept := map[string]bool{}
+	for _, k := range keep {
+		kept[k] = true
+	}
+
+	changes := []Difference{}
+	for _, d := range Diff(from, to) {
+		k := strings.TrimPrefix(d.Key, "env.")
+		if k == d.Key || kept[k] || d.From == "" {
+			continue
+		}
+		to.Env[k] = d.From
+		changes = append(changes, Difference{Key: d.Key, From: d.To, To: d.From})
+	}
+	return changes
+}
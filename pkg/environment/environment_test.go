@@ -0,0 +1,113 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package environment
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSaveLoadList(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"staging", "dev"} {
+		e, err := New(name, "local")
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Env["LOG_LEVEL"] = name
+		if err := e.Save(dir, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e, _ := New("dev", "local")
+	if err := e.Save(dir, false); err == nil {
+		t.Error("Save() should fail when the environment exists")
+	}
+
+	envs, err := List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, e := range envs {
+		names = append(names, e.Name())
+	}
+	if want := []string{"dev", "staging"}; !reflect.DeepEqual(want, names) {
+		t.Errorf("List() = %v, want %v", names, want)
+	}
+
+	staging, err := Load(dir, "staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if staging.Env["LOG_LEVEL"] != "staging" || staging.DeploymentName() != "staging" {
+		t.Errorf("Load() = %+v", staging)
+	}
+
+	if _, err := Load(dir, "prod"); err == nil {
+		t.Error("Load() should fail for a missing environment")
+	}
+	if _, err := New("Prod", "local"); err == nil {
+		t.Error("New() should fail for an invalid name")
+	}
+	for _, name := range []string{"../staging", "envs/staging", "..", ""} {
+		if _, err := Load(dir, name); err == nil || !strings.Contains(err.Error(), "invalid environment name") {
+			t.Errorf("Load(%q) error = %v, want an invalid environment name", name, err)
+		}
+	}
+}
+
+func TestDiffAndPromote(t *testing.T) {
+	staging := &Environment{name: "staging", Target: "aws-staging", Env: map[string]string{"A": "1", "B": "2", "SECRET": "s"}}
+	prod := &Environment{name: "prod", Target: "aws-prod", Env: map[string]string{"A": "1", "B": "old", "C": "3", "SECRET": "p"}}
+
+	tests := []struct {
+		name string
+		got  []Difference
+		want []Difference
+	}{
+		{
+			name: "diff",
+			got:  Diff(staging, prod),
+			want: []Difference{
+				{Key: "target", From: "aws-staging", To: "aws-prod"},
+				{Key: "deployment", From: "staging", To: "prod"},
+				{Key: "env.B", From: "2", To: "old"},
+				{Key: "env.C", From: "", To: "3"},
+				{Key: "env.SECRET", From: "s", To: "p"},
+			},
+		},
+		{
+			name: "promote keeps listed and extra values",
+			got:  Promote(staging, prod, []string{"SECRET"}),
+			want: []Difference{{Key: "env.B", From: "old", To: "2"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.want, tt.got) {
+				t.Errorf("got %+v, want %+v", tt.got, tt.want)
+			}
+		})
+	}
+
+	if want := map[string]string{"A": "1", "B": "2", "C": "3", "SECRET": "p"}; !reflect.DeepEqual(want, prod.Env) {
+		t.Errorf("Promote() env = %v, want %v", prod.Env, want)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package functiondockerfile
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/nitrictech/boxygen/pkg/backend/dockerfile"
+	"github.com/nitrictech/newcli/pkg/stack"
+)
+
+const (
+	dotnetSDKImage     = "mcr.microsoft.com/dotnet/sdk:6.0"
+	dotnetRuntimeImage = "mcr.microsoft.com/dotnet/runtime:6.0"
+)
+
+// dotnetGenerator builds the project file given as the function's handler with the sdk,
+// then copies the published output to a runtime image
+func dotnetGenerator(f *stack.Function, version, provider string, w io.Writer) error {
+	buildCon, err := dockerfile.NewContainer(dockerfile.NewContainerOpts{
+		From:   f.BaseImage(dotnetSDKImage),
+		As:     "build",
+		Ignore: []string{"bin/", "obj/"},
+	})
+	if err != nil {
+		return err
+	}
+
+	buildCon.Config(dockerfile.ConfigOptions{
+		WorkingDir: "/src/",
+	})
+	buildCon.Copy(dockerfile.CopyOptions{Src: ".", Dest: "."})
+	buildCon.Run(dockerfile.RunOptions{Command: []string{"dotnet", "restore", f.Handler}})
+	buildCon.Run(dockerfile.RunOptions{Command: []string{"dotnet", "publish", f.Handler, "-c", "Release", "-o", "/out"}})
+
+	con, err := dockerfile.NewContainer(dockerfile.NewContainerOpts{
+		From:   f.BaseImage(dotnetRuntimeImage),
+		Ignore: []string{},
+	})
+	if err != nil {
+		return err
+	}
+
+	con.Config(dockerfile.ConfigOptions{
+		WorkingDir: "/app/",
+	})
+	con.Copy(dockerfile.CopyOptions{Src: "/out", Dest: ".", From: "build"})
+
+	withMembrane(con, version, provider)
+
+	// the assembly is named after the project file
+	assembly := strings.TrimSuffix(filepath.Base(f.Handler), filepath.Ext(f.Handler)) + ".dll"
+	con.Config(dockerfile.ConfigOptions{
+		Ports: []int32{9001},
+		Cmd:   []string{"dotnet", assembly},
+	})
+
+	_, err = w.Write([]byte(strings.Join(append(buildCon.Lines(), con.Lines()...), "\n")))
+	return err
+}
+
+// dotnetDevBaseGenerator generates a base image with the sdk and nodemon for code-as-config and hot reloading,
+// nodemon restarts 'dotnet run' so the stack's watch settings apply as they do to other runtimes
+func dotnetDevBaseGenerator(baseImages map[string]string, w io.Writer) error {
+	con, err := dockerfile.NewContainer(dockerfile.NewContainerOpts{
+		From:   stack.BaseImage(baseImages, dotnetSDKImage),
+		Ignore: []string{"bin/", "obj/", ".nitric/", ".git/", ".idea/"},
+	})
+	if err != nil {
+		return err
+	}
+
+	con.Run(dockerfile.RunOptions{Command: []string{"apt-get", "update"}})
+	con.Run(dockerfile.RunOptions{Command: []string{"apt-get", "install", "-y", "--no-install-recommends", "nodejs", "npm"}})
+	con.Run(dockerfile.RunOptions{Command: []string{"npm", "install", "-g", "nodemon"}})
+	con.Config(dockerfile.ConfigOptions{
+		Entrypoint: []string{"nodemon"},
+		WorkingDir: "/app/",
+	})
+
+	_, err = w.Write([]byte(strings.Join(con.Lines(), "\n")))
+	return err
+}
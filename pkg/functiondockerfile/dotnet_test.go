@@ -0,0 +1,70 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package functiondockerfile
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nitrictech/newcli/pkg/stack"
+)
+
+func Test_dotnetGenerator(t *testing.T) {
+	w := &bytes.Buffer{}
+	f := &stack.Function{
+		Handler: "functions/orders/Orders.csproj",
+	}
+	if err := dotnetGenerator(f, "v1.2.3", "aws", w); err != nil {
+		t.Errorf("dotnetGenerator() error = %v", err)
+		return
+	}
+	wantW := `FROM mcr.microsoft.com/dotnet/sdk:6.0 as build
+WORKDIR /src/
+COPY . .
+RUN dotnet restore functions/orders/Orders.csproj
+RUN dotnet publish functions/orders/Orders.csproj -c Release -o /out
+FROM mcr.microsoft.com/dotnet/runtime:6.0
+WORKDIR /app/
+COPY --from=build /out .
+ADD https://github.com/nitrictech/nitric/releases/download/v1.2.3/membrane-aws /usr/local/bin/membrane
+RUN chmod +x-rw /usr/local/bin/membrane
+ENTRYPOINT ["/usr/local/bin/membrane"]
+EXPOSE 9001
+CMD ["dotnet", "Orders.dll"]`
+
+	if wantW != w.String() {
+		t.Errorf("dotnetGenerator() = %v, want %v", w.String(), wantW)
+	}
+}
+
+func Test_dotnetDevBaseGenerator(t *testing.T) {
+	w := &bytes.Buffer{}
+	if err := dotnetDevBaseGenerator(nil, w); err != nil {
+		t.Errorf("dotnetDevBaseGenerator() error = %v", err)
+		return
+	}
+	wantW := `FROM mcr.microsoft.com/dotnet/sdk:6.0
+RUN apt-get update
+RUN apt-get install -y --no-install-recommends nodejs npm
+RUN npm install -g nodemon
+WORKDIR /app/
+ENTRYPOINT ["nodemon"]`
+
+	if wantW != w.String() {
+		t.Errorf("dotnetDevBaseGenerator() = %v, want %v", w.String(), wantW)
+	}
+}
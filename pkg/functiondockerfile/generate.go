@@ -52,6 +52,15 @@ var generators = map[utils.Runtime]func(f *stack.Function, version, provider str
 	utils.RuntimeJavascript: javascriptGenerator,
 	utils.RuntimeTypescript: typescriptGenerator,
 	utils.RuntimePython:     pythonGenerator,
+	utils.RuntimeDotnet:     dotnetGenerator,
+}
+
+// baseGenerators produce the dependency layers shared by functions of the same runtime
+// that are built from the same context, these are always a prefix of the matching generator
+var baseGenerators = map[utils.Runtime]func(f *stack.Function, version, provider string, w io.Writer) error{
+	utils.RuntimeJavascript: javascriptBaseGenerator,
+	utils.RuntimeTypescript: typescriptBaseGenerator,
+	utils.RuntimePython:     pythonBaseGenerator,
 }
 
 func Generate(f *stack.Function, version, provider string, fwriter io.Writer) error {
@@ -66,9 +75,33 @@ func Generate(f *stack.Function, version, provider string, fwriter io.Writer) er
 	return generator(f, version, provider, fwriter)
 }
 
+// HasBase returns true if the runtime of the function supports a shared dependency base
+func HasBase(f *stack.Function) bool {
+	rt, err := utils.NewRunTimeFromFilename(f.Handler)
+	if err != nil {
+		return false
+	}
+	_, ok := baseGenerators[rt]
+	return ok
+}
+
+// GenerateBase generates a dockerfile containing only the dependency layers of the function.
+// Building it before the functions that share it means they reuse the cached layers.
+func GenerateBase(f *stack.Function, version, provider string, fwriter io.Writer) error {
+	rt, err := utils.NewRunTimeFromFilename(f.Handler)
+	if err != nil {
+		return err
+	}
+	generator, ok := baseGenerators[rt]
+	if generator == nil || !ok {
+		return errors.New("could not build base dockerfile from " + f.Handler + ", extension not supported")
+	}
+	return generator(f, version, provider, fwriter)
+}
+
 // GenerateForCodeAsConfig dockerfiles for code-as-config
-// These will initially be generated without the membrane
-func GenerateForCodeAsConfig(handler string, fwriter io.Writer) error {
+// These will initially be generated without the membrane, from the base images of the stack's baseImages (which may be nil)
+func GenerateForCodeAsConfig(handler string, baseImages map[string]string, fwriter io.Writer) error {
 	rt, err := utils.NewRunTimeFromFilename(handler)
 	if err != nil {
 		return err
@@ -77,7 +110,11 @@ func GenerateForCodeAsConfig(handler string, fwriter io.Writer) error {
 	case utils.RuntimeJavascript:
 		fallthrough
 	case utils.RuntimeTypescript:
-		return typescriptDevBaseGenerator(fwriter)
+		return typescriptDevBaseGenerator(baseImages, fwriter)
+	case utils.RuntimeGolang:
+		return golangDevBaseGenerator(baseImages, fwriter)
+	case utils.RuntimeDotnet:
+		return dotnetDevBaseGenerator(baseImages, fwriter)
 	}
 
 	return errors.New("could not build dockerfile from " + handler + ", extension not supported")
@@ -26,7 +26,7 @@ import (
 
 func golangGenerator(f *stack.Function, version, provider string, w io.Writer) error {
 	buildCon, err := dockerfile.NewContainer(dockerfile.NewContainerOpts{
-		From:   "golang:alpine",
+		From:   f.BaseImage("golang:alpine"),
 		As:     "build",
 		Ignore: []string{},
 	})
@@ -47,7 +47,7 @@ func golangGenerator(f *stack.Function, version, provider string, w io.Writer) e
 	buildCon.Run(dockerfile.RunOptions{Command: []string{"CGO_ENABLED=0", "GOOS=linux", "go", "build", "-o", "/bin/main", f.Handler}})
 
 	con, err := dockerfile.NewContainer(dockerfile.NewContainerOpts{
-		From:   "alpine",
+		From:   f.BaseImage("alpine"),
 		Ignore: []string{},
 	})
 	if err != nil {
@@ -65,3 +65,24 @@ func golangGenerator(f *stack.Function, version, provider string, w io.Writer) e
 	_, err = w.Write([]byte(strings.Join(append(buildCon.Lines(), con.Lines()...), "\n")))
 	return err
 }
+
+// golangDevBaseGenerator generates a base image with the go toolchain and nodemon for hot reloading
+func golangDevBaseGenerator(baseImages map[string]string, w io.Writer) error {
+	con, err := dockerfile.NewContainer(dockerfile.NewContainerOpts{
+		From:   stack.BaseImage(baseImages, "golang:alpine"),
+		Ignore: []string{".nitric/", ".git/", ".idea/"},
+	})
+	if err != nil {
+		return err
+	}
+
+	con.Run(dockerfile.RunOptions{Command: []string{"apk", "add", "--no-cache", "git", "gcc", "g++", "make", "yarn"}})
+	con.Run(dockerfile.RunOptions{Command: []string{"yarn", "global", "add", "nodemon"}})
+	con.Config(dockerfile.ConfigOptions{
+		Entrypoint: []string{"nodemon"},
+		WorkingDir: "/app/",
+	})
+
+	_, err = w.Write([]byte(strings.Join(con.Lines(), "\n")))
+	return err
+}
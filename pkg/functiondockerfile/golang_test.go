@@ -52,3 +52,20 @@ CMD ["/bin/main"]`
 		t.Errorf("golangGenerator() = %v, want %v", w.String(), wantW)
 	}
 }
+
+func Test_golangDevBaseGenerator(t *testing.T) {
+	w := &bytes.Buffer{}
+	if err := golangDevBaseGenerator(nil, w); err != nil {
+		t.Errorf("golangDevBaseGenerator() error = %v", err)
+		return
+	}
+	wantW := `FROM golang:alpine
+RUN apk add --no-cache git gcc g++ make yarn
+RUN yarn global add nodemon
+WORKDIR /app/
+ENTRYPOINT ["nodemon"]`
+
+	if wantW != w.String() {
+		t.Errorf("golangDevBaseGenerator() = %v, want %v", w.String(), wantW)
+	}
+}
@@ -34,7 +34,7 @@ const (
 
 func javaGenerator(f *stack.Function, version, provider string, w io.Writer) error {
 	buildCon, err := dockerfile.NewContainer(dockerfile.NewContainerOpts{
-		From:   mavenOpenJDKImage,
+		From:   f.BaseImage(mavenOpenJDKImage),
 		As:     "build",
 		Ignore: []string{},
 	})
@@ -47,7 +47,7 @@ func javaGenerator(f *stack.Function, version, provider string, w io.Writer) err
 	}
 
 	con, err := dockerfile.NewContainer(dockerfile.NewContainerOpts{
-		From:   jvmRuntimeBaseImage,
+		From:   f.BaseImage(jvmRuntimeBaseImage),
 		Ignore: []string{},
 	})
 	if err != nil {
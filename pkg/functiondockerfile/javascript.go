@@ -24,13 +24,15 @@ import (
 	"github.com/nitrictech/newcli/pkg/stack"
 )
 
-func javascriptGenerator(f *stack.Function, version, provider string, w io.Writer) error {
+// javascriptBase creates the container state shared by all javascript functions
+// built from the same context, everything up to and including the dependency install
+func javascriptBase(f *stack.Function, version, provider string) (dockerfile.ContainerState, error) {
 	con, err := dockerfile.NewContainer(dockerfile.NewContainerOpts{
-		From:   "node:alpine",
+		From:   f.BaseImage("node:alpine"),
 		Ignore: []string{"node_modules/", ".nitric/", ".git/", ".idea/"},
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 	withMembrane(con, version, provider)
 
@@ -40,6 +42,26 @@ func javascriptGenerator(f *stack.Function, version, provider string, w io.Write
 		"set", "-ex;",
 		"yarn", "install", "--production", "--frozen-lockfile", "--cache-folder", "/tmp/.cache;",
 		"rm", "-rf", "/tmp/.cache;"}})
+
+	return con, nil
+}
+
+func javascriptBaseGenerator(f *stack.Function, version, provider string, w io.Writer) error {
+	con, err := javascriptBase(f, version, provider)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write([]byte(strings.Join(con.Lines(), "\n")))
+	return err
+}
+
+func javascriptGenerator(f *stack.Function, version, provider string, w io.Writer) error {
+	con, err := javascriptBase(f, version, provider)
+	if err != nil {
+		return err
+	}
+
 	con.Copy(dockerfile.CopyOptions{Src: ".", Dest: "."})
 	con.Config(dockerfile.ConfigOptions{
 		Cmd: []string{"node", f.Handler},
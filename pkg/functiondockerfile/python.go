@@ -24,13 +24,15 @@ import (
 	"github.com/nitrictech/newcli/pkg/stack"
 )
 
-func pythonGenerator(f *stack.Function, version, provider string, w io.Writer) error {
+// pythonBase creates the container state shared by all python functions
+// built from the same context, everything up to and including the dependency install
+func pythonBase(f *stack.Function) (dockerfile.ContainerState, error) {
 	con, err := dockerfile.NewContainer(dockerfile.NewContainerOpts{
-		From:   "python:3.7-slim",
+		From:   f.BaseImage("python:3.7-slim"),
 		Ignore: []string{"__pycache__/", "*.py[cod]", "*$py.class"},
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	con.Run(dockerfile.RunOptions{Command: []string{"pip", "install", "--upgrade", "pip"}})
@@ -39,6 +41,26 @@ func pythonGenerator(f *stack.Function, version, provider string, w io.Writer) e
 	})
 	con.Copy(dockerfile.CopyOptions{Src: "requirements.txt", Dest: "requirements.txt"})
 	con.Run(dockerfile.RunOptions{Command: []string{"pip", "install", "--no-cache-dir", "-r", "requirements.txt"}})
+
+	return con, nil
+}
+
+func pythonBaseGenerator(f *stack.Function, version, provider string, w io.Writer) error {
+	con, err := pythonBase(f)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write([]byte(strings.Join(con.Lines(), "\n")))
+	return err
+}
+
+func pythonGenerator(f *stack.Function, version, provider string, w io.Writer) error {
+	con, err := pythonBase(f)
+	if err != nil {
+		return err
+	}
+
 	con.Copy(dockerfile.CopyOptions{Src: ".", Dest: "."})
 
 	withMembrane(con, version, provider)
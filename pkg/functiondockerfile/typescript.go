@@ -24,13 +24,15 @@ import (
 	"github.com/nitrictech/newcli/pkg/stack"
 )
 
-func typescriptGenerator(f *stack.Function, version, provider string, w io.Writer) error {
+// typescriptBase creates the container state shared by all typescript functions
+// built from the same context, everything up to and including the dependency install
+func typescriptBase(f *stack.Function) (dockerfile.ContainerState, error) {
 	con, err := dockerfile.NewContainer(dockerfile.NewContainerOpts{
-		From:   "node:alpine",
+		From:   f.BaseImage("node:alpine"),
 		Ignore: []string{"node_modules/", ".nitric/", ".git/", ".idea/"},
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	con.Run(dockerfile.RunOptions{Command: []string{"yarn", "global", "add", "typescript"}})
@@ -42,6 +44,25 @@ func typescriptGenerator(f *stack.Function, version, provider string, w io.Write
 		"yarn", "install", "--production", "--frozen-lockfile", "--cache-folder", "/tmp/.cache;",
 		"rm", "-rf", "/tmp/.cache;"}})
 
+	return con, nil
+}
+
+func typescriptBaseGenerator(f *stack.Function, version, provider string, w io.Writer) error {
+	con, err := typescriptBase(f)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write([]byte(strings.Join(con.Lines(), "\n")))
+	return err
+}
+
+func typescriptGenerator(f *stack.Function, version, provider string, w io.Writer) error {
+	con, err := typescriptBase(f)
+	if err != nil {
+		return err
+	}
+
 	withMembrane(con, version, provider)
 
 	con.Copy(dockerfile.CopyOptions{Src: ".", Dest: "."})
@@ -53,9 +74,9 @@ func typescriptGenerator(f *stack.Function, version, provider string, w io.Write
 }
 
 // typescriptDevBaseGenerator generates a base image for code-as-config
-func typescriptDevBaseGenerator(w io.Writer) error {
+func typescriptDevBaseGenerator(baseImages map[string]string, w io.Writer) error {
 	con, err := dockerfile.NewContainer(dockerfile.NewContainerOpts{
-		From:   "node:alpine",
+		From:   stack.BaseImage(baseImages, "node:alpine"),
 		Ignore: []string{"node_modules/", ".nitric/", ".git/", ".idea/"},
 	})
 	if err != nil {
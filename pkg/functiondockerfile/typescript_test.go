@@ -18,6 +18,7 @@ package functiondockerfile
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/nitrictech/newcli/pkg/stack"
@@ -48,3 +49,50 @@ CMD ["ts-node", "-T", "functions/list.ts"]`
 		t.Errorf("typescriptGenerator() = %v, want %v", w.String(), wantW)
 	}
 }
+
+func Test_typescriptBaseGenerator(t *testing.T) {
+	f := &stack.Function{
+		Handler: "functions/list.ts",
+	}
+	base := &bytes.Buffer{}
+	if err := typescriptBaseGenerator(f, "v1.2.3", "aws", base); err != nil {
+		t.Errorf("typescriptBaseGenerator() error = %v", err)
+		return
+	}
+	wantW := `FROM node:alpine
+RUN yarn global add typescript
+RUN yarn global add ts-node
+COPY package.json *.lock *-lock.json /
+RUN yarn import || echo Lockfile already exists
+RUN set -ex; yarn install --production --frozen-lockfile --cache-folder /tmp/.cache; rm -rf /tmp/.cache;`
+
+	if wantW != base.String() {
+		t.Errorf("typescriptBaseGenerator() = %v, want %v", base.String(), wantW)
+	}
+
+	// the function layers must extend the base layers for the build cache to be shared
+	w := &bytes.Buffer{}
+	if err := typescriptGenerator(f, "v1.2.3", "aws", w); err != nil {
+		t.Errorf("typescriptGenerator() error = %v", err)
+		return
+	}
+	if !strings.HasPrefix(w.String(), base.String()) {
+		t.Errorf("typescriptGenerator() = %v, does not extend %v", w.String(), base.String())
+	}
+}
+
+func Test_typescriptDevBaseGenerator(t *testing.T) {
+	w := &bytes.Buffer{}
+	if err := typescriptDevBaseGenerator(map[string]string{"node": "registry.example.com/mirror/node"}, w); err != nil {
+		t.Errorf("typescriptDevBaseGenerator() error = %v", err)
+		return
+	}
+	wantW := `FROM registry.example.com/mirror/node:alpine
+RUN yarn global add typescript ts-node nodemon
+WORKDIR /app/
+ENTRYPOINT ["ts-node"]`
+
+	if wantW != w.String() {
+		t.Errorf("typescriptDevBaseGenerator() = %v, want %v", w.String(), wantW)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+	"text/template"
+	"time"
+)
+
+type Options struct {
+	// The URL to send requests to
+	URL    string
+	Method string
+
+	// Requests per second, sent at a steady rate
+	RPS      int
+	Duration time.Duration
+
+	// A text/template for the request body, {{.Index}} is the number of the request
+	Payload string
+
+	// Timeout for each request
+	Timeout time.Duration
+}
+
+type Result struct {
+	Requests  int     `json:"requests"`
+	Errors    int     `json:"errors"`
+	ErrorRate float64 `json:"errorRate"`
+	P50Ms     float64 `json:"p50Ms"`
+	P90Ms     float64 `json:"p90Ms"`
+	P99Ms     float64 `json:"p99Ms"`
+	MaxMs     float64 `json:"maxMs"`
+}
+
+type payloadData struct {
+	Index int
+}
+
+// Run sends requests at the given rate for the duration, then reports latency percentiles
+// and the error rate. Any transport error or 5xx response is counted as an error.
+func Run(opts Options) (*Result, error) {
+	if opts.RPS <= 0 {
+		return nil, fmt.Errorf("rps must be greater than 0")
+	}
+
+	var tmpl *template.Template
+	if opts.Payload != "" {
+		var err error
+		tmpl, err = template.New("payload").Parse(opts.Payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	client := &http.Client{Timeout: opts.Timeout}
+	total := int(opts.Duration.Seconds() * float64(opts.RPS))
+	latencies := make([]time.Duration, total)
+	failed := make([]bool, total)
+
+	ticker := time.NewTicker(time.Second / time.Duration(opts.RPS))
+	defer ticker.Stop()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < total; i++ {
+		var body io.Reader
+		if tmpl != nil {
+			buf := &bytes.Buffer{}
+			if err := tmpl.Execute(buf, payloadData{Index: i}); err != nil {
+				return nil, err
+			}
+			body = buf
+		}
+		req, err := http.NewRequest(opts.Method, opts.URL, body)
+		if err != nil {
+			return nil, err
+		}
+
+		wg.Add(1)
+		go func(i int, req *http.Request) {
+			defer wg.Done()
+			start := time.Now()
+			resp, err := client.Do(req)
+			latencies[i] = time.Since(start)
+			if err != nil {
+				failed[i] = true
+				return
+			}
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+			failed[i] = resp.StatusCode >= 500
+		}(i, req)
+
+		<-ticker.C
+	}
+	wg.Wait()
+
+	return summarise(latencies, failed), nil
+}
+
+func summarise(latencies []time.Duration, failed []bool) *Result {
+	res := &Result{Requests: len(latencies)}
+	for _, f := range failed {
+		if f {
+			res.Errors++
+		}
+	}
+	if res.Requests == 0 {
+		return res
+	}
+	res.ErrorRate = float64(res.Errors) / float64(res.Requests)
+
+	sorted := append([]time.Duration{}, latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	res.P50Ms = ms(percentile(sorted, 50))
+	res.P90Ms = ms(percentile(sorted, 90))
+	res.P99Ms = ms(percentile(sorted, 99))
+	res.MaxMs = ms(sorted[len(sorted)-1])
+	return res
+}
+
+// percentile uses the nearest rank method on sorted durations
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
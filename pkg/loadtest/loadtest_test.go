@@ -0,0 +1,61 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_summarise(t *testing.T) {
+	tests := []struct {
+		name      string
+		latencies []time.Duration
+		failed    []bool
+		want      *Result
+	}{
+		{
+			name: "empty",
+			want: &Result{},
+		},
+		{
+			name: "percentiles",
+			latencies: []time.Duration{
+				10 * time.Millisecond, 1 * time.Millisecond, 9 * time.Millisecond, 2 * time.Millisecond, 8 * time.Millisecond,
+				3 * time.Millisecond, 7 * time.Millisecond, 4 * time.Millisecond, 6 * time.Millisecond, 5 * time.Millisecond,
+			},
+			failed: []bool{false, false, true, false, false, false, false, false, false, false},
+			want: &Result{
+				Requests:  10,
+				Errors:    1,
+				ErrorRate: 0.1,
+				P50Ms:     5,
+				P90Ms:     9,
+				P99Ms:     10,
+				MaxMs:     10,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := summarise(tt.latencies, tt.failed); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("summarise() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
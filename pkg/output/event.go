@@ -0,0 +1,58 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Structured is true when events are written as json or yaml, any other output
+// must then go to stderr so stdout can be parsed.
+func Structured() bool {
+	return outputFormat == "json" || outputFormat == "yaml"
+}
+
+// PrintEvent prints a single event of a stream (e.g. deployment progress) as soon as it happens.
+// json is written as one object per line, yaml as one document per event and
+// the table format uses the event's String method.
+func PrintEvent(event interface{}) {
+	printEvent(event, os.Stdout)
+}
+
+func printEvent(event interface{}, out io.Writer) {
+	switch outputFormat {
+	case "json":
+		b, err := json.Marshal(event)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Fprintln(out, string(b))
+	case "yaml":
+		b, err := yaml.Marshal(event)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Fprint(out, "---\n"+string(b))
+	default:
+		fmt.Fprintln(out, event)
+	}
+}
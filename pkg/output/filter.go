@@ -0,0 +1,141 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// printTemplate executes a go template against the object, e.g. '{{range .}}{{.Name}} {{end}}'
+func printTemplate(tmpl string, object interface{}, out io.Writer) error {
+	t, err := template.New("output").Parse(tmpl)
+	if err != nil {
+		return err
+	}
+	err = t.Execute(out, object)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out)
+	return err
+}
+
+// printJSONPath prints the values matching the path in the JSON form of the object, separated by spaces.
+// A subset of JSONPath is supported: fields (.name), indexes ([0], [-1]) and wildcards ([*]), e.g. '{.items[*].url}'
+func printJSONPath(path string, object interface{}, out io.Writer) error {
+	b, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	var data interface{}
+	err = json.Unmarshal(b, &data)
+	if err != nil {
+		return err
+	}
+
+	values, err := evalJSONPath(path, data)
+	if err != nil {
+		return err
+	}
+
+	strs := []string{}
+	for _, v := range values {
+		switch val := v.(type) {
+		case string:
+			strs = append(strs, val)
+		case map[string]interface{}, []interface{}:
+			b, err := json.Marshal(val)
+			if err != nil {
+				return err
+			}
+			strs = append(strs, string(b))
+		default:
+			strs = append(strs, fmt.Sprint(val))
+		}
+	}
+	_, err = fmt.Fprintln(out, strings.Join(strs, " "))
+	return err
+}
+
+func evalJSONPath(path string, data interface{}) ([]interface{}, error) {
+	p := strings.TrimSpace(path)
+	if !strings.HasPrefix(p, "{") || !strings.HasSuffix(p, "}") {
+		return nil, fmt.Errorf("invalid jsonpath %s, it must be wrapped in {}", path)
+	}
+	p = strings.TrimPrefix(p[1:len(p)-1], "$")
+
+	current := []interface{}{data}
+	for len(p) > 0 {
+		next := []interface{}{}
+		switch p[0] {
+		case '.':
+			p = p[1:]
+			end := strings.IndexAny(p, ".[")
+			if end == -1 {
+				end = len(p)
+			}
+			name := p[:end]
+			p = p[end:]
+			if name == "" {
+				continue
+			}
+			for _, c := range current {
+				if m, ok := c.(map[string]interface{}); ok {
+					if v, ok := m[name]; ok {
+						next = append(next, v)
+					}
+				}
+			}
+		case '[':
+			end := strings.Index(p, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("invalid jsonpath %s, missing ]", path)
+			}
+			idx := p[1:end]
+			p = p[end+1:]
+			for _, c := range current {
+				l, ok := c.([]interface{})
+				if !ok {
+					continue
+				}
+				if idx == "*" {
+					next = append(next, l...)
+					continue
+				}
+				i, err := strconv.Atoi(idx)
+				if err != nil {
+					return nil, fmt.Errorf("invalid jsonpath %s, index %s is not a number", path, idx)
+				}
+				if i < 0 {
+					i += len(l)
+				}
+				if i >= 0 && i < len(l) {
+					next = append(next, l[i])
+				}
+			}
+		default:
+			return nil, fmt.Errorf("invalid jsonpath %s, unexpected %c", path, p[0])
+		}
+		current = next
+	}
+	return current, nil
+}
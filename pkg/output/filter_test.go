@@ -0,0 +1,90 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nitrictech/newcli/pkg/target"
+)
+
+func Test_printJSONPath(t *testing.T) {
+	object := map[string]interface{}{
+		"items": []target.Target{
+			{Name: "dev", Provider: "local"},
+			{Name: "prod", Provider: "aws", Region: "us-east-1"},
+		},
+	}
+	tests := []struct {
+		name    string
+		path    string
+		expect  string
+		wantErr bool
+	}{
+		{
+			name:   "wildcard",
+			path:   "{.items[*].name}",
+			expect: "dev prod\n",
+		},
+		{
+			name:   "index",
+			path:   "{$.items[1].region}",
+			expect: "us-east-1\n",
+		},
+		{
+			name:   "negative index",
+			path:   "{.items[-1].provider}",
+			expect: "aws\n",
+		},
+		{
+			name:   "objects are printed as json",
+			path:   "{.items[0]}",
+			expect: "{\"name\":\"dev\",\"provider\":\"local\"}\n",
+		},
+		{
+			name:    "not wrapped",
+			path:    ".items",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := printJSONPath(tt.path, object, buf)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("printJSONPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !cmp.Equal(tt.expect, buf.String()) {
+				t.Error(cmp.Diff(tt.expect, buf.String()))
+			}
+		})
+	}
+}
+
+func Test_printTemplate(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := printTemplate("{{range .}}{{.Name}} {{end}}", []target.Target{{Name: "dev"}, {Name: "prod"}}, buf)
+	if err != nil {
+		t.Fatalf("printTemplate() error = %v", err)
+	}
+	if !cmp.Equal("dev prod \n", buf.String()) {
+		t.Error(cmp.Diff("dev prod \n", buf.String()))
+	}
+}
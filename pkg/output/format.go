@@ -22,28 +22,34 @@ import (
 	"io"
 	"os"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/jedib0t/go-pretty/table"
+	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
 
 	"github.com/nitrictech/newcli/pkg/pflagext"
 )
 
 var (
-	allowedFormats = []string{"json", "yaml", "table"}
+	allowedFormats = []string{"json", "yaml", "table", "template=", "jsonpath="}
 	defaultFormat  = "table"
 	outputFormat   string
 	OutputTypeFlag = pflagext.NewStringEnumVar(&outputFormat, allowedFormats, defaultFormat)
 )
 
 func Print(object interface{}) {
-	switch outputFormat {
-	case "json":
+	switch {
+	case outputFormat == "json":
 		printJson(object)
-	case "yaml":
+	case outputFormat == "yaml":
 		printYaml(object)
+	case strings.HasPrefix(outputFormat, "template="):
+		cobra.CheckErr(printTemplate(strings.TrimPrefix(outputFormat, "template="), object, os.Stdout))
+	case strings.HasPrefix(outputFormat, "jsonpath="):
+		cobra.CheckErr(printJSONPath(strings.TrimPrefix(outputFormat, "jsonpath="), object, os.Stdout))
 	default:
 		printTable(object)
 	}
@@ -139,7 +145,7 @@ func printList(object interface{}, out io.Writer) {
 	tab.Render()
 }
 
-// printMap will print something like the following:
+// printMap will print something like the following, ordered by key:
 // +----------+-------------+----------+--------+
 // | KEY      | NAME        | PROVIDER | REGION |
 // +----------+-------------+----------+--------+
@@ -154,11 +160,11 @@ func printMap(object interface{}, out io.Writer) {
 	tab.AppendHeader(append(table.Row{"key"}, names...))
 
 	value := reflect.ValueOf(object)
-	iter := value.MapRange()
+	keys := value.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
 
 	rows := []table.Row{}
-	for iter.Next() {
-		k := iter.Key()
+	for _, k := range keys {
 		v := value.MapIndex(k)
 
 		switch v.Kind() {
@@ -35,11 +35,13 @@ func Test_printStruct(t *testing.T) {
 		{
 			name:   "json tags",
 			object: target.Target{Name: "test", Provider: "azure", Region: "somewhere"},
-			expect: `+----------+-----------+
-| NAME     | test      |
-| PROVIDER | azure     |
-| REGION   | somewhere |
-+----------+-----------+
+			expect: `+------------+-----------+
+| NAME       | test      |
+| PROVIDER   | azure     |
+| REGION     | somewhere |
+| PRODUCTION | false     |
+| APPROVAL   | -         |
++------------+-----------+
 `,
 		},
 		{
@@ -76,13 +78,15 @@ func Test_printList(t *testing.T) {
 			object: []target.Target{
 				{Name: "test", Provider: "azure", Region: "somewhere"},
 				{Name: "local", Provider: "local"},
+				{Name: "prod", Provider: "aws", Production: true, Approval: &target.Approval{Webhook: "https://hooks.example.com/T0/B0/x", Secret: "s3cret"}},
 			},
-			expect: `+-------+----------+-----------+
-| NAME  | PROVIDER | REGION    |
-+-------+----------+-----------+
-| test  | azure    | somewhere |
-| local | local    |           |
-+-------+----------+-----------+
+			expect: `+-------+----------+-----------+------------+----------+
+| NAME  | PROVIDER | REGION    | PRODUCTION | APPROVAL |
++-------+----------+-----------+------------+----------+
+| test  | azure    | somewhere | false      | -        |
+| local | local    |           | false      | -        |
+| prod  | aws      |           | true       | webhook  |
++-------+----------+-----------+------------+----------+
 `,
 		},
 	}
@@ -109,12 +113,12 @@ func Test_printMap(t *testing.T) {
 				"t1":    {Name: "test", Provider: "azure", Region: "somewhere"},
 				"local": {Name: "local", Provider: "local"},
 			},
-			wantOut: `+-------+-------+----------+-----------+
-| KEY   | NAME  | PROVIDER | REGION    |
-+-------+-------+----------+-----------+
-| t1    | test  | azure    | somewhere |
-| local | local | local    |           |
-+-------+-------+----------+-----------+
+			wantOut: `+-------+-------+----------+-----------+------------+----------+
+| KEY   | NAME  | PROVIDER | REGION    | PRODUCTION | APPROVAL |
++-------+-------+----------+-----------+------------+----------+
+| local | local | local    |           | false      | -        |
+| t1    | test  | azure    | somewhere | false      | -        |
++-------+-------+----------+-----------+------------+----------+
 `,
 		},
 	}
@@ -128,3 +132,48 @@ func Test_printMap(t *testing.T) {
 		})
 	}
 }
+
+type testEvent struct {
+	Resource string `json:"resource" yaml:"resource"`
+	Phase    string `json:"phase" yaml:"phase"`
+}
+
+func (e testEvent) String() string {
+	return e.Resource + " " + e.Phase
+}
+
+func Test_printEvent(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		expect string
+	}{
+		{
+			name:   "json",
+			format: "json",
+			expect: "{\"resource\":\"function:api\",\"phase\":\"started\"}\n",
+		},
+		{
+			name:   "yaml",
+			format: "yaml",
+			expect: "---\nresource: function:api\nphase: started\n",
+		},
+		{
+			name:   "table",
+			format: "table",
+			expect: "function:api started\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputFormat = tt.format
+			defer func() { outputFormat = defaultFormat }()
+
+			buf := &bytes.Buffer{}
+			printEvent(testEvent{Resource: "function:api", Phase: "started"}, buf)
+			if !cmp.Equal(tt.expect, buf.String()) {
+				t.Error(cmp.Diff(tt.expect, buf.String()))
+			}
+		})
+	}
+}
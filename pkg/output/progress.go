@@ -0,0 +1,225 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Interactive is true when output is written as a table to a terminal, so it can be redrawn in place
+func Interactive() bool {
+	return outputFormat == defaultFormat && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+type progressRow struct {
+	resource string
+	status   string
+	message  string
+	start    time.Time
+	end      time.Time
+}
+
+// Progress displays the status of each resource of a deployment, redrawing as they change.
+// Output written to the display while it's drawn is printed above it, see Write and CaptureStdout.
+type Progress struct {
+	lock  sync.Mutex
+	out   io.Writer
+	rows  []*progressRow
+	lines int
+	frame int
+	// the terminal height, rows that don't fit are summarised, 0 means there is no limit
+	height  int
+	pending []byte
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewProgress starts drawing the progress display to out, Stop must be called once the deployment is finished
+func NewProgress(out io.Writer) *Progress {
+	p := &Progress{out: out, done: make(chan struct{})}
+	if f, ok := out.(*os.File); ok {
+		if _, h, err := term.GetSize(int(f.Fd())); err == nil {
+			p.height = h
+		}
+	}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.done:
+				return
+			case <-ticker.C:
+				p.lock.Lock()
+				p.frame++
+				p.render()
+				p.lock.Unlock()
+			}
+		}
+	}()
+	return p
+}
+
+// Write prints complete lines above the display, so they aren't overwritten by the next redraw
+func (p *Progress) Write(b []byte) (int, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.pending = append(p.pending, b...)
+	i := bytes.LastIndexByte(p.pending, '\n')
+	if i < 0 {
+		return len(b), nil
+	}
+	p.clear()
+	if _, err := p.out.Write(p.pending[:i+1]); err != nil {
+		return 0, err
+	}
+	p.pending = append([]byte{}, p.pending[i+1:]...)
+	p.render()
+	return len(b), nil
+}
+
+// CaptureStdout sends writes to os.Stdout through the display until the returned func is called
+func (p *Progress) CaptureStdout() (func(), error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+
+	copied := make(chan struct{})
+	go func() {
+		defer close(copied)
+		_, _ = io.Copy(p, r)
+	}()
+	return func() {
+		os.Stdout = stdout
+		w.Close()
+		<-copied
+		r.Close()
+	}, nil
+}
+
+// Update sets the status of the resource, statuses other than started, succeeded and failed only update the message
+func (p *Progress) Update(resource, status, message string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var row *progressRow
+	for _, r := range p.rows {
+		if r.resource == resource {
+			row = r
+		}
+	}
+	if row == nil {
+		row = &progressRow{resource: resource, start: time.Now()}
+		p.rows = append(p.rows, row)
+	}
+
+	switch status {
+	case "started":
+		row.status = status
+		row.start = time.Now()
+		row.end = time.Time{}
+	case "succeeded", "failed":
+		row.status = status
+		row.end = time.Now()
+	}
+	if message != "" {
+		row.message = message
+	}
+	p.render()
+}
+
+// Stop draws the final state of the display
+func (p *Progress) Stop() {
+	close(p.done)
+	p.wg.Wait()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if len(p.pending) > 0 {
+		p.clear()
+		fmt.Fprintf(p.out, "%s\n", p.pending)
+		p.pending = nil
+	}
+	p.render()
+}
+
+// clear moves back to the first line of the display and erases it
+func (p *Progress) clear() {
+	if p.lines > 0 {
+		fmt.Fprintf(p.out, "\033[%dA\033[J", p.lines)
+		p.lines = 0
+	}
+}
+
+func (p *Progress) render() {
+	if p.lines > 0 {
+		// move back to the first line of the display
+		fmt.Fprintf(p.out, "\033[%dA", p.lines)
+	}
+	rows := p.rows
+	hidden := 0
+	// redrawing only works while the whole display is on screen, so the earliest rows are summarised
+	if p.height > 1 && len(rows) > p.height-1 {
+		hidden = len(rows) - (p.height - 2)
+		rows = rows[hidden:]
+	}
+	lines := 0
+	if hidden > 0 {
+		fmt.Fprintf(p.out, "\033[2K... %d more\n", hidden)
+		lines++
+	}
+	for _, r := range rows {
+		symbol := spinnerFrames[p.frame%len(spinnerFrames)]
+		status := r.status
+		elapsed := time.Since(r.start)
+		switch r.status {
+		case "succeeded":
+			symbol = "✔"
+			elapsed = r.end.Sub(r.start)
+		case "failed":
+			symbol = "✖"
+			elapsed = r.end.Sub(r.start)
+		case "":
+			// only a message has been received, the resource hasn't started yet
+			symbol = "-"
+			status = "pending"
+			elapsed = 0
+		}
+		line := fmt.Sprintf("%s %-30s %-10s %6s", symbol, r.resource, status, elapsed.Round(100*time.Millisecond))
+		if r.message != "" {
+			line += " " + r.message
+		}
+		fmt.Fprintf(p.out, "\033[2K%s\n", line)
+		lines++
+	}
+	p.lines = lines
+}
@@ -0,0 +1,85 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestProgressPending(t *testing.T) {
+	out := &bytes.Buffer{}
+	p := &Progress{out: out}
+	p.Update("function:orders", "progressing", "building")
+
+	got := out.String()
+	if !strings.Contains(got, "- function:orders") || !strings.Contains(got, "pending") {
+		t.Errorf("resource without a status is not shown as pending: %q", got)
+	}
+	if strings.Contains(got, "!") {
+		t.Errorf("resource without a status is shown as an error: %q", got)
+	}
+}
+
+func TestProgressHeight(t *testing.T) {
+	out := &bytes.Buffer{}
+	p := &Progress{out: out, height: 4}
+	for _, r := range []string{"a", "b", "c", "d", "e"} {
+		p.Update("function:"+r, "started", "")
+	}
+	if p.lines != 3 {
+		t.Errorf("display is %d lines, want 3 to fit a terminal of 4 lines", p.lines)
+	}
+
+	// only the final redraw is checked
+	draw := out.String()
+	draw = draw[strings.LastIndex(draw, "\033[3A"):]
+	for _, want := range []string{"... 3 more", "function:d", "function:e"} {
+		if !strings.Contains(draw, want) {
+			t.Errorf("last redraw is missing %q: %q", want, draw)
+		}
+	}
+	if strings.Contains(draw, "function:c") {
+		t.Errorf("last redraw shows a hidden row: %q", draw)
+	}
+}
+
+func TestProgressWrite(t *testing.T) {
+	out := &bytes.Buffer{}
+	p := &Progress{out: out, done: make(chan struct{})}
+	p.Update("function:orders", "started", "")
+	out.Reset()
+
+	if _, err := p.Write([]byte("pulling image\npartial")); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	// the display is cleared, the line written and the display redrawn below it
+	if !strings.HasPrefix(got, "\033[1A\033[Jpulling image\n\033[2K") {
+		t.Errorf("Write() = %q, want the line above the display", got)
+	}
+	if strings.Contains(got, "partial") {
+		t.Errorf("Write() printed a partial line: %q", got)
+	}
+
+	out.Reset()
+	p.Stop()
+	if !strings.Contains(out.String(), "partial\n") {
+		t.Errorf("Stop() did not print the partial line: %q", out.String())
+	}
+}
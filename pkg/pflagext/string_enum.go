@@ -26,7 +26,8 @@ type stringEnum struct {
 	ValueP  *string
 }
 
-// newEnum give a list of allowed flag parameters, where the second argument is the default
+// newEnum give a list of allowed flag parameters, where the second argument is the default.
+// Allowed values ending in = take an argument, e.g. template= allows template={{.Name}}
 func NewStringEnumVar(value *string, allowed []string, d string) *stringEnum {
 	*value = d
 	return &stringEnum{
@@ -45,6 +46,9 @@ func (e *stringEnum) Set(p string) error {
 			if val == opt {
 				return true
 			}
+			if strings.HasSuffix(opt, "=") && strings.HasPrefix(val, opt) {
+				return true
+			}
 		}
 		return false
 	}
@@ -17,6 +17,8 @@
 package provider
 
 import (
+	"fmt"
+
 	"github.com/nitrictech/newcli/pkg/provider/local"
 	"github.com/nitrictech/newcli/pkg/provider/types"
 	"github.com/nitrictech/newcli/pkg/stack"
@@ -28,6 +30,6 @@ func NewProvider(s *stack.Stack, t *target.Target) (types.Provider, error) {
 	case "local":
 		return local.New(s, t)
 	default:
-		return nil, nil
+		return nil, fmt.Errorf("unsupported provider %s, only local is supported", t.Provider)
 	}
 }
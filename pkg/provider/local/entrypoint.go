@@ -63,6 +63,10 @@ func createNginxConfig(e *stack.Entrypoint, s *stack.Stack) (string, error) {
 	return strings.Join(configLines, "\n"), nil
 }
 
+func entrypointContainerName(entrypointName, deploymentName string) string {
+	return "entry-" + entrypointName + "-" + deploymentName
+}
+
 func (l *local) entrypoint(deploymentName, entrypointName string, e *stack.Entrypoint) error {
 	ports, err := freeport.Take(1)
 	if err != nil {
@@ -78,7 +82,7 @@ func (l *local) entrypoint(deploymentName, entrypointName string, e *stack.Entry
 
 	cID, err := l.cr.ContainerCreate(&container.Config{
 		Image:  "nginx",
-		Labels: l.labels(deploymentName, "gateway"),
+		Labels: l.labels(deploymentName, "gateway", "entrypoint:"+entrypointName),
 		ExposedPorts: nat.PortSet{
 			nat.Port(fmt.Sprintf("%d/tcp", httpPort)): struct{}{},
 		},
@@ -91,7 +95,7 @@ func (l *local) entrypoint(deploymentName, entrypointName string, e *stack.Entry
 			},
 		},
 		NetworkMode: container.NetworkMode(l.network),
-	}, nil, "entry-"+entrypointName+"-"+deploymentName)
+	}, nil, entrypointContainerName(entrypointName, deploymentName))
 	if err != nil {
 		return err
 	}
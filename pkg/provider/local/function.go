@@ -34,6 +34,10 @@ func containerSubscriptions(s *stack.Stack) (map[string][]string, error) {
 	return map[string][]string{}, nil
 }
 
+func functionContainerName(s *stack.Stack, f *stack.Function, provider, deploymentName string) string {
+	return f.ImageTagName(s, provider) + "-" + deploymentName
+}
+
 func (l *local) function(deploymentName string, f *stack.Function) error {
 	nitricRunDir := path.Join(l.s.Path(), runDir)
 	ports, err := freeport.Take(1)
@@ -44,40 +48,77 @@ func (l *local) function(deploymentName string, f *stack.Function) error {
 	port := uint16(ports[0])
 	imageName := f.ImageTagName(l.s, l.t.Provider)
 
+	portBindings := nat.PortMap{}
+	switch f.Visibility {
+	case "", stack.VisibilityPublic:
+		portBindings[nat.Port(fmt.Sprintf("%d/tcp", functionPort))] = []nat.PortBinding{
+			{
+				HostPort: fmt.Sprintf("%d", port),
+			},
+		}
+	case stack.VisibilityInternal:
+		// only reachable by other containers on the deployment network
+	default:
+		return fmt.Errorf("invalid visibility %s, must be %s or %s", f.Visibility, stack.VisibilityPublic, stack.VisibilityInternal)
+	}
+
+	mounts := []mount.Mount{
+		{
+			Type:   "bind",
+			Source: nitricRunDir,
+			Target: devVolume,
+		},
+	}
+	for _, fm := range f.FileMounts(l.s) {
+		mounts = append(mounts, mount.Mount{
+			Type:     "bind",
+			Source:   fm.Source,
+			Target:   fm.Target,
+			ReadOnly: true,
+		})
+	}
+
+	resources := container.Resources{
+		NanoCPUs: int64(f.CPU * 1e9),
+	}
+	if f.GPU != nil && f.GPU.Count > 0 {
+		// the GPU type can't be selected locally, any available GPU will be used
+		resources.DeviceRequests = []container.DeviceRequest{
+			{
+				Count:        f.GPU.Count,
+				Capabilities: [][]string{{"gpu"}},
+			},
+		}
+	}
+
+	env := []string{
+		"LOCAL_SUBSCRIPTIONS={}",
+		"NITRIC_DEV_VOLUME=" + devVolume,
+		"MINIO_ENDPOINT=" + fmt.Sprintf("http://minio-%s:9000", deploymentName),
+		"MINIO_ACCESS_KEY=minioadmin",
+		"MINIO_SECRET_KEY=minioadmin",
+	}
+	env = append(env, serviceDiscoveryEnv(l.s)...)
+	env = append(env, l.s.Telemetry.Env(l.s, f.Name())...)
+	env = append(env, f.EnvVars(l.s)...)
+
 	cID, err := l.cr.ContainerCreate(&container.Config{
 		Image:  imageName,
-		Labels: l.labels(deploymentName, "function"),
+		Labels: l.labels(deploymentName, "function", "function:"+f.Name()),
 		ExposedPorts: nat.PortSet{
 			nat.Port(fmt.Sprintf("%d/tcp", functionPort)): struct{}{},
 		},
-		Env: []string{
-			"LOCAL_SUBSCRIPTIONS={}",
-			"NITRIC_DEV_VOLUME=" + devVolume,
-			"MINIO_ENDPOINT=" + fmt.Sprintf("http://minio-%s:9000", deploymentName),
-			"MINIO_ACCESS_KEY=minioadmin",
-			"MINIO_SECRET_KEY=minioadmin",
-		},
+		Env: env,
 	}, &container.HostConfig{
-		PortBindings: nat.PortMap{
-			nat.Port(fmt.Sprintf("%d/tcp", functionPort)): []nat.PortBinding{
-				{
-					HostPort: fmt.Sprintf("%d", port),
-				},
-			},
-		},
-		Mounts: []mount.Mount{
-			{
-				Type:   "bind",
-				Source: nitricRunDir,
-				Target: devVolume,
-			},
-		},
-		NetworkMode: container.NetworkMode(l.network),
+		PortBindings: portBindings,
+		Mounts:       mounts,
+		NetworkMode:  container.NetworkMode(l.network),
+		Resources:    resources,
 	}, &network.NetworkingConfig{
 		EndpointsConfig: map[string]*network.EndpointSettings{
 			l.network: {Aliases: []string{f.Name()}},
 		},
-	}, imageName+"-"+deploymentName)
+	}, functionContainerName(l.s, f, l.t.Provider, deploymentName))
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,111 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	mock_containerengine "github.com/nitrictech/newcli/mocks/containerengine"
+	"github.com/nitrictech/newcli/pkg/stack"
+	"github.com/nitrictech/newcli/pkg/target"
+)
+
+// createdHostConfig deploys the function with a mock engine and returns the host config of its container
+func createdHostConfig(t *testing.T, f *stack.Function) *container.HostConfig {
+	ctrl := gomock.NewController(t)
+	me := mock_containerengine.NewMockContainerEngine(ctrl)
+
+	var hostConfig *container.HostConfig
+	me.EXPECT().ContainerCreate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(config *container.Config, hc *container.HostConfig, nc *network.NetworkingConfig, name string) (string, error) {
+			hostConfig = hc
+			return "cid", nil
+		})
+	me.EXPECT().Start("cid")
+
+	l := &local{s: &stack.Stack{Name: "shop"}, t: &target.Target{Provider: "local"}, cr: me, network: "shop-net-test"}
+	if err := l.function("test", f); err != nil {
+		t.Fatalf("function() error = %v", err)
+	}
+	return hostConfig
+}
+
+func TestFunctionGPU(t *testing.T) {
+	tests := []struct {
+		name string
+		gpu  *stack.GPU
+		want []container.DeviceRequest
+	}{
+		{
+			name: "no gpu",
+		},
+		{
+			name: "no gpus requested",
+			gpu:  &stack.GPU{Count: 0},
+		},
+		{
+			name: "gpus",
+			gpu:  &stack.GPU{Count: 2, Type: "nvidia-l4"},
+			want: []container.DeviceRequest{{Count: 2, Capabilities: [][]string{{"gpu"}}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &stack.Function{Handler: "orders.ts", ComputeUnit: stack.ComputeUnit{GPU: tt.gpu}}
+			got := createdHostConfig(t, f).DeviceRequests
+			if !cmp.Equal(tt.want, got) {
+				t.Error(cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestFunctionVisibility(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibility string
+		wantPorts  []string
+	}{
+		{name: "default", wantPorts: []string{"9001/tcp"}},
+		{name: "public", visibility: stack.VisibilityPublic, wantPorts: []string{"9001/tcp"}},
+		{name: "internal", visibility: stack.VisibilityInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &stack.Function{Handler: "orders.ts", ComputeUnit: stack.ComputeUnit{Visibility: tt.visibility}}
+			got := []string{}
+			for p := range createdHostConfig(t, f).PortBindings {
+				got = append(got, string(p))
+			}
+			if !cmp.Equal(tt.wantPorts, got, cmpopts.EquateEmpty()) {
+				t.Error(cmp.Diff(tt.wantPorts, got))
+			}
+		})
+	}
+
+	l := &local{s: &stack.Stack{Name: "shop"}, t: &target.Target{Provider: "local"}}
+	f := &stack.Function{Handler: "orders.ts", ComputeUnit: stack.ComputeUnit{Visibility: "private"}}
+	if err := l.function("test", f); err == nil {
+		t.Error("function() expected an error for an invalid visibility")
+	}
+}
@@ -40,6 +40,10 @@ func createAPIDirectory(apiName string) string {
 	return path.Join(stagingAPIDir, apiName)
 }
 
+func gatewayContainerName(apiName, deploymentName string) string {
+	return "api-" + apiName + "-" + deploymentName
+}
+
 func (l *local) gateway(deploymentName, apiName, apiFile string) error {
 	apiDocument := path.Join(l.s.Path(), apiFile)
 	ports, err := freeport.Take(1)
@@ -55,7 +59,7 @@ func (l *local) gateway(deploymentName, apiName, apiFile string) error {
 
 	cID, err := l.cr.ContainerCreate(&container.Config{
 		Image:  devAPIGatewayImageName,
-		Labels: l.labels(deploymentName, "gateway"),
+		Labels: l.labels(deploymentName, "gateway", "api:"+apiName),
 		ExposedPorts: nat.PortSet{
 			nat.Port(fmt.Sprintf("%d/tcp", gatewayPort)): struct{}{},
 		},
@@ -72,7 +76,7 @@ func (l *local) gateway(deploymentName, apiName, apiFile string) error {
 		EndpointsConfig: map[string]*network.EndpointSettings{
 			l.network: {Aliases: []string{"api-" + apiName}},
 		},
-	}, "api-"+apiName+"-"+deploymentName)
+	}, gatewayContainerName(apiName, deploymentName))
 	if err != nil {
 		return err
 	}
@@ -20,13 +20,19 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/spf13/viper"
 
 	"github.com/nitrictech/newcli/pkg/containerengine"
+	"github.com/nitrictech/newcli/pkg/output"
 	"github.com/nitrictech/newcli/pkg/provider/types"
 	"github.com/nitrictech/newcli/pkg/stack"
 	"github.com/nitrictech/newcli/pkg/target"
+	"github.com/nitrictech/newcli/pkg/utils"
 )
 
 const (
@@ -36,6 +42,8 @@ const (
 	LabelRunID       = "io.nitric-run-id"
 	LabelStackName   = "io.nitric-stack"
 	LabelType        = "io.nitric-type"
+	LabelResource    = "io.nitric-resource"
+	LabelGitPrefix   = "io.nitric-git-"
 	minioPort        = 9000
 	minioConsolePort = 9001 // TODO: Determine if we would like to expose the console
 )
@@ -52,6 +60,9 @@ type local struct {
 	t       *target.Target
 	network string
 	cr      containerengine.ContainerEngine
+	events  types.EventHandler
+	// git labels every container with the commit it was deployed from
+	git map[string]string
 }
 
 func New(s *stack.Stack, t *target.Target) (types.Provider, error) {
@@ -60,47 +71,169 @@ func New(s *stack.Stack, t *target.Target) (types.Provider, error) {
 		return nil, err
 	}
 
-	return &local{
+	l := &local{
 		s:       s,
 		t:       t,
 		cr:      cr,
 		network: "bridge",
-	}, nil
+		events:  func(e types.Event) { output.PrintEvent(e) },
+		git:     map[string]string{},
+	}
+	if git, err := utils.GitMetadata(s.Path()); err == nil && viper.GetBool("git_metadata") {
+		l.git = git.Labels(LabelGitPrefix)
+	}
+	return l, nil
 }
 
-func (l *local) Apply(name string) error {
-	err := l.cr.RemoveByLabel(LabelStackName, l.s.Name)
+func (l *local) Apply(name string, targets []string) error {
+	l.network = fmt.Sprintf("%s-net-%s", l.s.Name, name)
+
+	// fail before changing the deployment when it can't be completed
+	if err := l.checkImages(); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	op := types.OpCreate
+	if len(targets) > 0 {
+		op = types.OpReplace
+	}
+	err := l.withProgress(func() error {
+		if len(targets) > 0 {
+			return l.applyTargets(name, targets)
+		}
+		return l.applyResources(name)
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(targets) == 0 {
+		err = l.smokeTests(name)
+		if err != nil {
+			// there is no previous version to go back to locally, so remove the broken deployment,
+			// leaving the stack's other deployments running
+			rmErr := l.Delete(name)
+			if rmErr != nil {
+				return errors.WithMessagef(err, "removing the deployment failed: %v", rmErr)
+			}
+			return errors.WithMessage(err, "deployment removed")
+		}
+	}
+
+	// the endpoints are the outputs of the deployment
+	urls, err := l.endpoints(name)
+	if err != nil {
+		return err
+	}
+	l.events(types.Event{
+		Resource:   "deployment:" + name,
+		Op:         op,
+		Phase:      types.PhaseSucceeded,
+		DurationMs: time.Since(start).Milliseconds(),
+		Outputs:    urls,
+	})
+	return nil
+}
+
+// checkImages returns an error listing the functions whose images haven't been built
+func (l *local) checkImages() error {
+	errs := utils.NewErrorList()
+	for _, f := range l.s.Functions {
+		if f.Tag != "" {
+			// custom tags can't be listed by stack, these fail when their container is created
+			continue
+		}
+		imageName := f.ImageTagName(l.s, l.t.Provider)
+		imgs, err := l.cr.ListImages(l.s.Name, f.Name())
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, img := range imgs {
+			found = found || img.Repository == imageName
+		}
+		if !found {
+			errs.Add(fmt.Errorf("function %s: image %s not found, build it with 'nitric build create'", f.Name(), imageName))
+		}
+	}
+	return errs.Aggregate()
+}
+
+// withProgress runs fn with its events shown on an interactive progress display when writing to a terminal
+func (l *local) withProgress(fn func() error) error {
+	if !output.Interactive() {
+		return fn()
+	}
+	events := l.events
+	progress := output.NewProgress(os.Stdout)
+	restore, err := progress.CaptureStdout()
+	if err != nil {
+		progress.Stop()
+		return err
+	}
+	l.events = progressEvents(progress)
+	defer func() {
+		restore()
+		progress.Stop()
+		l.events = events
+	}()
+	return fn()
+}
+
+// progressEvents shows the events on the interactive progress display
+func progressEvents(p *output.Progress) types.EventHandler {
+	return func(e types.Event) {
+		msg := e.Message
+		if e.Error != "" {
+			msg = e.Error
+		}
+		p.Update(e.Resource, string(e.Phase), msg)
+	}
+}
+
+// applyResources replaces the whole deployment, the stack's other deployments are left running
+func (l *local) applyResources(name string) error {
+	err := l.cr.RemoveByLabel(map[string]string{LabelStackName: l.s.Name, LabelRunID: name})
 	if err != nil {
 		return err
 	}
 
-	l.network = fmt.Sprintf("%s-net-%s", l.s.Name, name)
 	err = l.cr.NetworkCreate(l.network)
 	if err != nil {
 		return errors.WithMessage(err, "network")
 	}
 
-	err = l.storage(name)
+	err = types.Track(l.events, types.OpCreate, "storage", func() error { return l.storage(name) })
 	if err != nil {
 		return errors.WithMessage(err, "storage")
 	}
 
 	for _, f := range l.s.Functions {
-		err = l.function(name, &f)
+		for _, topic := range f.Triggers.Topics {
+			if _, ok := l.s.Topics[topic]; !ok {
+				l.events(types.Event{
+					Resource: "function:" + f.Name(),
+					Phase:    types.PhaseWarning,
+					Message:  fmt.Sprintf("subscribes to topic %s which is not declared in the stack", topic),
+				})
+			}
+		}
+		err = types.Track(l.events, types.OpCreate, "function:"+f.Name(), func() error { return l.function(name, &f) })
 		if err != nil {
 			return errors.WithMessage(err, "function "+f.Name())
 		}
 	}
 
 	for k, apiFile := range l.s.Apis {
-		err = l.gateway(name, k, apiFile)
+		err = types.Track(l.events, types.OpCreate, "api:"+k, func() error { return l.gateway(name, k, apiFile) })
 		if err != nil {
 			return errors.WithMessage(err, "gateway "+k)
 		}
 	}
 
 	for k, v := range l.s.EntryPoints {
-		err = l.entrypoint(name, k, &v)
+		err = types.Track(l.events, types.OpCreate, "entrypoint:"+k, func() error { return l.entrypoint(name, k, &v) })
 		if err != nil {
 			return errors.WithMessage(err, "entrypoint "+k)
 		}
@@ -108,6 +241,80 @@ func (l *local) Apply(name string) error {
 	return nil
 }
 
+// applyTargets replaces only the containers of the targeted resources, leaving
+// the network, storage and the rest of the deployment running.
+func (l *local) applyTargets(name string, targets []string) error {
+	existing, err := l.cr.ContainersListByLabel(map[string]string{LabelStackName: l.s.Name, LabelRunID: name})
+	if err != nil {
+		return err
+	}
+	if len(existing) == 0 {
+		return fmt.Errorf("deployment %s does not exist, apply it without targets first", name)
+	}
+
+	for _, t := range targets {
+		parts := strings.SplitN(t, ":", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid target %s, expected <type>:<name> e.g. function:api", t)
+		}
+		resType, resName := parts[0], parts[1]
+
+		switch resType {
+		case "function":
+			f, ok := l.s.Functions[resName]
+			if !ok {
+				return fmt.Errorf("function %s not found in the stack", resName)
+			}
+			err = l.replace(name, t, functionContainerName(l.s, &f, l.t.Provider, name), func() error { return l.function(name, &f) })
+		case "api":
+			apiFile, ok := l.s.Apis[resName]
+			if !ok {
+				return fmt.Errorf("api %s not found in the stack", resName)
+			}
+			err = l.replace(name, t, gatewayContainerName(resName, name), func() error { return l.gateway(name, resName, apiFile) })
+		case "entrypoint":
+			e, ok := l.s.EntryPoints[resName]
+			if !ok {
+				return fmt.Errorf("entrypoint %s not found in the stack", resName)
+			}
+			err = l.replace(name, t, entrypointContainerName(resName, name), func() error { return l.entrypoint(name, resName, &e) })
+		default:
+			return fmt.Errorf("invalid target %s, type must be one of function, api or entrypoint", t)
+		}
+		if err != nil {
+			return errors.WithMessage(err, t)
+		}
+	}
+	return nil
+}
+
+// replace removes the container of the resource and creates it again. Containers deployed before
+// resources were labelled are found by their container name instead.
+func (l *local) replace(name, resource, containerName string, create func() error) error {
+	err := l.cr.RemoveByLabel(map[string]string{
+		LabelStackName: l.s.Name,
+		LabelRunID:     name,
+		LabelResource:  resource,
+	})
+	if err != nil {
+		return err
+	}
+	existing, err := l.cr.ContainersListByLabel(map[string]string{LabelStackName: l.s.Name, LabelRunID: name})
+	if err != nil {
+		return err
+	}
+	for _, c := range existing {
+		for _, n := range c.Names {
+			if strings.TrimPrefix(n, "/") == containerName {
+				if err := l.cr.RemoveContainer(c.ID); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return types.Track(l.events, types.OpReplace, resource, create)
+}
+
 type containerSummary struct {
 	Image  string
 	ID     string
@@ -115,6 +322,8 @@ type containerSummary struct {
 	State  string
 	Status string
 	Ports  []int
+	Commit string
+	Branch string
 }
 
 func (l *local) List() (interface{}, error) {
@@ -135,19 +344,91 @@ func (l *local) List() (interface{}, error) {
 			State:  c.State,
 			Status: c.Status,
 			Ports:  ports,
+			Commit: gitCommit(c.Labels),
+			Branch: c.Labels[LabelGitPrefix+"branch"],
 		})
 	}
 	return cons, nil
 }
 
 func (l *local) Delete(name string) error {
-	return l.cr.RemoveByLabel(LabelStackName, l.s.Name)
+	res, err := l.cr.ContainersListByLabel(map[string]string{LabelStackName: l.s.Name, LabelRunID: name})
+	if err != nil {
+		return err
+	}
+	return l.withProgress(func() error {
+		for _, c := range res {
+			// containers of older versions have no resource label, they are removed by ID all the same
+			resource := c.Labels[LabelResource]
+			if resource == "" && len(c.Names) > 0 {
+				resource = strings.TrimPrefix(c.Names[0], "/")
+			}
+			id := c.ID
+			err := types.Track(l.events, types.OpDelete, resource, func() error {
+				return l.cr.RemoveContainer(id)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+type resourceSummary struct {
+	Type       string
+	Resource   string
+	Deployment string
+	ID         string
+	Image      string
+}
+
+func (l *local) DeletePlan(name string) (interface{}, error) {
+	// matches the containers removed by Delete
+	res, err := l.cr.ContainersListByLabel(map[string]string{LabelStackName: l.s.Name, LabelRunID: name})
+	if err != nil {
+		return nil, err
+	}
+	plan := []resourceSummary{}
+	for _, c := range res {
+		plan = append(plan, resourceSummary{
+			Type:       c.Labels[LabelType],
+			Resource:   c.Labels[LabelResource],
+			Deployment: c.Labels[LabelRunID],
+			ID:         c.ID[0:12],
+			Image:      c.Image,
+		})
+	}
+	sort.Slice(plan, func(i, j int) bool {
+		if plan[i].Type != plan[j].Type {
+			return plan[i].Type < plan[j].Type
+		}
+		return plan[i].Resource < plan[j].Resource
+	})
+	return plan, nil
 }
 
-func (l *local) labels(deploymentName, contType string) map[string]string {
-	return map[string]string{
+// gitCommit returns the short commit a container was deployed from, marked when there were uncommitted changes
+func gitCommit(labels map[string]string) string {
+	commit := labels[LabelGitPrefix+"commit"]
+	if len(commit) > 12 {
+		commit = commit[0:12]
+	}
+	if commit != "" && labels[LabelGitPrefix+"dirty"] == "true" {
+		commit += "-dirty"
+	}
+	return commit
+}
+
+func (l *local) labels(deploymentName, contType, resource string) map[string]string {
+	labels := map[string]string{
 		LabelStackName: l.s.Name,
 		LabelRunID:     deploymentName,
 		LabelType:      contType,
+		LabelResource:  resource,
+	}
+	for k, v := range l.git {
+		labels[k] = v
 	}
+	return labels
 }
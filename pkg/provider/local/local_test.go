@@ -0,0 +1,213 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+
+	mock_containerengine "github.com/nitrictech/newcli/mocks/containerengine"
+	ptypes "github.com/nitrictech/newcli/pkg/provider/types"
+	"github.com/nitrictech/newcli/pkg/stack"
+	"github.com/nitrictech/newcli/pkg/target"
+)
+
+func TestReplace(t *testing.T) {
+	tests := []struct {
+		name        string
+		existing    []types.Container
+		wantRemoved []string
+	}{
+		{
+			name:     "labelled",
+			existing: []types.Container{{ID: "other", Names: []string{"/api-main-test"}}},
+		},
+		{
+			name: "deployed before resources were labelled",
+			existing: []types.Container{
+				{ID: "old", Names: []string{"/shop-orders-local-test"}},
+				{ID: "other", Names: []string{"/api-main-test"}},
+			},
+			wantRemoved: []string{"old"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			me := mock_containerengine.NewMockContainerEngine(ctrl)
+			me.EXPECT().RemoveByLabel(map[string]string{LabelStackName: "shop", LabelRunID: "test", LabelResource: "function:orders"})
+			me.EXPECT().ContainersListByLabel(map[string]string{LabelStackName: "shop", LabelRunID: "test"}).Return(tt.existing, nil)
+			for _, id := range tt.wantRemoved {
+				me.EXPECT().RemoveContainer(id)
+			}
+
+			l := &local{s: &stack.Stack{Name: "shop"}, cr: me, events: func(ptypes.Event) {}}
+			created := false
+			err := l.replace("test", "function:orders", "shop-orders-local-test", func() error {
+				created = true
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("replace() error = %v", err)
+			}
+			if !created {
+				t.Error("replace() did not create the resource")
+			}
+		})
+	}
+}
+
+func TestServiceDiscoveryEnv(t *testing.T) {
+	s := &stack.Stack{
+		Functions: map[string]stack.Function{"orders": {}, "my-api": {}},
+		Apis:      map[string]string{"orders": "orders.yaml"},
+	}
+	want := []string{
+		"NITRIC_API_ORDERS_URL=http://api-orders:8080",
+		"NITRIC_FUNCTION_MY_API_URL=http://my-api:9001",
+		"NITRIC_FUNCTION_ORDERS_URL=http://orders:9001",
+	}
+	if got := serviceDiscoveryEnv(s); !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestDeleteDeployment(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	me := mock_containerengine.NewMockContainerEngine(ctrl)
+	// only the containers of the deployment are listed, so other deployments of the stack are kept
+	me.EXPECT().ContainersListByLabel(map[string]string{LabelStackName: "shop", LabelRunID: "test"}).Return([]types.Container{
+		{ID: "orders", Labels: map[string]string{LabelStackName: "shop", LabelRunID: "test", LabelResource: "function:orders"}},
+		{ID: "legacy", Names: []string{"/shop-users-test"}, Labels: map[string]string{LabelStackName: "shop", LabelRunID: "test"}},
+	}, nil)
+	// removed by ID, so containers without a resource label are removed as well
+	me.EXPECT().RemoveContainer("orders")
+	me.EXPECT().RemoveContainer("legacy")
+
+	l := &local{s: &stack.Stack{Name: "shop"}, cr: me, events: func(ptypes.Event) {}}
+	if err := l.Delete("test"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+}
+
+func TestApplyFailedSmokeTest(t *testing.T) {
+	dir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "nitric.yaml"), []byte("name: shop\nsmokeTests:\n  broken:\n    command: exit 1\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := stack.FromFile(filepath.Join(dir, "nitric.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctrl := gomock.NewController(t)
+	me := mock_containerengine.NewMockContainerEngine(ctrl)
+	// only the containers of the deployment are replaced and removed, so the stack's prod deployment is never touched
+	me.EXPECT().RemoveByLabel(map[string]string{LabelStackName: "shop", LabelRunID: "test"})
+	me.EXPECT().NetworkCreate("shop-net-test")
+	me.EXPECT().Pull(gomock.Any())
+	me.EXPECT().ContainerCreate(gomock.Any(), gomock.Any(), gomock.Any(), "minio-test").Return("storage", nil)
+	me.EXPECT().Start("storage")
+	me.EXPECT().ContainersListByLabel(map[string]string{LabelStackName: "shop", LabelRunID: "test"}).Return([]types.Container{
+		{ID: "storage", Labels: map[string]string{LabelStackName: "shop", LabelRunID: "test", LabelResource: "storage"}},
+	}, nil).Times(2)
+	me.EXPECT().RemoveContainer("storage")
+
+	l := &local{s: s, t: &target.Target{Provider: "local"}, cr: me, events: func(ptypes.Event) {}}
+	err = l.Apply("test", nil)
+	if err == nil || !strings.Contains(err.Error(), "deployment removed") {
+		t.Errorf("Apply() error = %v, want the failed deployment removed", err)
+	}
+}
+
+func TestSmokeTests(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	ctrl := gomock.NewController(t)
+	me := mock_containerengine.NewMockContainerEngine(ctrl)
+	me.EXPECT().ContainersListByLabel(map[string]string{LabelStackName: "shop", LabelRunID: "test"}).Return([]types.Container{
+		{Labels: map[string]string{LabelResource: "api:main"}, Ports: []types.Port{{PublicPort: 49200}}},
+	}, nil)
+
+	l := &local{s: &stack.Stack{Name: "shop", SmokeTests: map[string]stack.SmokeTest{
+		"c": {Command: "echo c >> " + out},
+		"a": {Command: "echo a $NITRIC_API_MAIN_URL >> " + out},
+		"b": {Command: "echo b >> " + out},
+	}}, cr: me}
+	if err := l.smokeTests("test"); err != nil {
+		t.Fatalf("smokeTests() error = %v", err)
+	}
+
+	got, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "a http://localhost:49200\nb\nc\n"
+	if string(got) != want {
+		t.Errorf("smoke tests ran %q, want %q", got, want)
+	}
+}
+
+func TestSmokeEnv(t *testing.T) {
+	got := smokeEnv(map[string]string{
+		"function:orders": "http://localhost:49201",
+		"api:orders":      "http://localhost:49200",
+		"unlabelled":      "http://localhost:49202",
+	})
+	want := []string{
+		"NITRIC_API_ORDERS_URL=http://localhost:49200",
+		"NITRIC_FUNCTION_ORDERS_URL=http://localhost:49201",
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	out := &strings.Builder{}
+	w := &prefixWriter{prefix: "[orders]", out: out, lock: &sync.Mutex{}}
+
+	for _, p := range []string{"starting\nlisten", "ing on 9001\n", "exiting"} {
+		if _, err := w.Write([]byte(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := "[orders] starting\n[orders] listening on 9001\n"
+	if out.String() != want {
+		t.Errorf("Write() wrote %q, want %q", out.String(), want)
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want += "[orders] exiting\n"
+	if out.String() != want {
+		t.Errorf("Flush() wrote %q, want %q", out.String(), want)
+	}
+
+	// nothing is left to flush
+	if err := w.Flush(); err != nil || out.String() != want {
+		t.Errorf("second Flush() wrote %q, err %v", out.String(), err)
+	}
+}
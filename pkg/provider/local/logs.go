@@ -0,0 +1,129 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+
+	"github.com/nitrictech/newcli/pkg/provider/types"
+)
+
+// prefixWriter writes complete lines to out, each prefixed with the name of the function
+type prefixWriter struct {
+	prefix string
+	out    io.Writer
+	lock   *sync.Mutex
+	buf    bytes.Buffer
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	for {
+		line, err := w.buf.ReadString('\n')
+		if err != nil {
+			// keep the partial line until the rest of it is written
+			w.buf.WriteString(line)
+			return len(p), nil
+		}
+		w.lock.Lock()
+		_, err = fmt.Fprintf(w.out, "%s %s", w.prefix, line)
+		w.lock.Unlock()
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Flush writes the trailing partial line, if any, ending it with a newline
+func (w *prefixWriter) Flush() error {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	line := w.buf.String()
+	w.buf.Reset()
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	_, err := fmt.Fprintf(w.out, "%s %s\n", w.prefix, line)
+	return err
+}
+
+func (l *local) Logs(name string, opts types.LogOptions) error {
+	match := map[string]string{
+		LabelStackName: l.s.Name,
+		LabelRunID:     name,
+		LabelType:      "function",
+	}
+	if opts.Function != "" {
+		match[LabelResource] = "function:" + opts.Function
+	}
+
+	cons, err := l.cr.ContainersListByLabel(match)
+	if err != nil {
+		return err
+	}
+	if len(cons) == 0 {
+		return fmt.Errorf("no function containers found for deployment %s", name)
+	}
+
+	lock := &sync.Mutex{}
+	errs := make(chan error, len(cons))
+	wg := sync.WaitGroup{}
+	for _, c := range cons {
+		wg.Add(1)
+		go func(id, resource string) {
+			defer wg.Done()
+			rc, err := l.cr.Logs(id, dockertypes.ContainerLogsOptions{
+				ShowStdout: true,
+				ShowStderr: true,
+				Follow:     opts.Follow,
+				Since:      opts.Since,
+			})
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer rc.Close()
+
+			prefix := "[" + strings.TrimPrefix(resource, "function:") + "]"
+			stdout := &prefixWriter{prefix: prefix, out: os.Stdout, lock: lock}
+			stderr := &prefixWriter{prefix: prefix, out: os.Stderr, lock: lock}
+			_, err = stdcopy.StdCopy(stdout, stderr, rc)
+			// the stream can end without a newline, e.g. when the function exits mid-line
+			for _, w := range []*prefixWriter{stdout, stderr} {
+				if flushErr := w.Flush(); err == nil {
+					err = flushErr
+				}
+			}
+			if err != nil {
+				errs <- err
+			}
+		}(c.ID, c.Labels[LabelResource])
+	}
+	wg.Wait()
+	close(errs)
+
+	return <-errs
+}
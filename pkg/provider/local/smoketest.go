@@ -0,0 +1,137 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/nitrictech/newcli/pkg/output"
+	"github.com/nitrictech/newcli/pkg/stack"
+)
+
+// containers can take a while to start serving, so HTTP checks are retried until this timeout
+const smokeTestTimeout = 30 * time.Second
+
+// smokeTests runs the stack's smoke tests against the deployment
+func (l *local) smokeTests(deploymentName string) error {
+	if len(l.s.SmokeTests) == 0 {
+		return nil
+	}
+
+	urls, err := l.endpoints(deploymentName)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(l.s.SmokeTests))
+	for name := range l.s.SmokeTests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		t := l.s.SmokeTests[name]
+		if t.Command != "" {
+			err = runSmokeCommand(l.s.Path(), t.Command, urls)
+		} else {
+			err = checkSmokeHTTP(t, urls)
+		}
+		if err != nil {
+			return errors.WithMessage(err, "smoke test "+name)
+		}
+	}
+	return nil
+}
+
+// endpoints returns the host URLs of the deployment's published resources, keyed by <type>:<name>
+func (l *local) endpoints(deploymentName string) (map[string]string, error) {
+	res, err := l.cr.ContainersListByLabel(map[string]string{LabelStackName: l.s.Name, LabelRunID: deploymentName})
+	if err != nil {
+		return nil, err
+	}
+	urls := map[string]string{}
+	for _, c := range res {
+		for _, p := range c.Ports {
+			if p.PublicPort != 0 {
+				urls[c.Labels[LabelResource]] = fmt.Sprintf("http://localhost:%d", p.PublicPort)
+			}
+		}
+	}
+	return urls, nil
+}
+
+func runSmokeCommand(dir, command string, urls map[string]string) error {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	if output.Structured() {
+		cmd.Stdout = os.Stderr
+	}
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), smokeEnv(urls)...)
+	return cmd.Run()
+}
+
+// smokeEnv returns the service discovery env vars of the published resources, sorted by name
+func smokeEnv(urls map[string]string) []string {
+	env := []string{}
+	for resource, url := range urls {
+		parts := strings.SplitN(resource, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		env = append(env, fmt.Sprintf("%s=%s", serviceEnvName(parts[0], parts[1]), url))
+	}
+	sort.Strings(env)
+	return env
+}
+
+func checkSmokeHTTP(t stack.SmokeTest, urls map[string]string) error {
+	url, ok := urls[t.Target]
+	if !ok {
+		return fmt.Errorf("target %s is not a published resource of the deployment", t.Target)
+	}
+	status := t.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	client := http.Client{Timeout: 5 * time.Second}
+	deadline := time.Now().Add(smokeTestTimeout)
+	for {
+		resp, err := client.Get(url + t.Path)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == status {
+				return nil
+			}
+			err = fmt.Errorf("GET %s returned %d, expected %d", url+t.Path, resp.StatusCode, status)
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(time.Second)
+	}
+}
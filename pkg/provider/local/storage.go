@@ -52,7 +52,7 @@ func (l *local) storage(deploymentName string) error {
 	cID, err := l.cr.ContainerCreate(&container.Config{
 		Image:  minioImage,
 		Cmd:    []string{"minio", "server", "/nitric/buckets", "--console-address", fmt.Sprintf(":%d", consolePort)},
-		Labels: l.labels(deploymentName, "storage"),
+		Labels: l.labels(deploymentName, "storage", "storage"),
 		ExposedPorts: nat.PortSet{
 			nat.Port(fmt.Sprintf("%d/tcp", minioPort)):        struct{}{},
 			nat.Port(fmt.Sprintf("%d/tcp", minioConsolePort)): struct{}{},
@@ -17,10 +17,42 @@
 package local
 
 import (
+	"fmt"
 	"io"
 	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nitrictech/newcli/pkg/stack"
 )
 
+var nonEnvChars = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// serviceEnvName returns the env var holding the URL of a resource, namespaced by its kind so a
+// function and an api can share a name, e.g. NITRIC_API_MY_API_URL
+func serviceEnvName(kind, name string) string {
+	return "NITRIC_" + envName(kind) + "_" + envName(name) + "_URL"
+}
+
+func envName(s string) string {
+	return nonEnvChars.ReplaceAllString(strings.ToUpper(s), "_")
+}
+
+// serviceDiscoveryEnv returns the URLs of every function and api in the stack, as they are
+// reachable on the deployment network
+func serviceDiscoveryEnv(s *stack.Stack) []string {
+	env := []string{}
+	for name := range s.Functions {
+		env = append(env, fmt.Sprintf("%s=http://%s:%d", serviceEnvName("function", name), name, functionPort))
+	}
+	for name := range s.Apis {
+		env = append(env, fmt.Sprintf("%s=http://api-%s:%d", serviceEnvName("api", name), name, gatewayPort))
+	}
+	sort.Strings(env)
+	return env
+}
+
 func copyFile(src, dst string) error {
 	in, err := os.Open(src)
 	if err != nil {
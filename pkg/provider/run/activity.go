@@ -0,0 +1,80 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"sync"
+	"time"
+)
+
+// maxActivity is the number of recent requests and messages kept for the dashboard
+const maxActivity = 100
+
+type ActivityKind string
+
+const (
+	ActivityRequest ActivityKind = "request"
+	ActivityMessage ActivityKind = "message"
+)
+
+// ActivityEvent is a request to an api or a message published to a topic
+type ActivityEvent struct {
+	Time time.Time    `json:"time"`
+	Kind ActivityKind `json:"kind"`
+	// The api or topic
+	Name string `json:"name"`
+	// The method and path of a request, or the payload of a message
+	Detail string `json:"detail"`
+	// The response status of a request, or the failed deliveries of a message
+	Status     int     `json:"status"`
+	DurationMs float64 `json:"durationMs"`
+}
+
+// Activity keeps the most recent events of the local gateway
+type Activity struct {
+	mu     sync.Mutex
+	events []ActivityEvent
+}
+
+func NewActivity() *Activity {
+	return &Activity{events: []ActivityEvent{}}
+}
+
+func (a *Activity) record(e ActivityEvent) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.events = append(a.events, e)
+	if len(a.events) > maxActivity {
+		a.events = a.events[len(a.events)-maxActivity:]
+	}
+}
+
+// Recent returns the recorded events, most recent first
+func (a *Activity) Recent() []ActivityEvent {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	recent := make([]ActivityEvent, len(a.events))
+	for i, e := range a.events {
+		recent[len(a.events)-1-i] = e
+	}
+	return recent
+}
@@ -0,0 +1,93 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestActivity(t *testing.T) {
+	tests := []struct {
+		name      string
+		recorded  int
+		wantLen   int
+		wantFirst string
+	}{
+		{name: "none", recorded: 0, wantLen: 0},
+		{name: "most recent first", recorded: 3, wantLen: 3, wantFirst: "event-2"},
+		{name: "oldest dropped", recorded: maxActivity + 5, wantLen: maxActivity, wantFirst: fmt.Sprintf("event-%d", maxActivity+4)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewActivity()
+			for i := 0; i < tt.recorded; i++ {
+				a.record(ActivityEvent{Kind: ActivityRequest, Name: fmt.Sprintf("event-%d", i)})
+			}
+
+			got := a.Recent()
+			if len(got) != tt.wantLen {
+				t.Fatalf("Recent() returned %d events, want %d", len(got), tt.wantLen)
+			}
+			if tt.wantLen > 0 && got[0].Name != tt.wantFirst {
+				t.Errorf("Recent()[0] = %s, want %s", got[0].Name, tt.wantFirst)
+			}
+		})
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	m := NewMetrics()
+	m.observeRequest("orders", "create", 200, 20*time.Millisecond)
+	m.observeRequest("orders", "create", 500, 2*time.Second)
+	m.observeRequest("orders", "", 404, time.Millisecond)
+	m.observeRequest("carts", "carts", 200, time.Millisecond)
+	m.observeDeliveries("created", 2, 1)
+	m.observeRestarts("create", 0)
+	m.observeRestarts("carts", 0)
+	m.observeRestarts("carts", 1)
+
+	b := &strings.Builder{}
+	m.write(b)
+	got := b.String()
+
+	for _, want := range []string{
+		`nitric_api_requests_total{api="carts",function="carts",status="200"} 1`,
+		`nitric_api_requests_total{api="orders",function="create",status="200"} 1`,
+		`nitric_api_requests_total{api="orders",function="create",status="500"} 1`,
+		`nitric_api_requests_total{api="orders",function="",status="404"} 1`,
+		`nitric_api_request_duration_seconds_bucket{api="orders",function="create",le="0.01"} 0`,
+		`nitric_api_request_duration_seconds_bucket{api="orders",function="create",le="0.025"} 1`,
+		`nitric_api_request_duration_seconds_bucket{api="orders",function="create",le="2.5"} 2`,
+		`nitric_api_request_duration_seconds_bucket{api="orders",function="create",le="+Inf"} 2`,
+		`nitric_api_request_duration_seconds_count{api="orders",function="create"} 2`,
+		`nitric_topic_deliveries_total{topic="created",result="failure"} 1`,
+		`nitric_topic_deliveries_total{topic="created",result="success"} 2`,
+		`nitric_function_restarts_total{function="carts"} 1`,
+		`nitric_function_restarts_total{function="create"} 0`,
+	} {
+		if !strings.Contains(got, want+"\n") {
+			t.Errorf("metrics missing %s, got:\n%s", want, got)
+		}
+	}
+	// series are sorted so scrapes are stable
+	if strings.Index(got, `api="carts",function`) > strings.Index(got, `api="orders",function`) {
+		t.Errorf("request series not sorted by api:\n%s", got)
+	}
+}
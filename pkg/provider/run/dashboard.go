@@ -0,0 +1,286 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nitrictech/newcli/pkg/stack"
+)
+
+//go:embed dashboard/index.html
+var dashboardPage []byte
+
+type dashboardFunction struct {
+	Name    string `json:"name"`
+	Handler string `json:"handler"`
+	Runtime string `json:"runtime"`
+}
+
+type dashboardApi struct {
+	Name string `json:"name"`
+	// Routes of the api, e.g. GET /orders/{id}
+	Routes []string `json:"routes"`
+}
+
+type dashboardSchedule struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+	Topic      string `json:"topic"`
+	// The JSON payload published to the topic
+	Payload string `json:"payload"`
+}
+
+type dashboardState struct {
+	Functions []dashboardFunction `json:"functions"`
+	Apis      []dashboardApi      `json:"apis"`
+	Topics    []string            `json:"topics"`
+	Schedules []dashboardSchedule `json:"schedules"`
+	Activity  []ActivityEvent     `json:"activity"`
+}
+
+// Dashboard is a web UI for nitric run, it lists the functions, apis, topics and schedules
+// of the stack with the recent activity of the gateway, and can call apis and publish to topics.
+// Every request must carry the token of the session, so that other sites can't call the apis through it.
+type Dashboard struct {
+	server     *http.Server
+	address    string
+	token      string
+	gatewayURL string
+	stack      *stack.Stack
+	functions  []*Function
+	activity   *Activity
+	http       *http.Client
+}
+
+// NewDashboard creates a dashboard served on address, s is optional
+func NewDashboard(address, gatewayURL string, s *stack.Stack, functions []*Function, activity *Activity) (*Dashboard, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	d := &Dashboard{
+		address:    address,
+		token:      hex.EncodeToString(token),
+		gatewayURL: strings.TrimSuffix(gatewayURL, "/"),
+		stack:      s,
+		functions:  functions,
+		activity:   activity,
+		http:       &http.Client{Timeout: 30 * time.Second},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", d.index)
+	mux.HandleFunc("/api/state", d.state)
+	mux.HandleFunc("/api/invoke", d.invoke)
+	mux.HandleFunc("/api/publish", d.publish)
+	d.server = &http.Server{Addr: address, Handler: d.authorize(mux)}
+	return d, nil
+}
+
+// URL returns the address of the dashboard with the token of the session
+func (d *Dashboard) URL() string {
+	return fmt.Sprintf("http://%s/?token=%s", d.address, d.token)
+}
+
+// authorize rejects the requests without the token of the session, the page is opened with the token
+// in its query and sends it to the api in the X-Dashboard-Token header
+func (d *Dashboard) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			token = r.Header.Get("X-Dashboard-Token")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(d.token)) != 1 {
+			http.Error(w, "invalid dashboard token, open the dashboard with the url printed by nitric run", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (d *Dashboard) Start() error {
+	err := d.server.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+func (d *Dashboard) Stop() error {
+	return d.server.Shutdown(context.Background())
+}
+
+func (d *Dashboard) index(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(dashboardPage)
+}
+
+func (d *Dashboard) currentState() (dashboardState, error) {
+	state := dashboardState{
+		Functions: []dashboardFunction{},
+		Apis:      []dashboardApi{},
+		Topics:    []string{},
+		Schedules: []dashboardSchedule{},
+		Activity:  d.activity.Recent(),
+	}
+	for _, f := range d.functions {
+		state.Functions = append(state.Functions, dashboardFunction{Name: f.Name(), Handler: f.handler, Runtime: string(f.runtime)})
+	}
+	if d.stack == nil {
+		return state, nil
+	}
+
+	for name := range d.stack.Apis {
+		api := dashboardApi{Name: name, Routes: []string{}}
+		if doc, ok := d.stack.ApiDoc(name); ok {
+			for p, item := range doc.Paths {
+				for m := range item.Operations() {
+					api.Routes = append(api.Routes, m+" "+p)
+				}
+			}
+		}
+		sort.Strings(api.Routes)
+		state.Apis = append(state.Apis, api)
+	}
+	sort.Slice(state.Apis, func(i, j int) bool { return state.Apis[i].Name < state.Apis[j].Name })
+
+	for name := range d.stack.Topics {
+		state.Topics = append(state.Topics, name)
+	}
+	sort.Strings(state.Topics)
+
+	for name, sc := range d.stack.Schedules {
+		payload := []byte("{}")
+		if sc.Event.Payload != nil {
+			var err error
+			payload, err = json.Marshal(jsonMap(sc.Event.Payload))
+			if err != nil {
+				return state, fmt.Errorf("schedule %s has an invalid payload: %w", name, err)
+			}
+		}
+		state.Schedules = append(state.Schedules, dashboardSchedule{
+			Name:       name,
+			Expression: sc.Expression,
+			Topic:      sc.Target.Name,
+			Payload:    string(payload),
+		})
+	}
+	sort.Slice(state.Schedules, func(i, j int) bool { return state.Schedules[i].Name < state.Schedules[j].Name })
+
+	return state, nil
+}
+
+func (d *Dashboard) state(w http.ResponseWriter, r *http.Request) {
+	state, err := d.currentState()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state)
+}
+
+type invokeRequest struct {
+	Method string `json:"method"`
+	Api    string `json:"api"`
+	Path   string `json:"path"`
+	Body   string `json:"body"`
+}
+
+type publishRequest struct {
+	Topic   string `json:"topic"`
+	Payload string `json:"payload"`
+}
+
+type forwardResponse struct {
+	Status int    `json:"status"`
+	Body   string `json:"body"`
+}
+
+// invoke calls a route of an api through the gateway
+func (d *Dashboard) invoke(w http.ResponseWriter, r *http.Request) {
+	req := invokeRequest{}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if req.Method == "" {
+		req.Method = http.MethodGet
+	}
+	d.forward(w, strings.ToUpper(req.Method), fmt.Sprintf("%s/apis/%s/%s", d.gatewayURL, req.Api, strings.TrimPrefix(req.Path, "/")), req.Body)
+}
+
+// publish publishes a message to a topic through the gateway
+func (d *Dashboard) publish(w http.ResponseWriter, r *http.Request) {
+	req := publishRequest{}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if req.Payload == "" {
+		req.Payload = "{}"
+	}
+	d.forward(w, http.MethodPost, fmt.Sprintf("%s/topic/%s", d.gatewayURL, req.Topic), req.Payload)
+}
+
+func decodeRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func (d *Dashboard) forward(w http.ResponseWriter, method, url, body string) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp, err := d.http.Do(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(forwardResponse{Status: resp.StatusCode, Body: string(b)})
+}
@@ -0,0 +1,107 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nitrictech/newcli/pkg/stack"
+)
+
+func TestDashboardToken(t *testing.T) {
+	d, err := NewDashboard("localhost:49152", "http://localhost:9001", nil, nil, NewActivity())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(d.URL(), "/?token="+d.token) {
+		t.Errorf("URL() = %s, want the session token in the query", d.URL())
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		header string
+		want   int
+	}{
+		{name: "page with token", method: http.MethodGet, target: "/?token=" + d.token, want: http.StatusOK},
+		{name: "page without token", method: http.MethodGet, target: "/", want: http.StatusForbidden},
+		{name: "state with token", method: http.MethodGet, target: "/api/state", header: d.token, want: http.StatusOK},
+		{name: "state with wrong token", method: http.MethodGet, target: "/api/state", header: "guess", want: http.StatusForbidden},
+		{name: "invoke without token", method: http.MethodPost, target: "/api/invoke", want: http.StatusForbidden},
+		{name: "invoke with query token", method: http.MethodPost, target: "/api/invoke?token=" + d.token, want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader("{}"))
+			if tt.header != "" {
+				req.Header.Set("X-Dashboard-Token", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			d.server.Handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("%s %s = %d, want %d", tt.method, tt.target, rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestDashboardSchedulePayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload map[string]interface{}
+		want    string
+		wantErr bool
+	}{
+		{name: "none", want: "{}"},
+		{name: "nested", payload: map[string]interface{}{"report": map[interface{}]interface{}{"days": 7}}, want: `{"report":{"days":7}}`},
+		{name: "invalid", payload: map[string]interface{}{"report": make(chan int)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &stack.Stack{Schedules: map[string]stack.Schedule{
+				"weekly": {Expression: "@weekly", Target: stack.ScheduleTarget{Type: "topic", Name: "reports"}, Event: stack.ScheduleEvent{Payload: tt.payload}},
+			}}
+			d, err := NewDashboard("localhost:49152", "http://localhost:9001", s, nil, NewActivity())
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/api/state", nil)
+			req.Header.Set("X-Dashboard-Token", d.token)
+			rec := httptest.NewRecorder()
+			d.server.Handler.ServeHTTP(rec, req)
+
+			if tt.wantErr {
+				if rec.Code != http.StatusInternalServerError {
+					t.Errorf("state = %d, want %d", rec.Code, http.StatusInternalServerError)
+				}
+				return
+			}
+			state := dashboardState{}
+			if err := json.NewDecoder(rec.Body).Decode(&state); err != nil {
+				t.Fatal(err)
+			}
+			if len(state.Schedules) != 1 || state.Schedules[0].Payload != tt.want {
+				t.Errorf("schedules = %v, want the payload %s", state.Schedules, tt.want)
+			}
+		})
+	}
+}
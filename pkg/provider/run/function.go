@@ -16,27 +16,51 @@
 package run
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
 	"path/filepath"
 	"runtime"
 	"strings"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/strslice"
+	"github.com/spf13/viper"
 
 	"github.com/nitrictech/newcli/pkg/containerengine"
+	"github.com/nitrictech/newcli/pkg/stack"
 	"github.com/nitrictech/newcli/pkg/utils"
 )
 
 type Function struct {
+	name    string
 	handler string
 	runCtx  string
 	runtime utils.Runtime
 	ce      containerengine.ContainerEngine
 	// Container id populated after a call to Start
 	cid string
+	// The command that runs the handler on the host, instead of in a container
+	nativeCmd []string
+	// The host process populated after a call to Start
+	process *exec.Cmd
+	// The paths that restart the function
+	watch *stack.Watch
+	// The memory and cpu limits of the function when it's deployed
+	memory int
+	cpu    float64
+	// Env vars of the function as KEY=VALUE
+	env []string
+	// Files mounted (read only) into the function's container
+	files []stack.FileMount
+	// Counts the restarts of the function when set
+	metrics *Metrics
 }
 
 type LaunchOpts struct {
@@ -50,34 +74,109 @@ func launchOptsForFunction(f *Function) (LaunchOpts, error) {
 	if err != nil {
 		return opts, err
 	}
+	// the project is bind mounted to /app, so nodemon restarts the handler when it is edited on the host
+	include := []string{"/app"}
+	exclude := []string{"/app/node_modules/", "/app/.nitric/", "/app/bin/", "/app/obj/"}
+	if f.watch != nil {
+		if len(f.watch.Include) > 0 {
+			include = []string{}
+		}
+		for _, p := range f.watch.Include {
+			include = append(include, path.Join("/app", p))
+		}
+		for _, p := range f.watch.Exclude {
+			exclude = append(exclude, path.Join("/app", p))
+		}
+	}
+	watch := []string{}
+	for _, p := range include {
+		watch = append(watch, "--watch", p)
+	}
+	for _, p := range exclude {
+		watch = append(watch, "--ignore", p)
+	}
+	// changes within the delay are batched into a single restart
+	if delay := viper.GetDuration("watch_delay"); delay > 0 {
+		watch = append(watch, "--delay", fmt.Sprintf("%dms", delay.Milliseconds()))
+	}
+	// events aren't delivered for bind mounts of network filesystems (and some VMs), so poll instead
+	if viper.GetBool("watch_poll") {
+		watch = append(watch, "--legacy-watch")
+	}
 	switch rt {
-	case utils.RuntimeJavascript, utils.RuntimeTypescript:
-		opts.Cmd = strslice.StrSlice{"--watch", "/app/**", "--ext", "ts,js,json", "--exec", "ts-node -T " + "/app/" + f.handler}
+	case utils.RuntimeJavascript:
+		opts.Cmd = append(watch, "--ext", "ts,js,json", "--exec", "node "+"/app/"+f.handler)
+	case utils.RuntimeTypescript:
+		opts.Cmd = append(watch, "--ext", "ts,js,json", "--exec", "ts-node -T "+"/app/"+f.handler)
+	case utils.RuntimeGolang:
+		opts.Cmd = append(watch, "--ext", "go,mod,sum", "--exec", "go run "+"/app/"+f.handler)
+	case utils.RuntimeDotnet:
+		opts.Cmd = append(watch, "--ext", "cs,csproj,json", "--exec", "dotnet run --project "+"/app/"+f.handler)
 	default:
 		return opts, errors.New("could not get launchOpts from " + f.handler + ", runtime not supported")
 	}
 	return opts, nil
 }
 
+// SetWatch limits the paths that restart the function, it must be called before Start
+func (f *Function) SetWatch(w *stack.Watch) {
+	f.watch = w
+}
+
+// SetEnv sets the env vars (KEY=VALUE) of the function, it must be called before Start
+func (f *Function) SetEnv(env []string) {
+	f.env = env
+}
+
+// SetFiles sets the files mounted into the function's container, their sources must be absolute,
+// it must be called before Start
+func (f *Function) SetFiles(files []stack.FileMount) {
+	f.files = files
+}
+
+// SetLimits sets the memory (in MB) and cpu limits the function is deployed with, with enforce_limits these
+// are applied to its container with cgroups, otherwise a warning is printed when it starts
+func (f *Function) SetLimits(memory int, cpu float64) {
+	f.memory = memory
+	f.cpu = cpu
+}
+
+// SetMetrics counts the restarts of the function in the metrics, it must be called before Start
+func (f *Function) SetMetrics(m *Metrics) {
+	f.metrics = m
+}
+
+// resources returns the cgroup limits of the function's container
+func (f *Function) resources() container.Resources {
+	if !viper.GetBool("enforce_limits") {
+		if f.memory > 0 || f.cpu > 0 {
+			fmt.Printf("function %s: its memory and cpu limits are not enforced locally, run with --enforce-limits to apply them\n", f.Name())
+		}
+		return container.Resources{}
+	}
+	return container.Resources{
+		Memory: int64(f.memory) * 1024 * 1024,
+		// no swap, so exceeding the memory limit kills the function as it would when deployed
+		MemorySwap: int64(f.memory) * 1024 * 1024,
+		NanoCPUs:   int64(f.cpu * 1e9),
+	}
+}
+
 func (f *Function) Name() string {
+	if f.name != "" {
+		return f.name
+	}
 	return strings.Replace(filepath.Base(f.handler), filepath.Ext(f.handler), "", 1)
 }
 
+// HandlerPath returns the absolute path of the function's handler
+func (f *Function) HandlerPath() string {
+	return filepath.Join(f.runCtx, f.handler)
+}
+
 func (f *Function) Start() error {
-	hostConfig := &container.HostConfig{
-		AutoRemove: true,
-		Mounts: []mount.Mount{
-			{
-				Type:   "bind",
-				Source: f.runCtx,
-				Target: "/app",
-			},
-		},
-	}
-	if runtime.GOOS == "linux" {
-		// setup host.docker.internal to route to host gateway
-		// to access rpc server hosted by local CLI run
-		hostConfig.ExtraHosts = []string{"host.docker.internal:172.17.0.1"}
+	if f.nativeCmd != nil {
+		return f.startNative()
 	}
 
 	launchOpts, err := launchOptsForFunction(f)
@@ -85,30 +184,117 @@ func (f *Function) Start() error {
 		return err
 	}
 
+	env := append([]string{fmt.Sprintf("SERVICE_ADDRESS=host.docker.internal:%d", 50051)}, f.env...)
+
 	cID, err := f.ce.ContainerCreate(&container.Config{
 		Image: f.runtime.DevImageName(), // Select an image to use based on the handler
 		// Set the address to the bound port
-		Env:        []string{fmt.Sprintf("SERVICE_ADDRESS=host.docker.internal:%d", 50051)},
+		Env:        env,
 		Entrypoint: launchOpts.Entrypoint,
 		Cmd:        launchOpts.Cmd,
-	}, hostConfig, nil, f.Name())
+	}, f.hostConfig(), nil, f.Name())
 	if err != nil {
 		return err
 	}
 
 	f.cid = cID
 
-	return f.ce.Start(cID)
+	if err := f.ce.Start(cID); err != nil {
+		return err
+	}
+	if f.metrics != nil {
+		f.metrics.observeRestarts(f.Name(), 0)
+		logs, err := f.ce.Logs(cID, types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true, Follow: true})
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer logs.Close()
+			f.countRestarts(logs)
+		}()
+	}
+	return nil
+}
+
+// countRestarts counts the restarts nodemon reports in the output of the function's container
+func (f *Function) countRestarts(logs io.Reader) {
+	scanner := bufio.NewScanner(logs)
+	for scanner.Scan() {
+		if strings.Contains(scanner.Text(), "[nodemon] restarting") {
+			f.metrics.observeRestarts(f.Name(), 1)
+		}
+	}
+}
+
+// hostConfig returns the host config of the function's container, with the project mounted to /app
+func (f *Function) hostConfig() *container.HostConfig {
+	mounts := []mount.Mount{
+		{
+			Type:   "bind",
+			Source: f.runCtx,
+			Target: "/app",
+		},
+	}
+	for _, fm := range f.files {
+		mounts = append(mounts, mount.Mount{
+			Type:     "bind",
+			Source:   fm.Source,
+			Target:   fm.Target,
+			ReadOnly: true,
+		})
+	}
+
+	hostConfig := &container.HostConfig{
+		AutoRemove: true,
+		Mounts:     mounts,
+		Resources:  f.resources(),
+	}
+	if runtime.GOOS == "linux" {
+		// setup host.docker.internal to route to host gateway
+		// to access rpc server hosted by local CLI run
+		hostConfig.ExtraHosts = []string{"host.docker.internal:172.17.0.1"}
+	}
+	return hostConfig
+}
+
+// startNative runs the handler with the host's toolchain
+func (f *Function) startNative() error {
+	if f.memory > 0 || f.cpu > 0 {
+		fmt.Printf("function %s: its memory and cpu limits are not enforced when running natively\n", f.Name())
+	}
+	if len(f.files) > 0 {
+		fmt.Printf("function %s: its files are not mounted when running natively, they are read from their source paths\n", f.Name())
+	}
+	f.process = exec.Command(f.nativeCmd[0], f.nativeCmd[1:]...)
+	f.process.Dir = f.runCtx
+	f.process.Env = append(os.Environ(), fmt.Sprintf("SERVICE_ADDRESS=localhost:%d", 50051))
+	f.process.Env = append(f.process.Env, f.env...)
+	f.process.Stdout = os.Stdout
+	f.process.Stderr = os.Stderr
+	setProcessGroup(f.process)
+	return f.process.Start()
 }
 
 func (f *Function) Stop() error {
+	if f.process != nil {
+		if err := killProcessGroup(f.process); err != nil {
+			return err
+		}
+		// the exit status of a killed process isn't an error
+		_ = f.process.Wait()
+		return nil
+	}
 	return f.ce.Stop(f.cid, nil)
 }
 
 type FunctionOpts struct {
+	// The name of the function, defaults to the handler's file name
+	Name            string
 	Handler         string
 	RunCtx          string
 	ContainerEngine containerengine.ContainerEngine
+	// Run the handler with the host's toolchain
+	NativeCmd []string
 }
 
 func newFunction(opts FunctionOpts) (*Function, error) {
@@ -118,28 +304,53 @@ func newFunction(opts FunctionOpts) (*Function, error) {
 	}
 
 	return &Function{
-		runtime: runtime,
-		handler: opts.Handler,
-		runCtx:  opts.RunCtx,
-		ce:      opts.ContainerEngine,
+		name:      opts.Name,
+		runtime:   runtime,
+		handler:   opts.Handler,
+		runCtx:    opts.RunCtx,
+		ce:        opts.ContainerEngine,
+		nativeCmd: opts.NativeCmd,
 	}, nil
 }
 
-func FunctionsFromHandlers(runCtx string, handlers []string) ([]*Function, error) {
+// FunctionsFromHandlers creates a function for each handler, when native is set handlers whose
+// toolchain is installed on the host run directly on it and the rest fall back to containers
+func FunctionsFromHandlers(runCtx string, handlers []string, native bool) ([]*Function, error) {
 	funcs := make([]*Function, 0, len(handlers))
-	ce, err := containerengine.Discover()
+	var ce containerengine.ContainerEngine
+
+	relativeHandlers := []string{}
+	for _, h := range handlers {
+		relativeHandlerPath, _ := filepath.Rel(runCtx, h)
+		relativeHandlers = append(relativeHandlers, relativeHandlerPath)
+	}
+	names, err := utils.HandlerNames(relativeHandlers)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, h := range handlers {
-		relativeHandlerPath, _ := filepath.Rel(runCtx, h)
+	for _, relativeHandlerPath := range relativeHandlers {
+		opts := FunctionOpts{
+			Name:    names[relativeHandlerPath],
+			RunCtx:  runCtx,
+			Handler: relativeHandlerPath,
+		}
+		if native {
+			opts.NativeCmd, _ = utils.NativeCommand(runCtx, relativeHandlerPath)
+		}
+		if opts.NativeCmd == nil {
+			// the container engine is only needed when a handler runs in a container
+			if ce == nil {
+				var err error
+				ce, err = containerengine.Discover()
+				if err != nil {
+					return nil, err
+				}
+			}
+			opts.ContainerEngine = ce
+		}
 
-		if f, err := newFunction(FunctionOpts{
-			RunCtx:          runCtx,
-			Handler:         relativeHandlerPath,
-			ContainerEngine: ce,
-		}); err != nil {
+		if f, err := newFunction(opts); err != nil {
 			return nil, err
 		} else {
 			funcs = append(funcs, f)
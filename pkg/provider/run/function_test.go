@@ -0,0 +1,98 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nitrictech/newcli/pkg/stack"
+)
+
+func TestFunctionHostConfigMounts(t *testing.T) {
+	f := &Function{handler: "functions/list.ts", runCtx: "/project"}
+	f.SetFiles([]stack.FileMount{{Source: "/project/certs/ca.pem", Target: "/etc/ssl/ca.pem"}})
+
+	want := []mount.Mount{
+		{Type: "bind", Source: "/project", Target: "/app"},
+		{Type: "bind", Source: "/project/certs/ca.pem", Target: "/etc/ssl/ca.pem", ReadOnly: true},
+	}
+	if got := f.hostConfig().Mounts; !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestFunctionCountRestarts(t *testing.T) {
+	m := NewMetrics()
+	f := &Function{name: "orders", handler: "functions/orders.ts", metrics: m}
+	f.countRestarts(strings.NewReader(`[nodemon] starting ts-node -T /app/functions/orders.ts
+listening
+[nodemon] restarting due to changes...
+[nodemon] starting ts-node -T /app/functions/orders.ts
+[nodemon] restarting due to changes...
+`))
+
+	if got := m.restarts["orders"]; got != 2 {
+		t.Errorf("restarts = %d, want 2", got)
+	}
+}
+
+func TestLaunchOptsForFunction(t *testing.T) {
+	ignore := []string{"--ignore", "/app/node_modules/", "--ignore", "/app/.nitric/", "--ignore", "/app/bin/", "--ignore", "/app/obj/"}
+	tests := []struct {
+		name    string
+		handler string
+		watch   *stack.Watch
+		wantCmd []string
+	}{
+		{
+			name:    "typescript",
+			handler: "functions/list.ts",
+			wantCmd: append(append([]string{"--watch", "/app"}, ignore...), "--ext", "ts,js,json", "--exec", "ts-node -T /app/functions/list.ts"),
+		},
+		{
+			name:    "go",
+			handler: "functions/list/main.go",
+			wantCmd: append(append([]string{"--watch", "/app"}, ignore...), "--ext", "go,mod,sum", "--exec", "go run /app/functions/list/main.go"),
+		},
+		{
+			name:    "dotnet with watch settings",
+			handler: "functions/List/List.csproj",
+			watch:   &stack.Watch{Include: []string{"functions/List"}, Exclude: []string{"functions/List/tests"}},
+			wantCmd: append(append([]string{"--watch", "/app/functions/List"}, ignore...),
+				"--ignore", "/app/functions/List/tests", "--ext", "cs,csproj,json", "--exec", "dotnet run --project /app/functions/List/List.csproj"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Function{handler: tt.handler, watch: tt.watch}
+			got, err := launchOptsForFunction(f)
+			if err != nil {
+				t.Fatalf("launchOptsForFunction() error = %v", err)
+			}
+			if !cmp.Equal([]string{"nodemon"}, got.Entrypoint) {
+				t.Errorf("launchOptsForFunction() entrypoint = %v, want nodemon", got.Entrypoint)
+			}
+			if !cmp.Equal(tt.wantCmd, got.Cmd) {
+				t.Error(cmp.Diff(tt.wantCmd, got.Cmd))
+			}
+		})
+	}
+}
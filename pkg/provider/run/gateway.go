@@ -17,13 +17,19 @@
 package run
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"strings"
 	"time"
 
 	"github.com/fasthttp/router"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/spf13/viper"
 	"github.com/valyala/fasthttp"
 
+	"github.com/nitrictech/newcli/pkg/stack"
 	"github.com/nitrictech/nitric/pkg/plugins/gateway"
 	"github.com/nitrictech/nitric/pkg/triggers"
 	nitric_utils "github.com/nitrictech/nitric/pkg/utils"
@@ -38,6 +44,25 @@ type BaseHttpGateway struct {
 	gateway.UnimplementedGatewayPlugin
 
 	pool worker.WorkerPool
+
+	// payload schemas for topics that declare them
+	topicSchemas map[string]*openapi3.Schema
+
+	// CORS, authentication and rate limiting of apis that declare them
+	policies map[string]*apiPolicy
+
+	// request timeouts of apis whose functions declare one, these are only
+	// enforced with enforce_limits, otherwise slower requests are warned about
+	timeouts        map[string]time.Duration
+	enforceTimeouts bool
+
+	// the functions that handle the routes of each api, request metrics are labelled with them
+	routes map[string][]stack.ApiRoute
+
+	metrics *Metrics
+
+	// recent requests and messages, shown by the dashboard
+	activity *Activity
 }
 
 func apiWorkerFilter(apiName string) func(w worker.Worker) bool {
@@ -52,6 +77,23 @@ func apiWorkerFilter(apiName string) func(w worker.Worker) bool {
 
 func (s *BaseHttpGateway) api(ctx *fasthttp.RequestCtx) {
 	apiName := ctx.UserValue("name").(string)
+	start := time.Now()
+	detail := string(ctx.Method()) + " /" + ctx.UserValue("any").(string)
+	function := s.routeFunction(apiName, string(ctx.Method()), "/"+ctx.UserValue("any").(string))
+	defer func() {
+		s.metrics.observeRequest(apiName, function, ctx.Response.StatusCode(), time.Since(start))
+		s.activity.record(ActivityEvent{
+			Time:       start,
+			Kind:       ActivityRequest,
+			Name:       apiName,
+			Detail:     detail,
+			Status:     ctx.Response.StatusCode(),
+			DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		})
+	}()
+	if p, ok := s.policies[apiName]; ok && !p.handle(ctx) {
+		return
+	}
 	// Rewrite the URL of the request to remove the /api/{name} subroute
 	pathParts := nitric_utils.SplitPath(string(ctx.Path()))
 	// remove first two path parts
@@ -74,7 +116,13 @@ func (s *BaseHttpGateway) api(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
-	resp, err := worker.HandleHttpRequest(httpReq)
+	resp, err := s.handleWithTimeout(apiName, func() (*triggers.HttpResponse, error) {
+		return worker.HandleHttpRequest(httpReq)
+	})
+	if errors.Is(err, errTimeout) {
+		ctx.Error(fmt.Sprintf("request exceeded the %s timeout of api %s", s.timeouts[apiName], apiName), 504)
+		return
+	}
 
 	if err != nil {
 		ctx.Error(fmt.Sprintf("Error handling HTTP Request: %v", err), 500)
@@ -94,6 +142,11 @@ func (s *BaseHttpGateway) api(ctx *fasthttp.RequestCtx) {
 func (s *BaseHttpGateway) topic(ctx *fasthttp.RequestCtx) {
 	topicName := ctx.UserValue("name").(string)
 
+	if err := validateTopicPayload(s.topicSchemas, topicName, ctx.Request.Body()); err != nil {
+		ctx.Error(err.Error(), 400)
+		return
+	}
+
 	evt := &triggers.Event{
 		ID:      "test",
 		Topic:   topicName,
@@ -115,10 +168,67 @@ func (s *BaseHttpGateway) topic(ctx *fasthttp.RequestCtx) {
 			errList = append(errList, err)
 		}
 	}
+	s.metrics.observeDeliveries(topicName, len(ws)-len(errList), len(errList))
+	s.activity.record(ActivityEvent{
+		Time:   time.Now(),
+		Kind:   ActivityMessage,
+		Name:   topicName,
+		Detail: string(ctx.Request.Body()),
+		Status: len(errList),
+	})
 
 	ctx.Success("text/plain", []byte(fmt.Sprintf("%d successful & %d failed deliveries", len(ws)-len(errList), len(errList))))
 }
 
+// routeFunction returns the function that handles a request to an api, or an empty string when the route isn't known
+func (s *BaseHttpGateway) routeFunction(apiName, method, path string) string {
+	for _, r := range s.routes[apiName] {
+		if r.Method == method && matchRoute(r.Path, path) {
+			return r.Function
+		}
+	}
+	return ""
+}
+
+// matchRoute is true when the path matches the route's path, where path parameters (e.g. {id}) match any segment
+func matchRoute(route, path string) bool {
+	routeParts := nitric_utils.SplitPath(route)
+	pathParts := nitric_utils.SplitPath(path)
+	if len(routeParts) != len(pathParts) {
+		return false
+	}
+	for i, p := range routeParts {
+		if strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}") {
+			continue
+		}
+		if p != pathParts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// validateTopicPayload checks a JSON payload against the schema of the topic, topics without a schema accept any payload
+func validateTopicPayload(schemas map[string]*openapi3.Schema, topicName string, body []byte) error {
+	schema, ok := schemas[topicName]
+	if !ok {
+		return nil
+	}
+	var payload interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return fmt.Errorf("payload for topic %s is not valid JSON: %v", topicName, err)
+	}
+	if err := schema.VisitJSON(payload); err != nil {
+		return fmt.Errorf("payload does not match the schema for topic %s: %v", topicName, err)
+	}
+	return nil
+}
+
+func (s *BaseHttpGateway) prometheusMetrics(ctx *fasthttp.RequestCtx) {
+	ctx.SetContentType("text/plain; version=0.0.4")
+	s.metrics.write(ctx)
+}
+
 func (s *BaseHttpGateway) Start(pool worker.WorkerPool) error {
 	s.pool = pool
 
@@ -128,6 +238,8 @@ func (s *BaseHttpGateway) Start(pool worker.WorkerPool) error {
 	r.ANY("/apis/{name}/{any:*}", s.api)
 	// trigger a topic
 	r.POST("/topic/{name}", s.topic)
+	// prometheus metrics for the local run
+	r.GET("/metrics", s.prometheusMetrics)
 
 	s.server = &fasthttp.Server{
 		ReadTimeout:     time.Second * 1,
@@ -146,12 +258,106 @@ func (s *BaseHttpGateway) Stop() error {
 	return nil
 }
 
+// errTimeout is returned when a request takes longer than its api's enforced timeout
+var errTimeout = errors.New("request timed out")
+
+// handleWithTimeout calls handle, when it takes longer than the api's timeout a warning is printed
+// or when timeouts are enforced errTimeout is returned without waiting for it
+func (s *BaseHttpGateway) handleWithTimeout(apiName string, handle func() (*triggers.HttpResponse, error)) (*triggers.HttpResponse, error) {
+	timeout, ok := s.timeouts[apiName]
+	if !ok {
+		return handle()
+	}
+
+	type result struct {
+		resp *triggers.HttpResponse
+		err  error
+	}
+	done := make(chan result, 1)
+	start := time.Now()
+	go func() {
+		resp, err := handle()
+		done <- result{resp, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.resp, r.err
+	case <-time.After(timeout):
+	}
+
+	if s.enforceTimeouts {
+		fmt.Printf("api %s: request exceeded the %s timeout of its function, responding with 504\n", apiName, timeout)
+		return nil, errTimeout
+	}
+	r := <-done
+	fmt.Printf("api %s: request took %s, longer than the %s timeout of its function, it would fail when deployed\n",
+		apiName, time.Since(start).Round(time.Millisecond), timeout)
+	return r.resp, r.err
+}
+
+type GatewayOptions struct {
+	// Payload schemas of topics
+	TopicSchemas map[string]*openapi3.Schema
+	// CORS, authentication and rate limiting of apis
+	Policies map[string]stack.ApiPolicy
+	// Request timeouts of apis
+	Timeouts map[string]time.Duration
+	// The directory of the stack, policy files are relative to it
+	StackDir string
+	// Records the recent requests and messages when set
+	Activity *Activity
+	// The functions that handle the routes of each api
+	Routes map[string][]stack.ApiRoute
+	// Collects the stats served on /metrics, a new collector is used when nil
+	Metrics *Metrics
+}
+
+// gatewayAddress is the address the gateway listens on, set with GATEWAY_ADDRESS
+func gatewayAddress() string {
+	return nitric_utils.GetEnv("GATEWAY_ADDRESS", ":9001")
+}
+
+// GatewayURL returns the base URL the gateway can be reached at from the host,
+// addresses that listen on every interface (e.g. :9001) are reached at localhost
+func GatewayURL() string {
+	host, port, err := net.SplitHostPort(gatewayAddress())
+	if err != nil {
+		return "http://" + gatewayAddress()
+	}
+	if host == "" || net.ParseIP(host).IsUnspecified() {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
+
 // Create new HTTP gateway
-// XXX: No External Args for function atm (currently the plugin loader does not pass any argument information)
-func NewGateway() (gateway.GatewayService, error) {
-	address := nitric_utils.GetEnv("GATEWAY_ADDRESS", ":9001")
+// topic schemas are used to validate the payloads published to topics, and may be nil
+// policies are applied to the requests of their apis, JWKS paths are relative to the stack dir
+func NewGateway(opts GatewayOptions) (gateway.GatewayService, error) {
+	address := gatewayAddress()
+
+	apiPolicies := map[string]*apiPolicy{}
+	for name, p := range opts.Policies {
+		ap, err := newApiPolicy(p, opts.StackDir)
+		if err != nil {
+			return nil, fmt.Errorf("api %s: %v", name, err)
+		}
+		apiPolicies[name] = ap
+	}
+	metrics := opts.Metrics
+	if metrics == nil {
+		metrics = NewMetrics()
+	}
 
 	return &BaseHttpGateway{
-		address: address,
+		address:         address,
+		topicSchemas:    opts.TopicSchemas,
+		policies:        apiPolicies,
+		timeouts:        opts.Timeouts,
+		enforceTimeouts: viper.GetBool("enforce_limits"),
+		routes:          opts.Routes,
+		metrics:         metrics,
+		activity:        opts.Activity,
 	}, nil
 }
@@ -0,0 +1,79 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"os"
+	"testing"
+
+	"github.com/nitrictech/newcli/pkg/stack"
+)
+
+func TestRouteFunction(t *testing.T) {
+	gw := &BaseHttpGateway{routes: map[string][]stack.ApiRoute{
+		"shop": {
+			{Method: "GET", Path: "/orders", Function: "list"},
+			{Method: "GET", Path: "/orders/{id}", Function: "read"},
+			{Method: "POST", Path: "/orders", Function: "create"},
+		},
+	}}
+
+	tests := []struct {
+		name   string
+		api    string
+		method string
+		path   string
+		want   string
+	}{
+		{name: "static path", api: "shop", method: "GET", path: "/orders", want: "list"},
+		{name: "method", api: "shop", method: "POST", path: "/orders/", want: "create"},
+		{name: "path parameter", api: "shop", method: "GET", path: "/orders/o-1", want: "read"},
+		{name: "unknown route", api: "shop", method: "GET", path: "/orders/o-1/items"},
+		{name: "unknown api", api: "admin", method: "GET", path: "/orders"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gw.routeFunction(tt.api, tt.method, tt.path); got != tt.want {
+				t.Errorf("routeFunction() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGatewayURL(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{address: "", want: "http://localhost:9001"},
+		{address: ":8080", want: "http://localhost:8080"},
+		{address: "0.0.0.0:8080", want: "http://localhost:8080"},
+		{address: "127.0.0.1:9002", want: "http://127.0.0.1:9002"},
+		{address: "[::]:9003", want: "http://localhost:9003"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			if tt.address != "" {
+				os.Setenv("GATEWAY_ADDRESS", tt.address)
+				defer os.Unsetenv("GATEWAY_ADDRESS")
+			}
+			if got := GatewayURL(); got != tt.want {
+				t.Errorf("GatewayURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nitrictech/newcli/pkg/stack"
+)
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// loadJWKS reads the RSA keys of the JWKS at location, a URL or a path relative to dir, keyed by their kid
+func loadJWKS(location, dir string) (map[string]*rsa.PublicKey, error) {
+	var b []byte
+	var err error
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		client := http.Client{Timeout: 10 * time.Second}
+		resp, err := client.Get(location)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return nil, fmt.Errorf("fetching the JWKS from %s failed with status %s", location, resp.Status)
+		}
+		b, err = ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		b, err = ioutil.ReadFile(filepath.Join(dir, location))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return parseJWKS(b)
+}
+
+func parseJWKS(b []byte) (map[string]*rsa.PublicKey, error) {
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{}
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: invalid modulus: %v", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: invalid exponent: %v", k.Kid, err)
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("the JWKS has no RSA keys")
+	}
+	return keys, nil
+}
+
+// verifyJWT checks the RS256 signature and the exp, nbf, iss and aud claims of the token
+func verifyJWT(token string, keys map[string]*rsa.PublicKey, cfg *stack.Jwt, now time.Time) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("malformed token")
+	}
+
+	header := struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}{}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return err
+	}
+	if header.Alg != "RS256" {
+		return fmt.Errorf("unsupported algorithm %s", header.Alg)
+	}
+	key, ok := keys[header.Kid]
+	if !ok {
+		return fmt.Errorf("unknown key %s", header.Kid)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return fmt.Errorf("malformed signature")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+		return fmt.Errorf("invalid signature")
+	}
+
+	claims := struct {
+		Iss string      `json:"iss"`
+		Aud interface{} `json:"aud"`
+		Exp *int64      `json:"exp"`
+		Nbf *int64      `json:"nbf"`
+	}{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return err
+	}
+	if claims.Exp != nil && now.Unix() >= *claims.Exp {
+		return fmt.Errorf("token expired")
+	}
+	if claims.Nbf != nil && now.Unix() < *claims.Nbf {
+		return fmt.Errorf("token not valid yet")
+	}
+	if cfg.Issuer != "" && claims.Iss != cfg.Issuer {
+		return fmt.Errorf("unexpected issuer %s", claims.Iss)
+	}
+	if len(cfg.Audiences) > 0 && !audienceMatches(claims.Aud, cfg.Audiences) {
+		return fmt.Errorf("unexpected audience")
+	}
+	return nil
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return fmt.Errorf("malformed token")
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("malformed token")
+	}
+	return nil
+}
+
+// audienceMatches is true when the aud claim, a string or a list of strings, contains one of the audiences
+func audienceMatches(aud interface{}, audiences []string) bool {
+	claimed := []string{}
+	switch a := aud.(type) {
+	case string:
+		claimed = append(claimed, a)
+	case []interface{}:
+		for _, v := range a {
+			if s, ok := v.(string); ok {
+				claimed = append(claimed, s)
+			}
+		}
+	}
+	for _, c := range claimed {
+		for _, a := range audiences {
+			if c == a {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nitrictech/newcli/pkg/stack"
+)
+
+func signJWT(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestVerifyJWT(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := fmt.Sprintf(`{"keys":[{"kid":"k1","kty":"RSA","n":"%s","e":"%s"}]}`,
+		base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()))
+	keys, err := parseJWKS([]byte(jwks))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1000, 0)
+	cfg := &stack.Jwt{Issuer: "https://issuer", Audiences: []string{"api"}}
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{
+			name:  "valid",
+			token: signJWT(t, key, "k1", map[string]interface{}{"iss": "https://issuer", "aud": []string{"other", "api"}, "exp": 2000}),
+		},
+		{
+			name:    "expired",
+			token:   signJWT(t, key, "k1", map[string]interface{}{"iss": "https://issuer", "aud": "api", "exp": 900}),
+			wantErr: true,
+		},
+		{
+			name:    "wrong issuer",
+			token:   signJWT(t, key, "k1", map[string]interface{}{"iss": "https://other", "aud": "api"}),
+			wantErr: true,
+		},
+		{
+			name:    "wrong audience",
+			token:   signJWT(t, key, "k1", map[string]interface{}{"iss": "https://issuer", "aud": "other"}),
+			wantErr: true,
+		},
+		{
+			name:    "signed by another key",
+			token:   signJWT(t, other, "k1", map[string]interface{}{"iss": "https://issuer", "aud": "api"}),
+			wantErr: true,
+		},
+		{
+			name:    "unknown key",
+			token:   signJWT(t, key, "k2", map[string]interface{}{"iss": "https://issuer", "aud": "api"}),
+			wantErr: true,
+		},
+		{
+			name:    "malformed",
+			token:   "not.a-token",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := verifyJWT(tt.token, keys, cfg, now); (err != nil) != tt.wantErr {
+				t.Errorf("verifyJWT() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{{
+		"kid": "k1",
+		"kty": "RSA",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/jwks.json" {
+			http.Error(w, "<html>not found</html>", http.StatusNotFound)
+			return
+		}
+		w.Write(jwks)
+	}))
+	defer srv.Close()
+
+	keys, err := loadJWKS(srv.URL+"/jwks.json", "")
+	if err != nil {
+		t.Fatalf("loadJWKS() error = %v", err)
+	}
+	if _, ok := keys["k1"]; !ok {
+		t.Errorf("loadJWKS() = %v, want key k1", keys)
+	}
+
+	_, err = loadJWKS(srv.URL+"/missing.json", "")
+	if err == nil || !strings.Contains(err.Error(), srv.URL+"/missing.json") || !strings.Contains(err.Error(), "404") {
+		t.Errorf("loadJWKS() error = %v, want the URL and status", err)
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	b := newTokenBucket(1, 2)
+	now := time.Unix(0, 0)
+	got := []bool{b.allow(now), b.allow(now), b.allow(now), b.allow(now.Add(time.Second))}
+	want := []bool{true, true, false, true}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("allow() = %v, want %v", got, want)
+			break
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// latency buckets in seconds, matching the prometheus client defaults
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func (h *histogram) observe(v float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(latencyBuckets))
+	}
+	for i, b := range latencyBuckets {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+type requestKey struct {
+	api      string
+	function string
+	status   int
+}
+
+type latencyKey struct {
+	api      string
+	function string
+}
+
+type deliveryKey struct {
+	topic  string
+	result string
+}
+
+// Metrics collects the request, delivery and restart stats of the local run,
+// written in the prometheus text format
+type Metrics struct {
+	lock       sync.Mutex
+	requests   map[requestKey]uint64
+	latencies  map[latencyKey]*histogram
+	deliveries map[deliveryKey]uint64
+	restarts   map[string]uint64
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests:   map[requestKey]uint64{},
+		latencies:  map[latencyKey]*histogram{},
+		deliveries: map[deliveryKey]uint64{},
+		restarts:   map[string]uint64{},
+	}
+}
+
+// observeRequest records a request to an api, function is empty when the route isn't in the stack's api documents
+func (m *Metrics) observeRequest(api, function string, status int, d time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.requests[requestKey{api: api, function: function, status: status}]++
+	k := latencyKey{api: api, function: function}
+	h, ok := m.latencies[k]
+	if !ok {
+		h = &histogram{}
+		m.latencies[k] = h
+	}
+	h.observe(d.Seconds())
+}
+
+func (m *Metrics) observeDeliveries(topic string, succeeded, failed int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.deliveries[deliveryKey{topic: topic, result: "success"}] += uint64(succeeded)
+	m.deliveries[deliveryKey{topic: topic, result: "failure"}] += uint64(failed)
+}
+
+// observeRestarts adds to the restarts of a function, adding none starts its series at 0
+func (m *Metrics) observeRestarts(function string, n int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.restarts[function] += uint64(n)
+}
+
+func (m *Metrics) write(w io.Writer) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	fmt.Fprintln(w, "# HELP nitric_api_requests_total Requests handled by each api and function.")
+	fmt.Fprintln(w, "# TYPE nitric_api_requests_total counter")
+	reqKeys := make([]requestKey, 0, len(m.requests))
+	for k := range m.requests {
+		reqKeys = append(reqKeys, k)
+	}
+	sort.Slice(reqKeys, func(i, j int) bool {
+		if reqKeys[i].api != reqKeys[j].api {
+			return reqKeys[i].api < reqKeys[j].api
+		}
+		if reqKeys[i].function != reqKeys[j].function {
+			return reqKeys[i].function < reqKeys[j].function
+		}
+		return reqKeys[i].status < reqKeys[j].status
+	})
+	for _, k := range reqKeys {
+		fmt.Fprintf(w, "nitric_api_requests_total{api=%q,function=%q,status=\"%d\"} %d\n", k.api, k.function, k.status, m.requests[k])
+	}
+
+	fmt.Fprintln(w, "# HELP nitric_api_request_duration_seconds Latency of requests handled by each api and function.")
+	fmt.Fprintln(w, "# TYPE nitric_api_request_duration_seconds histogram")
+	latKeys := make([]latencyKey, 0, len(m.latencies))
+	for k := range m.latencies {
+		latKeys = append(latKeys, k)
+	}
+	sort.Slice(latKeys, func(i, j int) bool {
+		if latKeys[i].api != latKeys[j].api {
+			return latKeys[i].api < latKeys[j].api
+		}
+		return latKeys[i].function < latKeys[j].function
+	})
+	for _, k := range latKeys {
+		h := m.latencies[k]
+		for i, b := range latencyBuckets {
+			fmt.Fprintf(w, "nitric_api_request_duration_seconds_bucket{api=%q,function=%q,le=\"%g\"} %d\n", k.api, k.function, b, h.counts[i])
+		}
+		fmt.Fprintf(w, "nitric_api_request_duration_seconds_bucket{api=%q,function=%q,le=\"+Inf\"} %d\n", k.api, k.function, h.count)
+		fmt.Fprintf(w, "nitric_api_request_duration_seconds_sum{api=%q,function=%q} %g\n", k.api, k.function, h.sum)
+		fmt.Fprintf(w, "nitric_api_request_duration_seconds_count{api=%q,function=%q} %d\n", k.api, k.function, h.count)
+	}
+
+	fmt.Fprintln(w, "# HELP nitric_topic_deliveries_total Events delivered to topic subscribers.")
+	fmt.Fprintln(w, "# TYPE nitric_topic_deliveries_total counter")
+	delKeys := make([]deliveryKey, 0, len(m.deliveries))
+	for k := range m.deliveries {
+		delKeys = append(delKeys, k)
+	}
+	sort.Slice(delKeys, func(i, j int) bool {
+		if delKeys[i].topic != delKeys[j].topic {
+			return delKeys[i].topic < delKeys[j].topic
+		}
+		return delKeys[i].result < delKeys[j].result
+	})
+	for _, k := range delKeys {
+		fmt.Fprintf(w, "nitric_topic_deliveries_total{topic=%q,result=%q} %d\n", k.topic, k.result, m.deliveries[k])
+	}
+
+	fmt.Fprintln(w, "# HELP nitric_function_restarts_total Restarts of each function after its files changed.")
+	fmt.Fprintln(w, "# TYPE nitric_function_restarts_total counter")
+	functions := make([]string, 0, len(m.restarts))
+	for k := range m.restarts {
+		functions = append(functions, k)
+	}
+	sort.Strings(functions)
+	for _, f := range functions {
+		fmt.Fprintf(w, "nitric_function_restarts_total{function=%q} %d\n", f, m.restarts[f])
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/nitrictech/newcli/pkg/stack"
+)
+
+var defaultCorsMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+
+// apiPolicy applies an api's CORS, authentication and rate limiting to its requests,
+// the same way they are applied by the cloud gateways
+type apiPolicy struct {
+	policy  stack.ApiPolicy
+	keys    map[string]*rsa.PublicKey
+	limiter *tokenBucket
+}
+
+func newApiPolicy(policy stack.ApiPolicy, stackDir string) (*apiPolicy, error) {
+	p := &apiPolicy{policy: policy}
+	if policy.Jwt != nil {
+		keys, err := loadJWKS(policy.Jwt.Jwks, stackDir)
+		if err != nil {
+			return nil, fmt.Errorf("jwks %s: %v", policy.Jwt.Jwks, err)
+		}
+		p.keys = keys
+	}
+	if policy.RateLimit != nil {
+		p.limiter = newTokenBucket(policy.RateLimit.RequestsPerSecond, policy.RateLimit.Burst)
+	}
+	return p, nil
+}
+
+// handle returns false when the request has been answered by the policy, e.g. rejected or a CORS preflight
+func (p *apiPolicy) handle(ctx *fasthttp.RequestCtx) bool {
+	if p.policy.Cors != nil && !p.cors(ctx) {
+		return false
+	}
+	if p.limiter != nil && !p.limiter.allow(time.Now()) {
+		ctx.Error("rate limit exceeded", fasthttp.StatusTooManyRequests)
+		return false
+	}
+	if p.policy.Jwt != nil {
+		auth := string(ctx.Request.Header.Peek("Authorization"))
+		if !strings.HasPrefix(auth, "Bearer ") {
+			ctx.Error("missing bearer token", fasthttp.StatusUnauthorized)
+			return false
+		}
+		if err := verifyJWT(strings.TrimPrefix(auth, "Bearer "), p.keys, p.policy.Jwt, time.Now()); err != nil {
+			ctx.Error(err.Error(), fasthttp.StatusUnauthorized)
+			return false
+		}
+	}
+	return true
+}
+
+func (p *apiPolicy) cors(ctx *fasthttp.RequestCtx) bool {
+	cors := p.policy.Cors
+	origin := string(ctx.Request.Header.Peek("Origin"))
+	if origin == "" {
+		// not a cross origin request
+		return true
+	}
+
+	wildcard := false
+	allowed := false
+	for _, o := range cors.AllowOrigins {
+		wildcard = wildcard || o == "*"
+		allowed = allowed || o == "*" || o == origin
+	}
+	if !allowed {
+		ctx.Error(fmt.Sprintf("origin %s is not allowed", origin), fasthttp.StatusForbidden)
+		return false
+	}
+
+	if wildcard && !cors.AllowCredentials {
+		ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		ctx.Response.Header.Set("Access-Control-Allow-Origin", origin)
+		ctx.Response.Header.Add("Vary", "Origin")
+	}
+	if cors.AllowCredentials {
+		ctx.Response.Header.Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	if !ctx.IsOptions() || len(ctx.Request.Header.Peek("Access-Control-Request-Method")) == 0 {
+		return true
+	}
+
+	// preflight requests are answered by the gateway
+	methods := cors.AllowMethods
+	if len(methods) == 0 {
+		methods = defaultCorsMethods
+	}
+	ctx.Response.Header.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	if len(cors.AllowHeaders) > 0 {
+		ctx.Response.Header.Set("Access-Control-Allow-Headers", strings.Join(cors.AllowHeaders, ", "))
+	} else if requested := ctx.Request.Header.Peek("Access-Control-Request-Headers"); len(requested) > 0 {
+		ctx.Response.Header.SetBytesV("Access-Control-Allow-Headers", requested)
+	}
+	if cors.MaxAge > 0 {
+		ctx.Response.Header.Set("Access-Control-Max-Age", fmt.Sprint(cors.MaxAge))
+	}
+	ctx.SetStatusCode(fasthttp.StatusNoContent)
+	return false
+}
+
+// tokenBucket allows rate requests per second on average, with bursts of up to burst requests
+type tokenBucket struct {
+	lock   sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	b := float64(burst)
+	if burst <= 0 {
+		b = rate
+	}
+	if b < 1 {
+		b = 1
+	}
+	return &tokenBucket{rate: rate, burst: b, tokens: b}
+}
+
+func (b *tokenBucket) allow(now time.Time) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
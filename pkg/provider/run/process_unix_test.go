@@ -0,0 +1,74 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package run
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestStopNativeKillsChildren(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	// like npx and go run, the shell starts the handler as a child process
+	f := &Function{nativeCmd: []string{"sh", "-c", "sleep 60 & echo $! > " + pidFile + "; wait"}, runCtx: t.TempDir()}
+	if err := f.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	pid := 0
+	for i := 0; i < 50 && pid == 0; i++ {
+		time.Sleep(20 * time.Millisecond)
+		b, _ := ioutil.ReadFile(pidFile)
+		pid, _ = strconv.Atoi(strings.TrimSpace(string(b)))
+	}
+	if pid == 0 {
+		t.Fatal("the child process did not start")
+	}
+
+	if err := f.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	for i := 0; i < 50; i++ {
+		if !running(pid) {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Errorf("child process %d is still running after Stop()", pid)
+}
+
+// running returns true if the process exists and isn't a zombie waiting to be reaped
+func running(pid int) bool {
+	if syscall.Kill(pid, 0) != nil {
+		return false
+	}
+	stat, err := ioutil.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		// no procfs to check, e.g. on macOS
+		return true
+	}
+	fields := strings.Fields(string(stat[strings.LastIndex(string(stat), ")")+1:]))
+	return len(fields) == 0 || fields[0] != "Z"
+}
@@ -0,0 +1,32 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package run
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup does nothing, there are no process groups to signal on windows
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills the command, the processes it started may outlive it
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
@@ -0,0 +1,241 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/nitrictech/newcli/pkg/stack"
+	"github.com/nitrictech/nitric/pkg/plugins/events"
+	"github.com/nitrictech/nitric/pkg/plugins/queue"
+	"github.com/nitrictech/nitric/pkg/plugins/secret"
+)
+
+// Stubs stand in for the services that have no local emulator, they log every call
+// and return the canned responses of the stack so that functions don't fail on missing endpoints
+type Stubs struct {
+	out io.Writer
+	mu  sync.Mutex
+
+	secrets map[string][][]byte
+	queues  map[string][]queue.NitricTask
+	topics  []string
+	// payloads published to these topics are validated against their schema
+	topicSchemas map[string]*openapi3.Schema
+	// ids of the tasks that were sent without one
+	nextID int
+}
+
+type stubSecrets struct{ *Stubs }
+type stubQueues struct{ *Stubs }
+type stubEvents struct{ *Stubs }
+
+func NewStubs(s *stack.Stubs, topics []string, topicSchemas map[string]*openapi3.Schema, out io.Writer) *Stubs {
+	st := &Stubs{
+		out:          out,
+		secrets:      map[string][][]byte{},
+		queues:       map[string][]queue.NitricTask{},
+		topics:       topics,
+		topicSchemas: topicSchemas,
+	}
+	sort.Strings(st.topics)
+
+	if s == nil {
+		return st
+	}
+	for name, v := range s.Secrets {
+		st.secrets[name] = [][]byte{[]byte(v)}
+	}
+	for name, payloads := range s.Queues {
+		for _, p := range payloads {
+			st.queues[name] = append(st.queues[name], queue.NitricTask{
+				ID:      st.taskID(),
+				Payload: jsonMap(p),
+			})
+		}
+	}
+	return st
+}
+
+// jsonMap converts the nested maps yaml decodes, keyed by interface{}, to maps keyed by string so the payload can be marshalled to json
+func jsonMap(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = jsonValue(v)
+	}
+	return out
+}
+
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = jsonValue(e)
+		}
+		return m
+	case map[string]interface{}:
+		return jsonMap(v)
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, e := range v {
+			l[i] = jsonValue(e)
+		}
+		return l
+	default:
+		return v
+	}
+}
+
+func (s *Stubs) SecretPlugin() secret.SecretService { return &stubSecrets{s} }
+func (s *Stubs) QueuePlugin() queue.QueueService    { return &stubQueues{s} }
+func (s *Stubs) EventsPlugin() events.EventService  { return &stubEvents{s} }
+
+func (s *Stubs) taskID() string {
+	s.nextID++
+	return fmt.Sprintf("stub-%d", s.nextID)
+}
+
+func (s *Stubs) logf(format string, a ...interface{}) {
+	fmt.Fprintf(s.out, "[stub] "+format+"\n", a...)
+}
+
+func (s *stubSecrets) Put(sec *secret.Secret, value []byte) (*secret.SecretPutResponse, error) {
+	if sec == nil {
+		return nil, fmt.Errorf("provide non-nil secret")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.secrets[sec.Name] = append(s.secrets[sec.Name], value)
+	version := fmt.Sprint(len(s.secrets[sec.Name]))
+	s.logf("secret %s put, version %s", sec.Name, version)
+
+	return &secret.SecretPutResponse{
+		SecretVersion: &secret.SecretVersion{Secret: sec, Version: version},
+	}, nil
+}
+
+func (s *stubSecrets) Access(sv *secret.SecretVersion) (*secret.SecretAccessResponse, error) {
+	if sv == nil || sv.Secret == nil {
+		return nil, fmt.Errorf("provide non-nil secret version")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	versions := s.secrets[sv.Secret.Name]
+	value := []byte{}
+	version := sv.Version
+	switch {
+	case len(versions) == 0:
+		s.logf("secret %s accessed, it has no stubbed value so an empty value is returned", sv.Secret.Name)
+		version = "1"
+	case sv.Version == "" || sv.Version == "latest":
+		value = versions[len(versions)-1]
+		version = fmt.Sprint(len(versions))
+		s.logf("secret %s accessed, version %s", sv.Secret.Name, version)
+	default:
+		var n int
+		if _, err := fmt.Sscan(sv.Version, &n); err != nil || n < 1 || n > len(versions) {
+			return nil, fmt.Errorf("secret %s has no version %s", sv.Secret.Name, sv.Version)
+		}
+		value = versions[n-1]
+		s.logf("secret %s accessed, version %s", sv.Secret.Name, version)
+	}
+
+	return &secret.SecretAccessResponse{
+		SecretVersion: &secret.SecretVersion{Secret: sv.Secret, Version: version},
+		Value:         value,
+	}, nil
+}
+
+func (s *stubQueues) Send(q string, task queue.NitricTask) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if task.ID == "" {
+		task.ID = s.taskID()
+	}
+	s.queues[q] = append(s.queues[q], task)
+	s.logf("task %s sent to queue %s", task.ID, q)
+	return nil
+}
+
+func (s *stubQueues) SendBatch(q string, tasks []queue.NitricTask) (*queue.SendBatchResponse, error) {
+	for _, t := range tasks {
+		if err := s.Send(q, t); err != nil {
+			return nil, err
+		}
+	}
+	return &queue.SendBatchResponse{FailedTasks: []*queue.FailedTask{}}, nil
+}
+
+func (s *stubQueues) Receive(opts queue.ReceiveOptions) ([]queue.NitricTask, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks := s.queues[opts.QueueName]
+	n := int(*opts.Depth)
+	if n > len(tasks) {
+		n = len(tasks)
+	}
+	received := make([]queue.NitricTask, n)
+	for i, t := range tasks[:n] {
+		// the stub has no leases, a task is removed as soon as it's received
+		t.LeaseID = t.ID
+		received[i] = t
+	}
+	s.queues[opts.QueueName] = tasks[n:]
+	s.logf("%d task(s) received from queue %s", n, opts.QueueName)
+
+	return received, nil
+}
+
+func (s *stubQueues) Complete(q string, leaseId string) error {
+	s.logf("task %s completed on queue %s", leaseId, q)
+	return nil
+}
+
+func (s *stubEvents) Publish(topic string, event *events.NitricEvent) error {
+	if event == nil {
+		return fmt.Errorf("provide non-nil event")
+	}
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		return err
+	}
+	if err := validateTopicPayload(s.topicSchemas, topic, payload); err != nil {
+		s.logf("event %s rejected by topic %s, %v", event.ID, topic, err)
+		return err
+	}
+	s.logf("event %s published to topic %s, it is not delivered to subscribers", event.ID, topic)
+	return nil
+}
+
+func (s *stubEvents) ListTopics() ([]string, error) {
+	s.logf("topics listed")
+	return s.topics, nil
+}
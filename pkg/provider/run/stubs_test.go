@@ -0,0 +1,155 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/nitrictech/newcli/pkg/stack"
+	"github.com/nitrictech/nitric/pkg/plugins/events"
+	"github.com/nitrictech/nitric/pkg/plugins/queue"
+	"github.com/nitrictech/nitric/pkg/plugins/secret"
+	"gopkg.in/yaml.v2"
+)
+
+func TestStubSecrets(t *testing.T) {
+	out := &bytes.Buffer{}
+	sp := NewStubs(&stack.Stubs{Secrets: map[string]string{"api-key": "abc"}}, nil, nil, out).SecretPlugin()
+
+	if _, err := sp.Put(&secret.Secret{Name: "api-key"}, []byte("def")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		secret  string
+		version string
+		want    string
+		wantErr bool
+	}{
+		{name: "latest", secret: "api-key", version: "latest", want: "def"},
+		{name: "stubbed version", secret: "api-key", version: "1", want: "abc"},
+		{name: "missing version", secret: "api-key", version: "3", wantErr: true},
+		{name: "not stubbed", secret: "other", version: "latest", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := sp.Access(&secret.SecretVersion{Secret: &secret.Secret{Name: tt.secret}, Version: tt.version})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Access() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && string(resp.Value) != tt.want {
+				t.Errorf("Access() = %s, want %s", resp.Value, tt.want)
+			}
+		})
+	}
+
+	if !strings.Contains(out.String(), "[stub] secret other accessed") {
+		t.Errorf("expected the access of other to be logged, got %s", out.String())
+	}
+}
+
+func TestStubQueues(t *testing.T) {
+	qp := NewStubs(&stack.Stubs{Queues: map[string][]map[string]interface{}{
+		"work": {{"n": 1}},
+	}}, nil, nil, &bytes.Buffer{}).QueuePlugin()
+
+	if err := qp.Send("work", queue.NitricTask{Payload: map[string]interface{}{"n": 2}}); err != nil {
+		t.Fatal(err)
+	}
+
+	depth := uint32(10)
+	tasks, err := qp.Receive(queue.ReceiveOptions{QueueName: "work", Depth: &depth})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 || tasks[0].Payload["n"] != 1 || tasks[1].Payload["n"] != 2 {
+		t.Errorf("Receive() = %v, want the stubbed task then the sent task", tasks)
+	}
+
+	tasks, err = qp.Receive(queue.ReceiveOptions{QueueName: "work"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 0 {
+		t.Errorf("Receive() = %v, want no tasks once they have been received", tasks)
+	}
+}
+
+func TestStubQueuesNestedPayload(t *testing.T) {
+	s := &stack.Stubs{}
+	err := yaml.Unmarshal([]byte(`
+queues:
+  work:
+    - order:
+        id: 1
+        items:
+          - sku: a
+`), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	qp := NewStubs(s, nil, nil, &bytes.Buffer{}).QueuePlugin()
+
+	tasks, err := qp.Receive(queue.ReceiveOptions{QueueName: "work"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 {
+		t.Fatalf("Receive() = %v, want the stubbed task", tasks)
+	}
+	got, err := json.Marshal(tasks[0].Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"order":{"id":1,"items":[{"sku":"a"}]}}`
+	if string(got) != want {
+		t.Errorf("payload = %s, want %s", got, want)
+	}
+}
+
+func TestStubEventsSchema(t *testing.T) {
+	orders := openapi3.NewObjectSchema().WithProperty("id", openapi3.NewStringSchema())
+	orders.Required = []string{"id"}
+	schemas := map[string]*openapi3.Schema{"orders": orders}
+	ep := NewStubs(nil, []string{"orders", "audit"}, schemas, &bytes.Buffer{}).EventsPlugin()
+
+	tests := []struct {
+		name    string
+		topic   string
+		payload map[string]interface{}
+		wantErr bool
+	}{
+		{name: "valid payload", topic: "orders", payload: map[string]interface{}{"id": "o-1"}},
+		{name: "missing required property", topic: "orders", payload: map[string]interface{}{"total": 3}, wantErr: true},
+		{name: "wrong property type", topic: "orders", payload: map[string]interface{}{"id": 1}, wantErr: true},
+		{name: "topic without a schema", topic: "audit", payload: map[string]interface{}{"anything": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ep.Publish(tt.topic, &events.NitricEvent{ID: "e-1", Payload: tt.payload})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Publish() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
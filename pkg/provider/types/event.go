@@ -0,0 +1,94 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+type Phase string
+
+const (
+	PhaseStarted   Phase = "started"
+	PhaseSucceeded Phase = "succeeded"
+	PhaseFailed    Phase = "failed"
+	PhaseWarning   Phase = "warning"
+)
+
+// Op is the change made to a resource
+type Op string
+
+const (
+	OpCreate  Op = "create"
+	OpReplace Op = "replace"
+	OpDelete  Op = "delete"
+)
+
+// Event reports the progress of a deployment, providers emit them instead of writing to stdout
+// so they can be rendered in any output format
+type Event struct {
+	// The resource the event is about, <type>:<name> e.g. function:api
+	Resource string `json:"resource" yaml:"resource"`
+	Op       Op     `json:"op,omitempty" yaml:"op,omitempty"`
+	Phase    Phase  `json:"phase" yaml:"phase"`
+	// Milliseconds since the resource started, set on completion
+	DurationMs int64  `json:"durationMs,omitempty" yaml:"durationMs,omitempty"`
+	Message    string `json:"message,omitempty" yaml:"message,omitempty"`
+	Error      string `json:"error,omitempty" yaml:"error,omitempty"`
+	// Outputs of the resource, e.g. the endpoints of a deployment
+	Outputs map[string]string `json:"outputs,omitempty" yaml:"outputs,omitempty"`
+}
+
+func (e Event) String() string {
+	switch e.Phase {
+	case PhaseSucceeded:
+		msg := fmt.Sprintf("%s %s (%s)", e.Resource, e.Phase, time.Duration(e.DurationMs)*time.Millisecond)
+		keys := make([]string, 0, len(e.Outputs))
+		for k := range e.Outputs {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			msg += fmt.Sprintf("\n  %s: %s", k, e.Outputs[k])
+		}
+		return msg
+	case PhaseFailed:
+		return fmt.Sprintf("%s %s: %s", e.Resource, e.Phase, e.Error)
+	case PhaseWarning:
+		return fmt.Sprintf("%s %s: %s", e.Resource, e.Phase, e.Message)
+	default:
+		return fmt.Sprintf("%s %s", e.Resource, e.Phase)
+	}
+}
+
+// EventHandler receives the events of a deployment
+type EventHandler func(Event)
+
+// Track emits the started event for the resource, runs fn and then emits its outcome
+func Track(events EventHandler, op Op, resource string, fn func() error) error {
+	events(Event{Resource: resource, Op: op, Phase: PhaseStarted})
+	start := time.Now()
+	err := fn()
+	if err != nil {
+		events(Event{Resource: resource, Op: op, Phase: PhaseFailed, Error: err.Error(), DurationMs: time.Since(start).Milliseconds()})
+		return err
+	}
+	events(Event{Resource: resource, Op: op, Phase: PhaseSucceeded, DurationMs: time.Since(start).Milliseconds()})
+	return nil
+}
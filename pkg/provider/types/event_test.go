@@ -0,0 +1,77 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTrack(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		want    []Event
+		wantErr bool
+	}{
+		{
+			name: "succeeded",
+			want: []Event{
+				{Resource: "function:api", Op: OpCreate, Phase: PhaseStarted},
+				{Resource: "function:api", Op: OpCreate, Phase: PhaseSucceeded},
+			},
+		},
+		{
+			name: "failed",
+			err:  errors.New("boom"),
+			want: []Event{
+				{Resource: "function:api", Op: OpCreate, Phase: PhaseStarted},
+				{Resource: "function:api", Op: OpCreate, Phase: PhaseFailed, Error: "boom"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []Event{}
+			err := Track(func(e Event) {
+				e.DurationMs = 0
+				got = append(got, e)
+			}, OpCreate, "function:api", func() error { return tt.err })
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Track() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Track() events = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventString(t *testing.T) {
+	e := Event{
+		Resource:   "deployment:dev",
+		Phase:      PhaseSucceeded,
+		DurationMs: 1500,
+		Outputs:    map[string]string{"api:main": "http://localhost:49152", "entrypoint:web": "http://localhost:49153"},
+	}
+	want := "deployment:dev succeeded (1.5s)\n  api:main: http://localhost:49152\n  entrypoint:web: http://localhost:49153"
+	if got := e.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -16,9 +16,24 @@
 
 package types
 
+type LogOptions struct {
+	// Only show the logs of this function
+	Function string
+	// Keep streaming new logs
+	Follow bool
+	// Only show logs since this time, a timestamp or relative duration e.g. 10m
+	Since string
+}
+
 type Provider interface {
-	Apply(deploymentName string) error
+	// Apply creates or updates a deployment, when targets (e.g. function:api) are given
+	// only those resources are updated
+	Apply(deploymentName string, targets []string) error
 	Delete(deploymentName string) error
+	// DeletePlan returns the resources that Delete would remove, without removing them
+	DeletePlan(deploymentName string) (interface{}, error)
+	// Logs writes the logs of a deployment's functions to stdout
+	Logs(deploymentName string, opts LogOptions) error
 	List() (interface{}, error)
 	//Status()
 }
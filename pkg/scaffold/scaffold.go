@@ -0,0 +1,132 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/nitrictech/newcli/pkg/stack"
+	"github.com/nitrictech/newcli/pkg/utils"
+)
+
+const (
+	ExampleAPI      = "api"
+	ExampleSchedule = "schedule"
+	ExamplePubSub   = "pubsub"
+)
+
+// Runtimes that projects can be scaffolded for, their handlers must run with nitric run
+var Runtimes = []string{utils.RuntimeTypescript.String(), utils.RuntimeJavascript.String()}
+
+// Examples that projects can be scaffolded with
+var Examples = []string{ExampleAPI, ExampleSchedule, ExamplePubSub}
+
+// Options of a new project
+type Options struct {
+	Name    string
+	Runtime utils.Runtime
+	Example string
+}
+
+// Files returns the contents of the project's files keyed by their path relative to the project directory
+func Files(o Options) (map[string]string, error) {
+	rt, ok := runtimeFiles[o.Runtime]
+	if !ok {
+		return nil, fmt.Errorf("runtime %s is not supported, use one of %v", o.Runtime, Runtimes)
+	}
+	handler, ok := rt.handlers[o.Example]
+	if !ok {
+		return nil, fmt.Errorf("example %s is not supported, use one of %v", o.Example, Examples)
+	}
+
+	handlerPath := fmt.Sprintf("functions/%s.%s", o.Example, rt.ext)
+	s := stack.Stack{
+		Name: o.Name,
+		Functions: map[string]stack.Function{
+			o.Example: {Handler: handlerPath},
+		},
+	}
+	b, err := yaml.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]string{
+		"nitric.yaml": string(b),
+		handlerPath:   handler,
+		".gitignore":  rt.gitignore,
+	}
+	for name, content := range rt.manifests {
+		files[name] = content
+	}
+
+	for name, content := range files {
+		t, err := template.New(name).Parse(content)
+		if err != nil {
+			return nil, err
+		}
+		out := &bytes.Buffer{}
+		if err := t.Execute(out, o); err != nil {
+			return nil, err
+		}
+		files[name] = out.String()
+	}
+	return files, nil
+}
+
+// Write creates the project in dir, which must be empty unless force is set
+func Write(dir string, o Options, force bool) error {
+	files, err := Files(o)
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if len(entries) > 0 {
+			return fmt.Errorf("directory %s is not empty, use --force to write into it anyway", dir)
+		}
+	}
+
+	names := []string{}
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(p, []byte(files[name]), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
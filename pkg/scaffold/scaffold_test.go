@@ -0,0 +1,93 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaffold
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nitrictech/newcli/pkg/utils"
+)
+
+func TestFiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      Options
+		wantFiles []string
+		wantErr   bool
+	}{
+		{
+			name:      "typescript api",
+			opts:      Options{Name: "demo", Runtime: utils.RuntimeTypescript, Example: ExampleAPI},
+			wantFiles: []string{".gitignore", "functions/api.ts", "nitric.yaml", "package.json", "tsconfig.json"},
+		},
+		{
+			name:      "javascript pubsub",
+			opts:      Options{Name: "demo", Runtime: utils.RuntimeJavascript, Example: ExamplePubSub},
+			wantFiles: []string{".gitignore", "functions/pubsub.js", "nitric.yaml", "package.json"},
+		},
+		{
+			name:    "unsupported runtime",
+			opts:    Options{Name: "demo", Runtime: utils.RuntimePython, Example: ExampleAPI},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported example",
+			opts:    Options{Name: "demo", Runtime: utils.RuntimeTypescript, Example: "websocket"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := Files(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Files() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			names := []string{}
+			for name := range files {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if !cmp.Equal(tt.wantFiles, names) {
+				t.Error(cmp.Diff(tt.wantFiles, names))
+			}
+			if !strings.Contains(files["nitric.yaml"], "name: demo") {
+				t.Errorf("nitric.yaml does not contain the stack name:\n%s", files["nitric.yaml"])
+			}
+		})
+	}
+}
+
+func TestHandlerRuntimes(t *testing.T) {
+	// the handlers of every template must be recognised as their runtime
+	for _, name := range Runtimes {
+		for _, example := range Examples {
+			h := fmt.Sprintf("functions/%s.%s", example, runtimeFiles[utils.Runtime(name)].ext)
+			rt, err := utils.NewRunTimeFromFilename(h)
+			if err != nil || rt.String() != name {
+				t.Errorf("handler %s has runtime %v (error %v), want %s", h, rt, err, name)
+			}
+		}
+	}
+}
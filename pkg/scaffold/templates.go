@@ -0,0 +1,152 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaffold
+
+import "github.com/nitrictech/newcli/pkg/utils"
+
+// runtimeTemplates are the files written for a runtime, they are go templates executed with the project Options
+type runtimeTemplates struct {
+	// the file extension of handlers
+	ext       string
+	handlers  map[string]string
+	manifests map[string]string
+	gitignore string
+}
+
+const nodeGitignore = `node_modules/
+.nitric/
+`
+
+const typescriptAPI = `import { api } from '@nitric/sdk';
+
+const mainApi = api('main');
+
+mainApi.get('/hello/:name', async (ctx) => {
+  const { name } = ctx.req.params;
+  ctx.res.body = ` + "`Hello ${name}`" + `;
+  return ctx;
+});
+`
+
+const typescriptSchedule = `import { schedule } from '@nitric/sdk';
+
+schedule('process-tasks').every('5 minutes', async (ctx) => {
+  console.log('processing tasks');
+  return ctx;
+});
+`
+
+const typescriptPubSub = `import { topic } from '@nitric/sdk';
+
+const updates = topic('updates');
+
+updates.subscribe(async (ctx) => {
+  console.log('received update', ctx.req.json());
+  return ctx;
+});
+`
+
+const javascriptAPI = `const { api } = require('@nitric/sdk');
+
+const mainApi = api('main');
+
+mainApi.get('/hello/:name', async (ctx) => {
+  const { name } = ctx.req.params;
+  ctx.res.body = ` + "`Hello ${name}`" + `;
+  return ctx;
+});
+`
+
+const javascriptSchedule = `const { schedule } = require('@nitric/sdk');
+
+schedule('process-tasks').every('5 minutes', async (ctx) => {
+  console.log('processing tasks');
+  return ctx;
+});
+`
+
+const javascriptPubSub = `const { topic } = require('@nitric/sdk');
+
+const updates = topic('updates');
+
+updates.subscribe(async (ctx) => {
+  console.log('received update', ctx.req.json());
+  return ctx;
+});
+`
+
+const typescriptPackage = `{
+  "name": "{{.Name}}",
+  "version": "1.0.0",
+  "private": true,
+  "dependencies": {
+    "@nitric/sdk": "^0.4.0"
+  },
+  "devDependencies": {
+    "ts-node": "^10.4.0",
+    "typescript": "^4.5.4"
+  }
+}
+`
+
+const javascriptPackage = `{
+  "name": "{{.Name}}",
+  "version": "1.0.0",
+  "private": true,
+  "dependencies": {
+    "@nitric/sdk": "^0.4.0"
+  }
+}
+`
+
+const typescriptConfig = `{
+  "compilerOptions": {
+    "target": "es2019",
+    "module": "commonjs",
+    "strict": true,
+    "esModuleInterop": true
+  }
+}
+`
+
+var runtimeFiles = map[utils.Runtime]runtimeTemplates{
+	utils.RuntimeTypescript: {
+		ext: "ts",
+		handlers: map[string]string{
+			ExampleAPI:      typescriptAPI,
+			ExampleSchedule: typescriptSchedule,
+			ExamplePubSub:   typescriptPubSub,
+		},
+		manifests: map[string]string{
+			"package.json":  typescriptPackage,
+			"tsconfig.json": typescriptConfig,
+		},
+		gitignore: nodeGitignore,
+	},
+	utils.RuntimeJavascript: {
+		ext: "js",
+		handlers: map[string]string{
+			ExampleAPI:      javascriptAPI,
+			ExampleSchedule: javascriptSchedule,
+			ExamplePubSub:   javascriptPubSub,
+		},
+		manifests: map[string]string{
+			"package.json": javascriptPackage,
+		},
+		gitignore: nodeGitignore,
+	},
+}
@@ -0,0 +1,169 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nitrictech/newcli/pkg/utils"
+)
+
+// Changes are the parts of a stack affected by a set of changed files
+type Changes struct {
+	Stack string `json:"stack" yaml:"stack"`
+	// Config is set when the stack file changed, which affects every resource
+	Config     bool     `json:"config" yaml:"config"`
+	Functions  []string `json:"functions" yaml:"functions"`
+	Containers []string `json:"containers" yaml:"containers"`
+	Apis       []string `json:"apis" yaml:"apis"`
+	// Files are the changed files that affect the stack, relative to it
+	Files []string `json:"files" yaml:"files"`
+}
+
+// Changed returns the parts of the stack affected by the changed files (absolute paths). Containers are
+// affected by the files of their context directory and functions by those of their context directory that
+// aren't excluded from the build or, when a function sets watch paths, only by the files within or matching
+// them, which can include shared modules outside the stack, e.g. ../libs/**
+func (s *Stack) Changed(files []string) (*Changes, error) {
+	c := &Changes{
+		Stack:      s.Name,
+		Functions:  []string{},
+		Containers: []string{},
+		Apis:       []string{},
+		Files:      []string{},
+	}
+	functions := map[string]bool{}
+	containers := map[string]bool{}
+	apis := map[string]bool{}
+
+	for _, f := range files {
+		rel, err := filepath.Rel(s.dir, f)
+		if err != nil {
+			return nil, err
+		}
+		rel = filepath.ToSlash(rel)
+		affects := false
+
+		if rel == s.file {
+			c.Config = true
+			affects = true
+		}
+		for name, api := range s.Apis {
+			if rel == filepath.ToSlash(filepath.Clean(api)) {
+				apis[name] = true
+				affects = true
+			}
+		}
+		for name, fn := range s.Functions {
+			ok, err := fn.changedBy(s, f, rel)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				functions[name] = true
+				affects = true
+			}
+		}
+		for name, con := range s.Containers {
+			if within(con.contextDirectory, f) || rel == filepath.ToSlash(filepath.Clean(con.Dockerfile)) {
+				containers[name] = true
+				affects = true
+			}
+		}
+
+		if affects {
+			c.Files = append(c.Files, rel)
+		}
+	}
+
+	if c.Config {
+		for name := range s.Functions {
+			functions[name] = true
+		}
+		for name := range s.Containers {
+			containers[name] = true
+		}
+		for name := range s.Apis {
+			apis[name] = true
+		}
+	}
+	c.Functions = append(c.Functions, sortedKeys(functions)...)
+	c.Containers = append(c.Containers, sortedKeys(containers)...)
+	c.Apis = append(c.Apis, sortedKeys(apis)...)
+	sort.Strings(c.Files)
+	return c, nil
+}
+
+// changedBy is true when the file, given as an absolute path and relative to the stack, affects the function
+func (f *Function) changedBy(s *Stack, file, rel string) (bool, error) {
+	matchAny := func(patterns []string, name string) (bool, error) {
+		for _, p := range patterns {
+			ok, err := utils.MatchGlob(p, name)
+			if ok || err != nil {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+	// watch paths are directories or files, as nodemon watches them, unless they are globs
+	watches := func(paths []string, name string) (bool, error) {
+		for _, p := range paths {
+			if strings.ContainsAny(p, "*?[") {
+				ok, err := utils.MatchGlob(p, name)
+				if ok || err != nil {
+					return ok, err
+				}
+				continue
+			}
+			p = path.Clean(filepath.ToSlash(p))
+			if name == p || strings.HasPrefix(name, p+"/") {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	if f.Watch != nil {
+		excluded, err := watches(f.Watch.Exclude, rel)
+		if excluded || err != nil {
+			return false, err
+		}
+		if len(f.Watch.Include) > 0 {
+			return watches(f.Watch.Include, rel)
+		}
+	}
+	// the build copies the whole context directory, so shared modules and manifests within it change the function
+	if !within(f.contextDirectory, file) {
+		return false, nil
+	}
+	// files excluded from the build don't change the function
+	contextRel, err := filepath.Rel(f.contextDirectory, file)
+	if err != nil {
+		return false, err
+	}
+	excluded, err := matchAny(f.Excludes, filepath.ToSlash(contextRel))
+	return !excluded, err
+}
+
+// within is true when file is in dir
+func within(dir, file string) bool {
+	rel, err := filepath.Rel(dir, file)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,133 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestChanged(t *testing.T) {
+	dir := filepath.Join(string(filepath.Separator), "repo", "apps", "shop")
+	s := &Stack{
+		dir:  dir,
+		file: "nitric.yaml",
+		Name: "shop",
+		Functions: map[string]Function{
+			"orders": {
+				Handler:     "functions/orders.ts",
+				Excludes:    []string{"**/*.md", "payments/**"},
+				ComputeUnit: ComputeUnit{contextDirectory: dir},
+			},
+			"payments": {
+				Handler:     "payments/main.go",
+				Watch:       &Watch{Include: []string{"payments", "../../libs/money/**"}, Exclude: []string{"payments/testdata"}},
+				ComputeUnit: ComputeUnit{contextDirectory: filepath.Join(dir, "payments")},
+			},
+		},
+		Containers: map[string]Container{
+			"worker": {Dockerfile: "worker/Dockerfile", ComputeUnit: ComputeUnit{contextDirectory: filepath.Join(dir, "worker")}},
+		},
+		Apis: map[string]string{"main": "api.yaml"},
+	}
+
+	tests := []struct {
+		name  string
+		files []string
+		want  *Changes
+	}{
+		{
+			name:  "unrelated",
+			files: []string{"/repo/apps/other/main.ts", "/repo/apps/shop/README.md"},
+			want:  &Changes{Stack: "shop", Functions: []string{}, Containers: []string{}, Apis: []string{}, Files: []string{}},
+		},
+		{
+			name:  "excluded from the build",
+			files: []string{"/repo/apps/shop/docs/guide.md"},
+			want:  &Changes{Stack: "shop", Functions: []string{}, Containers: []string{}, Apis: []string{}, Files: []string{}},
+		},
+		{
+			name:  "shared module in the context directory",
+			files: []string{"/repo/apps/shop/common/util.ts"},
+			want: &Changes{
+				Stack: "shop", Functions: []string{"orders"}, Containers: []string{}, Apis: []string{},
+				Files: []string{"common/util.ts"},
+			},
+		},
+		{
+			name:  "context root manifests",
+			files: []string{"/repo/apps/shop/package.json", "/repo/apps/shop/package-lock.json"},
+			want: &Changes{
+				Stack: "shop", Functions: []string{"orders"}, Containers: []string{}, Apis: []string{},
+				Files: []string{"package-lock.json", "package.json"},
+			},
+		},
+		{
+			name:  "outside of the watch paths",
+			files: []string{"/repo/apps/shop/payments/testdata/card.json", "/repo/libs/auth/token.go"},
+			want:  &Changes{Stack: "shop", Functions: []string{}, Containers: []string{}, Apis: []string{}, Files: []string{}},
+		},
+		{
+			name:  "watched directory",
+			files: []string{"/repo/apps/shop/payments/charge/stripe.go", "/repo/apps/shop/payments/testdata/card.json"},
+			want: &Changes{
+				Stack: "shop", Functions: []string{"payments"}, Containers: []string{}, Apis: []string{},
+				Files: []string{"payments/charge/stripe.go"},
+			},
+		},
+		{
+			name:  "shared module",
+			files: []string{"/repo/libs/money/currency.go"},
+			want: &Changes{
+				Stack: "shop", Functions: []string{"payments"}, Containers: []string{}, Apis: []string{},
+				Files: []string{"../../libs/money/currency.go"},
+			},
+		},
+		{
+			name:  "handler and api",
+			files: []string{"/repo/apps/shop/functions/orders.ts", "/repo/apps/shop/api.yaml"},
+			want: &Changes{
+				Stack: "shop", Functions: []string{"orders"}, Containers: []string{}, Apis: []string{"main"},
+				Files: []string{"api.yaml", "functions/orders.ts"},
+			},
+		},
+		{
+			name:  "stack file",
+			files: []string{"/repo/apps/shop/nitric.yaml"},
+			want: &Changes{
+				Stack: "shop", Config: true, Functions: []string{"orders", "payments"}, Containers: []string{"worker"},
+				Apis: []string{"main"}, Files: []string{"nitric.yaml"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := []string{}
+			for _, f := range tt.files {
+				files = append(files, filepath.FromSlash(f))
+			}
+			got, err := s.Changed(files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Changed() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/nitrictech/newcli/pkg/utils"
+)
+
+// Platform returns the image platform matching the compute unit's architecture,
+// or an empty string to build for the host platform
+func (c *ComputeUnit) Platform() (string, error) {
+	switch c.Architecture {
+	case "":
+		return "", nil
+	case "amd64", "x86_64":
+		return "linux/amd64", nil
+	case "arm64", "aarch64":
+		return "linux/arm64", nil
+	default:
+		return "", fmt.Errorf("unsupported architecture %s, must be amd64 or arm64", c.Architecture)
+	}
+}
+
+// FileMounts returns the file mounts of the compute unit with their sources resolved,
+// relative sources are relative to the stack and absolute sources are used as they are
+func (c *ComputeUnit) FileMounts(s *Stack) []FileMount {
+	mounts := []FileMount{}
+	for _, fm := range c.Files {
+		if !filepath.IsAbs(fm.Source) {
+			fm.Source = filepath.Join(s.Path(), fm.Source)
+		}
+		mounts = append(mounts, fm)
+	}
+	return mounts
+}
+
+// EnvVars returns the env vars of the compute unit as KEY=VALUE, the variables of the stack's
+// env files are set for every compute unit and overridden by those of its env map
+func (c *ComputeUnit) EnvVars(s *Stack) []string {
+	vars := map[string]string{}
+	for k, v := range s.envFile {
+		vars[k] = v
+	}
+	for k, v := range c.Env {
+		vars[k] = utils.ExpandEnv(v, s.envFile)
+	}
+
+	env := []string{}
+	for k, v := range vars {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// SetDefaultEnv sets env vars for every compute unit of the stack, those of its env files override them
+func (s *Stack) SetDefaultEnv(vars map[string]string) {
+	if s.envFile == nil {
+		s.envFile = map[string]string{}
+	}
+	for k, v := range vars {
+		if _, ok := s.envFile[k]; !ok {
+			s.envFile[k] = v
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFileMounts(t *testing.T) {
+	s := &Stack{dir: "/project"}
+	c := &ComputeUnit{Files: []FileMount{
+		{Source: "certs/ca.pem", Target: "/etc/ssl/ca.pem"},
+		{Source: "/var/secrets/key.json", Target: "/secrets/key.json"},
+	}}
+
+	want := []FileMount{
+		{Source: "/project/certs/ca.pem", Target: "/etc/ssl/ca.pem"},
+		{Source: "/var/secrets/key.json", Target: "/secrets/key.json"},
+	}
+	if got := c.FileMounts(s); !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
@@ -18,6 +18,8 @@ package stack
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 )
 
 const DefaulMembraneVersion = "v0.12.1-rc.5"
@@ -37,6 +39,42 @@ func (f *Function) ContextDirectory() string {
 	return f.contextDirectory
 }
 
+// FunctionByHandler returns the function whose handler is the file, given as an absolute path,
+// functions are matched by their handler as their names don't always match the handler's file name
+func (s *Stack) FunctionByHandler(file string) (Function, bool) {
+	for _, f := range s.Functions {
+		if filepath.Join(f.contextDirectory, f.Handler) == filepath.Clean(file) {
+			return f, true
+		}
+	}
+	return Function{}, false
+}
+
+// BaseImage returns the image a build of the function should start from in place of image, see BaseImage
+func (f *Function) BaseImage(image string) string {
+	return BaseImage(f.baseImages, image)
+}
+
+// BaseImage returns the image a build should start from in place of image, given the baseImages of a stack,
+// an override for the repository alone keeps the default tag (e.g. node: mirror/node gives mirror/node:alpine)
+func BaseImage(baseImages map[string]string, image string) string {
+	if override, ok := baseImages[image]; ok {
+		return override
+	}
+	repo, tag := image, ""
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		repo, tag = image[:i], image[i:]
+	}
+	override, ok := baseImages[repo]
+	if !ok {
+		return image
+	}
+	if strings.LastIndex(override, ":") > strings.LastIndex(override, "/") {
+		return override
+	}
+	return override + tag
+}
+
 // ImageTagName returns the default image tag for a source image built from this function
 // provider the provider name (e.g. aws), used to uniquely identify builds for specific providers
 func (f *Function) ImageTagName(s *Stack, provider string) string {
@@ -0,0 +1,73 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import "testing"
+
+func TestBaseImage(t *testing.T) {
+	f := &Function{baseImages: map[string]string{
+		"node:alpine": "mirror.example.com/node:16-alpine",
+		"golang":      "mirror.example.com/golang",
+		"alpine":      "mirror.example.com:5000/alpine:3.15",
+		"python":      "localhost:5000/python",
+	}}
+
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{image: "node:alpine", want: "mirror.example.com/node:16-alpine"},
+		{image: "golang:alpine", want: "mirror.example.com/golang:alpine"},
+		{image: "alpine", want: "mirror.example.com:5000/alpine:3.15"},
+		{image: "python:3.7-slim", want: "localhost:5000/python:3.7-slim"},
+		{image: "mcr.microsoft.com/dotnet/sdk:6.0", want: "mcr.microsoft.com/dotnet/sdk:6.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			if got := f.BaseImage(tt.image); got != tt.want {
+				t.Errorf("BaseImage(%s) = %s, want %s", tt.image, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFunctionByHandler(t *testing.T) {
+	s := &Stack{Functions: map[string]Function{
+		"users":  {Handler: "functions/users.ts", ComputeUnit: ComputeUnit{contextDirectory: "/stack"}},
+		"orders": {Handler: "orders.ts", ComputeUnit: ComputeUnit{contextDirectory: "/stack/services"}},
+	}}
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{file: "/stack/functions/users.ts", want: "users"},
+		{file: "/stack/services/./orders.ts", want: "orders"},
+		{file: "/stack/orders.ts", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			f, ok := s.FunctionByHandler(tt.file)
+			if ok != (tt.want != "") {
+				t.Fatalf("FunctionByHandler(%s) found = %v, want %v", tt.file, ok, tt.want != "")
+			}
+			if ok && f.Handler != s.Functions[tt.want].Handler {
+				t.Errorf("FunctionByHandler(%s) = %s, want %s", tt.file, f.Handler, s.Functions[tt.want].Handler)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Node is a resource of the stack, its ID is <type>:<name> e.g. function:api
+type Node struct {
+	ID         string      `yaml:"id" json:"id"`
+	Type       string      `yaml:"type" json:"type"`
+	Name       string      `yaml:"name" json:"name"`
+	Properties interface{} `yaml:"properties,omitempty" json:"properties,omitempty"`
+}
+
+// Edge is a relationship between two resources, e.g. a function subscribing to a topic
+type Edge struct {
+	From     string `yaml:"from" json:"from"`
+	To       string `yaml:"to" json:"to"`
+	Relation string `yaml:"relation" json:"relation"`
+}
+
+// Graph is the resources of a stack and their relationships, sorted so
+// that the same stack always produces the same graph
+type Graph struct {
+	Nodes []Node `yaml:"nodes" json:"nodes"`
+	Edges []Edge `yaml:"edges,omitempty" json:"edges,omitempty"`
+}
+
+type nitricTarget struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+func (g *Graph) addNode(resType, name string, props interface{}) {
+	g.Nodes = append(g.Nodes, Node{ID: resType + ":" + name, Type: resType, Name: name, Properties: props})
+}
+
+func (g *Graph) addEdge(from, to, relation string) {
+	g.Edges = append(g.Edges, Edge{From: from, To: to, Relation: relation})
+}
+
+// Graph returns the resource graph of the stack
+func (s *Stack) Graph() *Graph {
+	g := &Graph{Nodes: []Node{}, Edges: []Edge{}}
+
+	for name, f := range s.Functions {
+		f.Env = envKeys(f.Env)
+		g.addNode("function", name, f)
+		for _, t := range f.Triggers.Topics {
+			g.addEdge("function:"+name, "topic:"+t, "subscribes")
+		}
+	}
+	for name, c := range s.Containers {
+		c.Env = envKeys(c.Env)
+		g.addNode("container", name, c)
+		for _, t := range c.Triggers.Topics {
+			g.addEdge("container:"+name, "topic:"+t, "subscribes")
+		}
+	}
+	for name := range s.Collections {
+		g.addNode("collection", name, nil)
+	}
+	for name := range s.Buckets {
+		g.addNode("bucket", name, nil)
+	}
+	for name, t := range s.Topics {
+		g.addNode("topic", name, t)
+	}
+	for name := range s.Queues {
+		g.addNode("queue", name, nil)
+	}
+	for name, sc := range s.Schedules {
+		g.addNode("schedule", name, sc)
+		g.addEdge("schedule:"+name, sc.Target.Type+":"+sc.Target.Name, "triggers")
+	}
+	for name, file := range s.Apis {
+		g.addNode("api", name, map[string]string{"document": file})
+		for _, target := range apiTargets(s.apiDocs[name]) {
+			g.addEdge("api:"+name, target, "routes")
+		}
+	}
+	for name, site := range s.Sites {
+		g.addNode("site", name, site)
+	}
+	for name, e := range s.EntryPoints {
+		g.addNode("entrypoint", name, e)
+		for _, p := range e.Paths {
+			g.addEdge("entrypoint:"+name, p.Type+":"+p.Target, "routes")
+		}
+	}
+
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+	sortEdges(g.Edges)
+	g.Edges = uniqueEdges(g.Edges)
+	return g
+}
+
+// sortEdges sorts edges by resource and then by relation, so every edge has a stable position
+func sortEdges(edges []Edge) {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		if edges[i].To != edges[j].To {
+			return edges[i].To < edges[j].To
+		}
+		return edges[i].Relation < edges[j].Relation
+	})
+}
+
+// envKeys returns the env vars without their values, which can be secrets and
+// shouldn't end up in committed snapshots
+func envKeys(env map[string]string) map[string]string {
+	if env == nil {
+		return nil
+	}
+	keys := map[string]string{}
+	for k := range env {
+		keys[k] = ""
+	}
+	return keys
+}
+
+func uniqueEdges(edges []Edge) []Edge {
+	unique := []Edge{}
+	for i, e := range edges {
+		if i == 0 || e != edges[i-1] {
+			unique = append(unique, e)
+		}
+	}
+	return unique
+}
+
+// apiTargets returns the resources the operations of an api document route to
+func apiTargets(doc *openapi3.T) []string {
+	targets := []string{}
+	if doc == nil {
+		return targets
+	}
+	for _, item := range doc.Paths {
+		for _, op := range item.Operations() {
+			if t, ok := operationTarget(op); ok {
+				targets = append(targets, t.Type+":"+t.Name)
+			}
+		}
+	}
+	return targets
+}
+
+// operationTarget returns the resource an api operation routes to, from its x-nitric-target extension
+func operationTarget(op *openapi3.Operation) (nitricTarget, bool) {
+	t := nitricTarget{}
+	ext, ok := op.Extensions["x-nitric-target"]
+	if !ok {
+		return t, false
+	}
+	switch v := ext.(type) {
+	case json.RawMessage:
+		if err := json.Unmarshal(v, &t); err != nil {
+			return t, false
+		}
+	case map[string]interface{}:
+		t.Type, _ = v["type"].(string)
+		t.Name, _ = v["name"].(string)
+	}
+	return t, t.Type != "" && t.Name != ""
+}
@@ -0,0 +1,78 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"fmt"
+	"strings"
+)
+
+// dotShapes are the graphviz node shapes of each resource type
+var dotShapes = map[string]string{
+	"function":   "box",
+	"container":  "box3d",
+	"topic":      "parallelogram",
+	"queue":      "parallelogram",
+	"bucket":     "cylinder",
+	"collection": "cylinder",
+	"schedule":   "note",
+	"api":        "house",
+	"entrypoint": "house",
+	"site":       "tab",
+}
+
+// DOT renders the graph in the graphviz DOT language, e.g. to be converted with 'dot -Tsvg'
+func (g *Graph) DOT(name string) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "digraph %q {\n", name)
+	fmt.Fprintln(b, "  rankdir=LR;")
+	for _, n := range g.Nodes {
+		shape, ok := dotShapes[n.Type]
+		if !ok {
+			shape = "ellipse"
+		}
+		fmt.Fprintf(b, "  %q [label=%q, shape=%s];\n", n.ID, n.Type+"\n"+n.Name, shape)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(b, "  %q -> %q [label=%q];\n", e.From, e.To, e.Relation)
+	}
+	fmt.Fprintln(b, "}")
+	return b.String()
+}
+
+// Mermaid renders the graph as a mermaid flowchart, e.g. to be embedded in markdown
+func (g *Graph) Mermaid() string {
+	b := &strings.Builder{}
+	fmt.Fprintln(b, "graph LR")
+
+	// mermaid ids can't contain colons, so nodes are numbered in order
+	ids := map[string]string{}
+	id := func(resource string) string {
+		if _, ok := ids[resource]; !ok {
+			ids[resource] = fmt.Sprintf("n%d", len(ids))
+			fmt.Fprintf(b, "  %s[\"%s\"]\n", ids[resource], resource)
+		}
+		return ids[resource]
+	}
+	for _, n := range g.Nodes {
+		id(n.ID)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(b, "  %s -->|%s| %s\n", id(e.From), e.Relation, id(e.To))
+	}
+	return b.String()
+}
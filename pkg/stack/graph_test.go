@@ -0,0 +1,129 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGraph(t *testing.T) {
+	s := &Stack{
+		Name: "test",
+		Functions: map[string]Function{
+			"orders": {Handler: "orders.ts", ComputeUnit: ComputeUnit{
+				Triggers: Triggers{Topics: []string{"created"}},
+				Env:      map[string]string{"API_KEY": "secret"},
+			}},
+		},
+		Topics: map[string]Topic{"created": {}},
+		Schedules: map[string]Schedule{
+			"nightly": {Expression: "daily", Target: ScheduleTarget{Type: "topic", Name: "created"}},
+			"hourly":  {Expression: "hourly", Target: ScheduleTarget{Type: "topic", Name: "created"}},
+		},
+		EntryPoints: map[string]Entrypoint{
+			"main": {Paths: map[string]EntrypointPath{
+				"/":       {Type: "function", Target: "orders"},
+				"/orders": {Type: "function", Target: "orders"},
+			}},
+		},
+	}
+
+	got := s.Graph()
+
+	ids := []string{}
+	for _, n := range got.Nodes {
+		ids = append(ids, n.ID)
+	}
+	wantIds := []string{"entrypoint:main", "function:orders", "schedule:hourly", "schedule:nightly", "topic:created"}
+	if !cmp.Equal(wantIds, ids) {
+		t.Error(cmp.Diff(wantIds, ids))
+	}
+
+	wantEdges := []Edge{
+		{From: "entrypoint:main", To: "function:orders", Relation: "routes"},
+		{From: "function:orders", To: "topic:created", Relation: "subscribes"},
+		{From: "schedule:hourly", To: "topic:created", Relation: "triggers"},
+		{From: "schedule:nightly", To: "topic:created", Relation: "triggers"},
+	}
+	if !cmp.Equal(wantEdges, got.Edges) {
+		t.Error(cmp.Diff(wantEdges, got.Edges))
+	}
+
+	// env values can be secrets, only the keys are kept
+	wantEnv := map[string]string{"API_KEY": ""}
+	if env := got.Nodes[1].Properties.(Function).Env; !cmp.Equal(wantEnv, env) {
+		t.Error(cmp.Diff(wantEnv, env))
+	}
+	if s.Functions["orders"].Env["API_KEY"] != "secret" {
+		t.Error("Graph() modified the stack's env")
+	}
+}
+
+func TestSortEdges(t *testing.T) {
+	edges := []Edge{
+		{From: "entrypoint:main", To: "function:orders", Relation: "routes"},
+		{From: "entrypoint:main", To: "function:carts", Relation: "routes"},
+		{From: "entrypoint:main", To: "function:orders", Relation: "depends"},
+	}
+	sortEdges(edges)
+	want := []Edge{
+		{From: "entrypoint:main", To: "function:carts", Relation: "routes"},
+		{From: "entrypoint:main", To: "function:orders", Relation: "depends"},
+		{From: "entrypoint:main", To: "function:orders", Relation: "routes"},
+	}
+	if !cmp.Equal(want, edges) {
+		t.Error(cmp.Diff(want, edges))
+	}
+}
+
+func TestGraphRender(t *testing.T) {
+	g := &Graph{
+		Nodes: []Node{
+			{ID: "function:orders", Type: "function", Name: "orders"},
+			{ID: "topic:created", Type: "topic", Name: "created"},
+		},
+		Edges: []Edge{
+			{From: "function:orders", To: "topic:created", Relation: "subscribes"},
+			{From: "schedule:nightly", To: "topic:created", Relation: "triggers"},
+		},
+	}
+
+	wantDOT := `digraph "test" {
+  rankdir=LR;
+  "function:orders" [label="function\norders", shape=box];
+  "topic:created" [label="topic\ncreated", shape=parallelogram];
+  "function:orders" -> "topic:created" [label="subscribes"];
+  "schedule:nightly" -> "topic:created" [label="triggers"];
+}
+`
+	if got := g.DOT("test"); !cmp.Equal(wantDOT, got) {
+		t.Error(cmp.Diff(wantDOT, got))
+	}
+
+	wantMermaid := `graph LR
+  n0["function:orders"]
+  n1["topic:created"]
+  n0 -->|subscribes| n1
+  n2["schedule:nightly"]
+  n2 -->|triggers| n1
+`
+	if got := g.Mermaid(); !cmp.Equal(wantMermaid, got) {
+		t.Error(cmp.Diff(wantMermaid, got))
+	}
+}
@@ -22,15 +22,30 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+
+	"github.com/nitrictech/newcli/pkg/utils"
 )
 
 var (
 	stackPath string
+	envFiles  []string
 )
 
+// notFoundError is the cause of FromOptions errors when there is no stack at the stack path
+type notFoundError struct {
+	error
+}
+
+// IsNotFound returns true when FromOptions failed because there is no stack at the stack path,
+// for commands where the stack is optional. Other errors mean the stack is there but broken.
+func IsNotFound(err error) bool {
+	_, ok := errors.Cause(err).(*notFoundError)
+	return ok
+}
+
 func wrapStatError(err error) error {
 	if os.IsNotExist(err) {
-		return errors.WithMessage(err, "Please provide the correct path to the stack (eg. -s ./nitric.yaml)")
+		return errors.WithMessage(&notFoundError{err}, "Please provide the correct path to the stack (eg. -s ./nitric.yaml)")
 	}
 	if os.IsPermission(err) {
 		return errors.WithMessagef(err, "Please make sure that %s has the correct permissions", stackPath)
@@ -55,7 +70,36 @@ func FromOptions() (*Stack, error) {
 		return nil, wrapStatError(err)
 	}
 
-	return FromFile(stackPath)
+	s, err := FromFile(stackPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(envFiles) > 0 {
+		s.envFile, err = readEnvFiles(envFiles)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// readEnvFiles merges the variables of the env files, later files override the variables of earlier ones
+func readEnvFiles(files []string) (map[string]string, error) {
+	env := map[string]string{}
+	for _, f := range files {
+		vars, err := utils.ReadEnvFile(f)
+		if os.IsNotExist(err) {
+			return nil, errors.Errorf("env file not found: %s", f)
+		}
+		if err != nil {
+			return nil, errors.WithMessage(err, "env file")
+		}
+		for k, v := range vars {
+			env[k] = v
+		}
+	}
+	return env, nil
 }
 
 func AddOptions(cmd *cobra.Command) {
@@ -63,3 +107,8 @@ func AddOptions(cmd *cobra.Command) {
 	cobra.CheckErr(err)
 	cmd.Flags().StringVarP(&stackPath, "stack", "s", wd, "path to the stack")
 }
+
+// AddEnvOptions adds the --env-file flag, for the commands that run the stack's compute units
+func AddEnvOptions(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&envFiles, "env-file", []string{}, "read env vars for every function from a file of KEY=VALUE lines, can be repeated")
+}
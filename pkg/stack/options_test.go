@@ -0,0 +1,51 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadEnvFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, ".env")
+	local := filepath.Join(dir, ".env.local")
+	if err := ioutil.WriteFile(base, []byte("A=1\nB=2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(local, []byte("B=3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readEnvFiles([]string{base, local})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(got, map[string]string{"A": "1", "B": "3"}) {
+		t.Errorf("readEnvFiles() = %v, want the later file to override B", got)
+	}
+
+	_, err = readEnvFiles([]string{base, filepath.Join(dir, "missing.env")})
+	if err == nil || !strings.Contains(err.Error(), "env file not found") {
+		t.Errorf("readEnvFiles() error = %v, want env file not found", err)
+	}
+}
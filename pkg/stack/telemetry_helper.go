@@ -0,0 +1,53 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Env returns the standard OpenTelemetry env vars for a compute unit of the stack
+func (t *Telemetry) Env(s *Stack, serviceName string) []string {
+	if t == nil || t.Endpoint == "" {
+		return []string{}
+	}
+
+	env := []string{
+		"OTEL_EXPORTER_OTLP_ENDPOINT=" + t.Endpoint,
+		"OTEL_SERVICE_NAME=" + serviceName,
+		fmt.Sprintf("OTEL_RESOURCE_ATTRIBUTES=nitric.stack=%s,nitric.function=%s", s.Name, serviceName),
+	}
+
+	if len(t.Headers) > 0 {
+		headers := []string{}
+		for k, v := range t.Headers {
+			headers = append(headers, k+"="+v)
+		}
+		sort.Strings(headers)
+		env = append(env, "OTEL_EXPORTER_OTLP_HEADERS="+strings.Join(headers, ","))
+	}
+
+	if t.SampleRate != nil {
+		env = append(env,
+			"OTEL_TRACES_SAMPLER=parentbased_traceidratio",
+			fmt.Sprintf("OTEL_TRACES_SAMPLER_ARG=%g", *t.SampleRate),
+		)
+	}
+	return env
+}
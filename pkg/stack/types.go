@@ -17,18 +17,46 @@
 package stack
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"path"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
+
+	"github.com/nitrictech/newcli/pkg/utils"
 )
 
 type Triggers struct {
 	Topics []string `yaml:"topics,omitempty"`
 }
 
+// FileMount makes a file or directory from the stack available inside a compute unit,
+// e.g. credential files or certificates for libraries that only accept file paths
+type FileMount struct {
+	// Source is the path of the file or directory, absolute or relative to the stack
+	Source string `yaml:"source"`
+
+	// Target is the absolute path the source will be mounted to
+	Target string `yaml:"target"`
+}
+
+// GPU requests accelerators for a compute unit
+type GPU struct {
+	// The number of GPUs to attach
+	Count int `yaml:"count"`
+
+	// The GPU model, e.g. nvidia-l4, the accepted values depend on the provider
+	Type string `yaml:"type,omitempty"`
+}
+
 type ComputeUnit struct {
 	name string `yaml:"-"` //nolint:structcheck,unused
 
@@ -43,6 +71,18 @@ type ComputeUnit struct {
 	// The memory of the compute instance in MB
 	Memory int `yaml:"memory,omitempty"`
 
+	// The maximum duration of a request in seconds
+	Timeout int `yaml:"timeout,omitempty"`
+
+	// The number of vCPUs allocated to the compute instance, fractions are allowed (e.g. 0.5)
+	CPU float64 `yaml:"cpu,omitempty"`
+
+	// GPUs to attach to the compute instance
+	GPU *GPU `yaml:"gpu,omitempty"`
+
+	// The CPU architecture to build and run on, amd64 or arm64 (defaults to the host's architecture)
+	Architecture string `yaml:"architecture,omitempty"`
+
 	// The minimum number of instances to keep alive
 	MinScale int `yaml:"minScale,omitempty"`
 
@@ -51,11 +91,43 @@ type ComputeUnit struct {
 
 	// Allow the user to specify a custom unique tag for the function
 	Tag string `yaml:"tag,omitempty"`
+
+	// Files to mount (read only) into the compute unit
+	Files []FileMount `yaml:"files,omitempty"`
+
+	// Env vars of the compute unit, values can reference ${VAR} from the env files or host environment
+	Env map[string]string `yaml:"env,omitempty"`
+
+	// Build time variables passed to the image build
+	BuildArgs map[string]string `yaml:"buildArgs,omitempty"`
+
+	// Labels added to the image, e.g. org.opencontainers.image.vendor
+	Labels map[string]string `yaml:"labels,omitempty"`
+
+	// Visibility of the compute unit's endpoint, either public (the default) or
+	// internal, which makes it reachable only from within the deployment
+	Visibility string `yaml:"visibility,omitempty"`
+}
+
+const (
+	VisibilityPublic   = "public"
+	VisibilityInternal = "internal"
+)
+
+// Watch limits the paths that restart a function during nitric run, e.g. to the function's own
+// packages in a monorepo. Paths are relative to the stack and may be globs.
+type Watch struct {
+	// Paths to watch, defaults to the whole stack
+	Include []string `yaml:"include,omitempty"`
+
+	// Paths to ignore
+	Exclude []string `yaml:"exclude,omitempty"`
 }
 
 type Function struct {
 	// The location of the function handler
-	// relative to context
+	// relative to context, a glob (e.g. functions/*/main.go) declares
+	// a function for each matching handler
 	Handler string `yaml:"handler"`
 
 	// The build pack version of the membrane used for the function build
@@ -68,6 +140,9 @@ type Function struct {
 	// files to exclude from final build
 	Excludes []string `yaml:"excludes,omitempty"`
 
+	// The paths that restart the function when edited during nitric run
+	Watch *Watch `yaml:"watch,omitempty"`
+
 	// The most requests a single function instance should handle
 	MaxRequests int `yaml:"maxRequests,omitempty"`
 
@@ -76,6 +151,9 @@ type Function struct {
 	// would use public, but its reserved by typescript
 	External bool `yaml:"external"`
 
+	// Base image overrides copied from the stack
+	baseImages map[string]string `yaml:"-"`
+
 	ComputeUnit `yaml:",inline"`
 }
 
@@ -111,7 +189,11 @@ type Collection struct{}
 
 type Bucket struct{}
 
-type Topic struct{}
+type Topic struct {
+	// Path to a JSON schema that payloads published to the topic must satisfy,
+	// relative to the stack
+	Schema string `yaml:"schema,omitempty"`
+}
 
 type Queue struct{}
 
@@ -138,20 +220,112 @@ type Entrypoint struct {
 	Paths   map[string]EntrypointPath `yaml:"paths,omitempty"`
 }
 
+// SmokeTest is checked against a deployment once it has been applied,
+// it is either an HTTP check against a resource or a command
+type SmokeTest struct {
+	// The resource to check, <type>:<name> e.g. entrypoint:main or function:api
+	Target string `yaml:"target,omitempty"`
+
+	// The path to request from the target
+	Path string `yaml:"path,omitempty"`
+
+	// The expected response status, defaults to 200
+	Status int `yaml:"status,omitempty"`
+
+	// A command to run from the stack directory instead of an HTTP check,
+	// NITRIC_<TYPE>_<NAME>_URL env vars (e.g. NITRIC_API_MAIN_URL) are set to the deployed endpoints
+	Command string `yaml:"command,omitempty"`
+}
+
+// Telemetry configures where compute units send their traces and metrics
+type Telemetry struct {
+	// The OTLP endpoint to export to, e.g. https://api.honeycomb.io
+	Endpoint string `yaml:"endpoint"`
+
+	// Headers sent with every export, e.g. API keys
+	Headers map[string]string `yaml:"headers,omitempty"`
+
+	// The fraction of traces to sample, between 0 and 1 (defaults to 1)
+	SampleRate *float64 `yaml:"sampleRate,omitempty"`
+}
+
+// ApiPolicy is the CORS, authentication and rate limiting applied to the routes of an api
+type ApiPolicy struct {
+	Cors      *Cors      `yaml:"cors,omitempty"`
+	Jwt       *Jwt       `yaml:"jwt,omitempty"`
+	RateLimit *RateLimit `yaml:"rateLimit,omitempty"`
+}
+
+type Cors struct {
+	// Origins allowed to make requests, * allows any origin
+	AllowOrigins []string `yaml:"allowOrigins"`
+
+	// Defaults to GET, POST, PUT, PATCH and DELETE
+	AllowMethods []string `yaml:"allowMethods,omitempty"`
+
+	// Defaults to the headers requested by the browser
+	AllowHeaders []string `yaml:"allowHeaders,omitempty"`
+
+	AllowCredentials bool `yaml:"allowCredentials,omitempty"`
+
+	// How long preflight responses can be cached for in seconds
+	MaxAge int `yaml:"maxAge,omitempty"`
+}
+
+// Jwt requires requests to carry a bearer token signed by one of the keys of the JWKS (RS256)
+type Jwt struct {
+	// The URL of the JWKS, or its path relative to the stack
+	Jwks string `yaml:"jwks"`
+
+	// The expected iss claim, not checked when empty
+	Issuer string `yaml:"issuer,omitempty"`
+
+	// The token's aud claim must contain one of these, not checked when empty
+	Audiences []string `yaml:"audiences,omitempty"`
+}
+
+type RateLimit struct {
+	RequestsPerSecond float64 `yaml:"requestsPerSecond"`
+
+	// The most requests allowed at once, defaults to RequestsPerSecond
+	Burst int `yaml:"burst,omitempty"`
+}
+
+// Stubs are the canned responses of the services that have no local emulator,
+// returned to functions during nitric run
+type Stubs struct {
+	// Values returned when a secret is accessed, secrets not listed return an empty value
+	Secrets map[string]string `yaml:"secrets,omitempty"`
+
+	// Task payloads returned when receiving from a queue, before any tasks sent to it
+	Queues map[string][]map[string]interface{} `yaml:"queues,omitempty"`
+}
+
 type Stack struct {
-	dir         string
-	Name        string                 `yaml:"name"`
-	Functions   map[string]Function    `yaml:"functions,omitempty"`
-	Collections map[string]Collection  `yaml:"collections,omitempty"`
-	Containers  map[string]Container   `yaml:"containers,omitempty"`
-	Buckets     map[string]Bucket      `yaml:"buckets,omitempty"`
-	Topics      map[string]Topic       `yaml:"topics,omitempty"`
-	Queues      map[string]Queue       `yaml:"queues,omitempty"`
-	Schedules   map[string]Schedule    `yaml:"schedules,omitempty"`
-	apiDocs     map[string]*openapi3.T `yaml:"-"`
-	Apis        map[string]string      `yaml:"apis,omitempty"`
-	Sites       map[string]Site        `yaml:"sites,omitempty"`
-	EntryPoints map[string]Entrypoint  `yaml:"entrypoints,omitempty"`
+	dir          string
+	file         string
+	Name         string                      `yaml:"name"`
+	Functions    map[string]Function         `yaml:"functions,omitempty"`
+	Collections  map[string]Collection       `yaml:"collections,omitempty"`
+	Containers   map[string]Container        `yaml:"containers,omitempty"`
+	Buckets      map[string]Bucket           `yaml:"buckets,omitempty"`
+	Topics       map[string]Topic            `yaml:"topics,omitempty"`
+	Queues       map[string]Queue            `yaml:"queues,omitempty"`
+	Schedules    map[string]Schedule         `yaml:"schedules,omitempty"`
+	apiDocs      map[string]*openapi3.T      `yaml:"-"`
+	topicSchemas map[string]*openapi3.Schema `yaml:"-"`
+	envFile      map[string]string           `yaml:"-"`
+	Apis         map[string]string           `yaml:"apis,omitempty"`
+	ApiPolicies  map[string]ApiPolicy        `yaml:"apiPolicies,omitempty"`
+	Sites        map[string]Site             `yaml:"sites,omitempty"`
+	EntryPoints  map[string]Entrypoint       `yaml:"entrypoints,omitempty"`
+	SmokeTests   map[string]SmokeTest        `yaml:"smokeTests,omitempty"`
+	Telemetry    *Telemetry                  `yaml:"telemetry,omitempty"`
+	Stubs        *Stubs                      `yaml:"stubs,omitempty"`
+
+	// Replacement images for the base images function builds start from,
+	// keyed by the default image (e.g. node:alpine) or its repository (e.g. node)
+	BaseImages map[string]string `yaml:"baseImages,omitempty"`
 }
 
 func (s *Stack) SetApiDoc(name string, doc *openapi3.T) {
@@ -162,6 +336,12 @@ func (s *Stack) SetApiDoc(name string, doc *openapi3.T) {
 	s.apiDocs[name] = doc
 }
 
+// ApiDoc returns the openapi document of the named api
+func (s *Stack) ApiDoc(name string) (*openapi3.T, bool) {
+	doc, ok := s.apiDocs[name]
+	return doc, ok
+}
+
 func FromFile(name string) (*Stack, error) {
 	yamlFile, err := ioutil.ReadFile(name)
 	if err != nil {
@@ -171,11 +351,15 @@ func FromFile(name string) (*Stack, error) {
 	if err != nil {
 		return nil, err
 	}
-	stack := &Stack{dir: dir}
+	stack := &Stack{dir: dir, file: filepath.Base(name)}
 	err = yaml.Unmarshal(yamlFile, stack)
 	if err != nil {
 		return nil, err
 	}
+	err = stack.expandHandlerGlobs()
+	if err != nil {
+		return nil, err
+	}
 	for name, fn := range stack.Functions {
 		fn.name = name
 		if fn.Context != "" {
@@ -183,6 +367,7 @@ func FromFile(name string) (*Stack, error) {
 		} else {
 			fn.contextDirectory = stack.Path()
 		}
+		fn.baseImages = stack.BaseImages
 		stack.Functions[name] = fn
 	}
 	for name, c := range stack.Containers {
@@ -208,9 +393,133 @@ func FromFile(name string) (*Stack, error) {
 		}
 	}
 
+	// Load the payload schemas of topics that declare them
+	for k, v := range stack.Topics {
+		if v.Schema == "" {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(stack.dir, v.Schema))
+		if err != nil {
+			return nil, errors.WithMessagef(err, "schema for topic %s", k)
+		}
+		schema := &openapi3.Schema{}
+		if err := json.Unmarshal(b, schema); err != nil {
+			return nil, errors.WithMessagef(err, "schema for topic %s", k)
+		}
+		if err := schema.Validate(context.Background()); err != nil {
+			return nil, errors.WithMessagef(err, "invalid schema for topic %s", k)
+		}
+		if stack.topicSchemas == nil {
+			stack.topicSchemas = make(map[string]*openapi3.Schema)
+		}
+		stack.topicSchemas[k] = schema
+	}
+
 	return stack, nil
 }
 
+// expandHandlerGlobs replaces functions whose handler is a glob (e.g. functions/*/main.go) with
+// a function for each matching handler, named by utils.HandlerNames
+func (s *Stack) expandHandlerGlobs() error {
+	keys := []string{}
+	for key, fn := range s.Functions {
+		if utils.IsGlob(fn.Handler) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	// the functions are only replaced once every glob is expanded, so each name is checked against all of them
+	expanded := map[string]Function{}
+	for _, key := range keys {
+		fn := s.Functions[key]
+		context := path.Join(s.dir, fn.Context)
+		handlers, err := utils.Glob(context, fn.Handler)
+		if err != nil {
+			return err
+		}
+		if len(handlers) == 0 {
+			return fmt.Errorf("function %s: no handlers match %s", key, fn.Handler)
+		}
+		names, err := utils.HandlerNames(handlers)
+		if err != nil {
+			return errors.WithMessagef(err, "function %s", key)
+		}
+
+		for _, handler := range handlers {
+			name := names[handler]
+			if existing, ok := s.Functions[name]; ok && !utils.IsGlob(existing.Handler) {
+				return fmt.Errorf("function %s: the function %s for handler %s is already declared", key, name, handler)
+			}
+			if _, ok := expanded[name]; ok {
+				return fmt.Errorf("function %s: the function %s for handler %s is also matched by another glob", key, name, handler)
+			}
+			f := fn
+			f.Handler = handler
+			expanded[name] = f
+		}
+	}
+
+	for _, key := range keys {
+		delete(s.Functions, key)
+	}
+	for name, f := range expanded {
+		s.Functions[name] = f
+	}
+	return nil
+}
+
+// ApiTimeouts returns the request timeout of each api, the longest timeout of the functions it routes to
+func (s *Stack) ApiTimeouts() map[string]time.Duration {
+	timeouts := map[string]time.Duration{}
+	for name := range s.Apis {
+		for _, target := range apiTargets(s.apiDocs[name]) {
+			fn, ok := s.Functions[strings.TrimPrefix(target, "function:")]
+			if !ok || !strings.HasPrefix(target, "function:") {
+				continue
+			}
+			if t := time.Duration(fn.Timeout) * time.Second; t > timeouts[name] {
+				timeouts[name] = t
+			}
+		}
+	}
+	return timeouts
+}
+
+// ApiRoute is an operation of an api and the function that handles it
+type ApiRoute struct {
+	Method string
+	// The path of the operation, e.g. /orders/{id}
+	Path     string
+	Function string
+}
+
+// ApiRoutes returns the operations of each api that route to a function
+func (s *Stack) ApiRoutes() map[string][]ApiRoute {
+	routes := map[string][]ApiRoute{}
+	for name := range s.Apis {
+		doc, ok := s.apiDocs[name]
+		if !ok {
+			continue
+		}
+		for p, item := range doc.Paths {
+			for m, op := range item.Operations() {
+				t, ok := operationTarget(op)
+				if !ok || t.Type != "function" {
+					continue
+				}
+				routes[name] = append(routes[name], ApiRoute{Method: m, Path: p, Function: t.Name})
+			}
+		}
+	}
+	return routes
+}
+
+// TopicSchemas returns the payload schemas of the topics that declare one
+func (s *Stack) TopicSchemas() map[string]*openapi3.Schema {
+	return s.topicSchemas
+}
+
 func (s *Stack) Path() string {
 	return s.dir
 }
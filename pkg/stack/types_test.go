@@ -0,0 +1,281 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/google/go-cmp/cmp"
+)
+
+func apiDoc(targets ...string) *openapi3.T {
+	doc := &openapi3.T{Paths: openapi3.Paths{}}
+	for _, t := range targets {
+		op := &openapi3.Operation{ExtensionProps: openapi3.ExtensionProps{Extensions: map[string]interface{}{
+			"x-nitric-target": map[string]interface{}{"type": "function", "name": t},
+		}}}
+		doc.Paths["/"+t] = &openapi3.PathItem{Get: op}
+	}
+	return doc
+}
+
+func TestApiTimeouts(t *testing.T) {
+	s := &Stack{
+		Functions: map[string]Function{
+			"orders":   {Handler: "orders.ts", ComputeUnit: ComputeUnit{Timeout: 10}},
+			"payments": {Handler: "payments.ts", ComputeUnit: ComputeUnit{Timeout: 30}},
+			"users":    {Handler: "users.ts"},
+		},
+		Apis: map[string]string{"shop": "shop.yaml", "accounts": "accounts.yaml"},
+	}
+	s.SetApiDoc("shop", apiDoc("orders", "payments"))
+	s.SetApiDoc("accounts", apiDoc("users"))
+
+	want := map[string]time.Duration{"shop": 30 * time.Second}
+	if got := s.ApiTimeouts(); !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestApiRoutes(t *testing.T) {
+	s := &Stack{Apis: map[string]string{"shop": "shop.yaml", "docs": "docs.yaml"}}
+	doc := apiDoc("orders")
+	doc.Paths["/site"] = &openapi3.PathItem{Get: &openapi3.Operation{ExtensionProps: openapi3.ExtensionProps{Extensions: map[string]interface{}{
+		"x-nitric-target": map[string]interface{}{"type": "container", "name": "site"},
+	}}}}
+	s.SetApiDoc("shop", doc)
+
+	want := map[string][]ApiRoute{"shop": {{Method: "GET", Path: "/orders", Function: "orders"}}}
+	if got := s.ApiRoutes(); !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestFromFileCPU(t *testing.T) {
+	tests := []struct {
+		name    string
+		cpu     string
+		want    float64
+		wantErr bool
+	}{
+		{name: "fraction", cpu: "0.5", want: 0.5},
+		{name: "whole", cpu: "2", want: 2},
+		{name: "not a number", cpu: "half", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "nitric.yaml")
+			yaml := "name: shop\nfunctions:\n  orders:\n    handler: orders.ts\n    cpu: " + tt.cpu + "\n"
+			if err := ioutil.WriteFile(file, []byte(yaml), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			s, err := FromFile(file)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && s.Functions["orders"].CPU != tt.want {
+				t.Errorf("FromFile() cpu = %v, want %v", s.Functions["orders"].CPU, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromFileTopicSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr bool
+	}{
+		{name: "valid", schema: `{"type": "object", "properties": {"id": {"type": "string"}}}`},
+		{name: "not json", schema: `type: object`, wantErr: true},
+		{name: "unknown type", schema: `{"type": "strng"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := ioutil.WriteFile(filepath.Join(dir, "created.json"), []byte(tt.schema), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			yaml := "name: shop\ntopics:\n  created:\n    schema: created.json\n"
+			if err := ioutil.WriteFile(filepath.Join(dir, "nitric.yaml"), []byte(yaml), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			s, err := FromFile(filepath.Join(dir, "nitric.yaml"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && s.TopicSchemas()["created"] == nil {
+				t.Error("FromFile() did not load the schema")
+			}
+		})
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	dir := t.TempDir()
+	broken := filepath.Join(dir, "broken.yaml")
+	if err := ioutil.WriteFile(broken, []byte("functions: ["), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want bool
+	}{
+		{name: "missing", path: filepath.Join(dir, "nitric.yaml"), want: true},
+		{name: "broken", path: broken, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stackPath = tt.path
+			_, err := FromOptions()
+			if err == nil {
+				t.Fatal("FromOptions() expected an error")
+			}
+			if got := IsNotFound(err); got != tt.want {
+				t.Errorf("IsNotFound(%v) = %v, want %v", err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTelemetryEnv(t *testing.T) {
+	rate := 0.25
+	tests := []struct {
+		name      string
+		telemetry *Telemetry
+		want      []string
+	}{
+		{
+			name: "not configured",
+			want: []string{},
+		},
+		{
+			name:      "no endpoint",
+			telemetry: &Telemetry{Headers: map[string]string{"x-api-key": "secret"}},
+			want:      []string{},
+		},
+		{
+			name: "headers and sample rate",
+			telemetry: &Telemetry{
+				Endpoint:   "https://api.honeycomb.io",
+				Headers:    map[string]string{"x-team": "shop", "x-api-key": "secret"},
+				SampleRate: &rate,
+			},
+			want: []string{
+				"OTEL_EXPORTER_OTLP_ENDPOINT=https://api.honeycomb.io",
+				"OTEL_SERVICE_NAME=orders",
+				"OTEL_RESOURCE_ATTRIBUTES=nitric.stack=shop,nitric.function=orders",
+				"OTEL_EXPORTER_OTLP_HEADERS=x-api-key=secret,x-team=shop",
+				"OTEL_TRACES_SAMPLER=parentbased_traceidratio",
+				"OTEL_TRACES_SAMPLER_ARG=0.25",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.telemetry.Env(&Stack{Name: "shop"}, "orders")
+			if !cmp.Equal(tt.want, got) {
+				t.Error(cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestExpandHandlerGlobs(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"functions/orders/main.go", "functions/users/main.go", "a/main.ts", "a/main.js", "other/users.go"} {
+		p := filepath.Join(dir, f)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(""), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		functions map[string]Function
+		want      map[string]string
+		wantErr   bool
+	}{
+		{
+			name: "expanded",
+			functions: map[string]Function{
+				"services": {Handler: "functions/*/main.go"},
+				"list":     {Handler: "list.ts"},
+			},
+			want: map[string]string{"orders": "functions/orders/main.go", "users": "functions/users/main.go", "list": "list.ts"},
+		},
+		{
+			name: "glob named like a handler it matches",
+			functions: map[string]Function{
+				"orders": {Handler: "functions/*/main.go"},
+			},
+			want: map[string]string{"orders": "functions/orders/main.go", "users": "functions/users/main.go"},
+		},
+		{
+			name: "already declared",
+			functions: map[string]Function{
+				"services": {Handler: "functions/*/main.go"},
+				"orders":   {Handler: "orders.ts"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "matched by two globs",
+			functions: map[string]Function{
+				"services": {Handler: "functions/*/main.go"},
+				"other":    {Handler: "other/*.go"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "handlers that can't be told apart",
+			functions: map[string]Function{
+				"a": {Handler: "a/main.*"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Stack{dir: dir, Functions: tt.functions}
+			err := s.expandHandlerGlobs()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expandHandlerGlobs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := map[string]string{}
+			for name, f := range s.Functions {
+				got[name] = f.Handler
+			}
+			if !cmp.Equal(tt.want, got) {
+				t.Error(cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
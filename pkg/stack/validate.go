@@ -0,0 +1,209 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/nitrictech/newcli/pkg/utils"
+)
+
+// Validate checks the whole stack, so mistakes are found before anything is built or deployed:
+// files that don't exist, references to resources that aren't declared, invalid api documents,
+// schedule expressions and compute unit settings, and names used by more than one compute unit
+func (s *Stack) Validate() error {
+	errs := utils.NewErrorList()
+
+	for _, name := range sortedKeys(s.Functions) {
+		fn := s.Functions[name]
+		prefix := "function " + name
+		if fn.Handler == "" {
+			errs.Add(fmt.Errorf("%s: handler is required", prefix))
+		} else {
+			errs.Add(s.checkFile(prefix, "handler", path.Join(fn.Context, fn.Handler)))
+		}
+		for _, e := range s.validateComputeUnit(prefix, &fn.ComputeUnit) {
+			errs.Add(e)
+		}
+	}
+
+	for _, name := range sortedKeys(s.Containers) {
+		c := s.Containers[name]
+		prefix := "container " + name
+		if _, ok := s.Functions[name]; ok {
+			errs.Add(fmt.Errorf("%s: the name is also used by a function", prefix))
+		}
+		if c.Dockerfile == "" {
+			errs.Add(fmt.Errorf("%s: dockerfile is required", prefix))
+		} else {
+			errs.Add(s.checkFile(prefix, "dockerfile", path.Join(c.Context, c.Dockerfile)))
+		}
+		for _, e := range s.validateComputeUnit(prefix, &c.ComputeUnit) {
+			errs.Add(e)
+		}
+	}
+
+	for _, name := range sortedKeys(s.Schedules) {
+		sc := s.Schedules[name]
+		prefix := "schedule " + name
+		if _, _, err := utils.ExpressionToCron(sc.Expression); err != nil {
+			errs.Add(fmt.Errorf("%s: %v", prefix, err))
+		}
+		errs.Add(s.checkReference(prefix, sc.Target.Type, sc.Target.Name))
+	}
+
+	for _, name := range sortedKeys(s.Apis) {
+		prefix := "api " + name
+		doc, ok := s.apiDocs[name]
+		if !ok {
+			continue
+		}
+		if err := doc.Validate(context.Background()); err != nil {
+			errs.Add(fmt.Errorf("%s: invalid openapi document %s: %v", prefix, s.Apis[name], err))
+		}
+		for _, target := range apiTargets(doc) {
+			parts := strings.SplitN(target, ":", 2)
+			errs.Add(s.checkReference(prefix, parts[0], parts[1]))
+		}
+	}
+
+	for _, name := range sortedKeys(s.ApiPolicies) {
+		prefix := "api policy " + name
+		p := s.ApiPolicies[name]
+		if _, ok := s.Apis[name]; !ok {
+			errs.Add(fmt.Errorf("%s: api %s is not declared", prefix, name))
+		}
+		if p.Jwt != nil && p.Jwt.Jwks == "" {
+			errs.Add(fmt.Errorf("%s: jwt jwks is required", prefix))
+		}
+		if p.RateLimit != nil && p.RateLimit.RequestsPerSecond <= 0 {
+			errs.Add(fmt.Errorf("%s: rateLimit requestsPerSecond must be greater than 0", prefix))
+		}
+	}
+
+	for _, name := range sortedKeys(s.EntryPoints) {
+		prefix := "entrypoint " + name
+		ep := s.EntryPoints[name]
+		for _, p := range sortedKeys(ep.Paths) {
+			errs.Add(s.checkReference(prefix+" path "+p, ep.Paths[p].Type, ep.Paths[p].Target))
+		}
+	}
+
+	for _, name := range sortedKeys(s.SmokeTests) {
+		st := s.SmokeTests[name]
+		if st.Target == "" {
+			continue
+		}
+		parts := strings.SplitN(st.Target, ":", 2)
+		if len(parts) != 2 {
+			errs.Add(fmt.Errorf("smoke test %s: target %s must be <type>:<name>", name, st.Target))
+			continue
+		}
+		errs.Add(s.checkReference("smoke test "+name, parts[0], parts[1]))
+	}
+
+	return errs.Aggregate()
+}
+
+func (s *Stack) validateComputeUnit(prefix string, c *ComputeUnit) []error {
+	errs := []error{}
+	if _, err := c.Platform(); err != nil {
+		errs = append(errs, fmt.Errorf("%s: %v", prefix, err))
+	}
+	switch c.Visibility {
+	case "", VisibilityPublic, VisibilityInternal:
+	default:
+		errs = append(errs, fmt.Errorf("%s: invalid visibility %s, must be %s or %s", prefix, c.Visibility, VisibilityPublic, VisibilityInternal))
+	}
+	if c.Memory < 0 || c.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("%s: memory and timeout can't be negative", prefix))
+	}
+	if c.CPU < 0 || math.IsNaN(c.CPU) || math.IsInf(c.CPU, 0) {
+		errs = append(errs, fmt.Errorf("%s: invalid cpu %v, must be a positive number of vCPUs (e.g. 0.5)", prefix, c.CPU))
+	}
+	if c.MaxScale > 0 && c.MinScale > c.MaxScale {
+		errs = append(errs, fmt.Errorf("%s: minScale %d is greater than maxScale %d", prefix, c.MinScale, c.MaxScale))
+	}
+	for _, t := range c.Triggers.Topics {
+		if _, ok := s.Topics[t]; !ok {
+			errs = append(errs, fmt.Errorf("%s: subscribes to topic %s which is not declared", prefix, t))
+		}
+	}
+	for _, f := range c.Files {
+		if err := s.checkFile(prefix, "file", f.Source); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// checkFile returns an error when the file, absolute or relative to the stack, doesn't exist
+func (s *Stack) checkFile(prefix, kind, file string) error {
+	p := file
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(s.dir, file)
+	}
+	if _, err := os.Stat(p); err != nil {
+		return fmt.Errorf("%s: %s %s not found", prefix, kind, file)
+	}
+	return nil
+}
+
+// checkReference returns an error when the resource of the type isn't declared by the stack
+func (s *Stack) checkReference(prefix, kind, name string) error {
+	var ok bool
+	switch kind {
+	case "function":
+		_, ok = s.Functions[name]
+	case "container":
+		_, ok = s.Containers[name]
+	case "api":
+		_, ok = s.Apis[name]
+	case "site":
+		_, ok = s.Sites[name]
+	case "topic":
+		_, ok = s.Topics[name]
+	case "queue":
+		_, ok = s.Queues[name]
+	case "entrypoint":
+		_, ok = s.EntryPoints[name]
+	default:
+		return fmt.Errorf("%s: unknown resource type %q for %s", prefix, kind, name)
+	}
+	if !ok {
+		return fmt.Errorf("%s: %s %s is not declared", prefix, kind, name)
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of a map with string keys in order, so errors are reported in a stable order
+func sortedKeys(m interface{}) []string {
+	keys := []string{}
+	for _, k := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,149 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "orders.ts"), []byte(""), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// files mounted from outside of the stack
+	other := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(other, "ca.pem"), []byte(""), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		stack   Stack
+		wantErr []string
+	}{
+		{
+			name: "valid",
+			stack: Stack{
+				Functions: map[string]Function{"orders": {Handler: "orders.ts", ComputeUnit: ComputeUnit{Triggers: Triggers{Topics: []string{"created"}}}}},
+				Topics:    map[string]Topic{"created": {}},
+				Schedules: map[string]Schedule{"nightly": {Expression: "daily", Target: ScheduleTarget{Type: "topic", Name: "created"}}},
+			},
+		},
+		{
+			name: "absolute file mount",
+			stack: Stack{
+				Functions: map[string]Function{"orders": {Handler: "orders.ts", ComputeUnit: ComputeUnit{Files: []FileMount{{Source: filepath.Join(other, "ca.pem"), Target: "/etc/ssl/ca.pem"}}}}},
+			},
+		},
+		{
+			name: "missing file mounts",
+			stack: Stack{
+				Functions: map[string]Function{"orders": {Handler: "orders.ts", ComputeUnit: ComputeUnit{Files: []FileMount{
+					{Source: "ca.pem", Target: "/etc/ssl/ca.pem"},
+					{Source: filepath.Join(other, "key.pem"), Target: "/etc/ssl/key.pem"},
+				}}}},
+			},
+			wantErr: []string{
+				"function orders: file ca.pem not found",
+				"function orders: file " + filepath.Join(other, "key.pem") + " not found",
+			},
+		},
+		{
+			name: "missing handler and undeclared topic",
+			stack: Stack{
+				Functions: map[string]Function{"orders": {Handler: "order.ts", ComputeUnit: ComputeUnit{Triggers: Triggers{Topics: []string{"created"}}}}},
+			},
+			wantErr: []string{
+				"function orders: handler order.ts not found",
+				"function orders: subscribes to topic created which is not declared",
+			},
+		},
+		{
+			name: "invalid schedule and entrypoint target",
+			stack: Stack{
+				Schedules:   map[string]Schedule{"nightly": {Expression: "sometimes", Target: ScheduleTarget{Type: "topic", Name: "created"}}},
+				EntryPoints: map[string]Entrypoint{"main": {Paths: map[string]EntrypointPath{"/": {Type: "function", Target: "orders"}}}},
+			},
+			wantErr: []string{
+				"schedule nightly: invalid schedule expression",
+				"schedule nightly: topic created is not declared",
+				"entrypoint main path /: function orders is not declared",
+			},
+		},
+		{
+			name: "name collision and invalid settings",
+			stack: Stack{
+				Functions:   map[string]Function{"orders": {Handler: "orders.ts", ComputeUnit: ComputeUnit{Visibility: "private"}}},
+				Containers:  map[string]Container{"orders": {Dockerfile: "Dockerfile", ComputeUnit: ComputeUnit{MinScale: 2, MaxScale: 1}}},
+				ApiPolicies: map[string]ApiPolicy{"main": {RateLimit: &RateLimit{}}},
+			},
+			wantErr: []string{
+				"function orders: invalid visibility private",
+				"container orders: the name is also used by a function",
+				"container orders: dockerfile Dockerfile not found",
+				"container orders: minScale 2 is greater than maxScale 1",
+				"api policy main: api main is not declared",
+				"api policy main: rateLimit requestsPerSecond must be greater than 0",
+			},
+		},
+		{
+			name: "invalid cpu and memory",
+			stack: Stack{
+				Functions: map[string]Function{
+					"orders":   {Handler: "orders.ts", ComputeUnit: ComputeUnit{CPU: -0.5, Memory: -128}},
+					"payments": {Handler: "orders.ts", ComputeUnit: ComputeUnit{CPU: math.NaN()}},
+					"users":    {Handler: "orders.ts", ComputeUnit: ComputeUnit{CPU: math.Inf(1)}},
+				},
+			},
+			wantErr: []string{
+				"function orders: memory and timeout can't be negative",
+				"function orders: invalid cpu -0.5",
+				"function payments: invalid cpu NaN",
+				"function users: invalid cpu +Inf",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.stack.dir = dir
+			err := tt.stack.Validate()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Validate() expected an error")
+			}
+			lines := strings.Split(err.Error(), "\n")
+			if len(lines) != len(tt.wantErr) {
+				t.Fatalf("Validate() returned %d errors, want %d:\n%v", len(lines), len(tt.wantErr), err)
+			}
+			for i, want := range tt.wantErr {
+				if !strings.HasPrefix(lines[i], want) {
+					t.Errorf("Validate() error %d = %s, want %s", i, lines[i], want)
+				}
+			}
+		})
+	}
+}
@@ -17,6 +17,8 @@
 package target
 
 import (
+	"fmt"
+
 	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -24,6 +26,9 @@ import (
 	"github.com/nitrictech/newcli/pkg/pflagext"
 )
 
+// Providers that can be deployed to
+var Providers = []string{"local", "aws", "azure", "gcp", "digitalocean"}
+
 var (
 	target   string
 	name     string
@@ -53,6 +58,22 @@ func FromOptions() *Target {
 	return &t
 }
 
+// FromName returns the named target of the configuration, local is always available
+func FromName(targetName string) (*Target, error) {
+	targets := map[string]Target{}
+	if err := mapstructure.Decode(viper.GetStringMap("targets"), &targets); err != nil {
+		return nil, err
+	}
+	t, ok := targets[targetName]
+	if !ok {
+		if targetName == "local" {
+			return &Target{Name: "local", Provider: "local"}, nil
+		}
+		return nil, fmt.Errorf("target %s is not configured, see 'nitric target list'", targetName)
+	}
+	return &t, nil
+}
+
 func AddOptions(cmd *cobra.Command, providerOnly bool) {
 	targetsMap := viper.GetStringMap("targets")
 	targets := []string{}
@@ -65,10 +86,9 @@ func AddOptions(cmd *cobra.Command, providerOnly bool) {
 		return targets, cobra.ShellCompDirectiveDefault
 	})
 
-	providers := []string{"local", "aws", "azure", "gcp", "digitalocean"}
-	cmd.Flags().VarP(pflagext.NewStringEnumVar(&provider, providers, "local"), "provider", "p", "the provider to deploy to")
+	cmd.Flags().VarP(pflagext.NewStringEnumVar(&provider, Providers, "local"), "provider", "p", "the provider to deploy to")
 	cmd.RegisterFlagCompletionFunc("provider", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		return providers, cobra.ShellCompDirectiveDefault
+		return Providers, cobra.ShellCompDirectiveDefault
 	})
 
 	if !providerOnly {
@@ -20,4 +20,32 @@ type Target struct {
 	Name     string `json:"name,omitempty"`
 	Provider string `json:"provider,omitempty"`
 	Region   string `json:"region,omitempty"`
+
+	// Production targets are protected, deploying to or deleting from them requires a clean
+	// git state, a preview of the change and typing the stack's name to confirm it
+	Production bool `json:"production,omitempty"`
+
+	// Approval, when set, posts each deployment to a webhook and waits for it to be approved
+	Approval *Approval `json:"approval,omitempty"`
+}
+
+type Approval struct {
+	// Webhook the deployment plan is posted to, e.g. a Slack webhook or an approval service.
+	// Webhook URLs often carry credentials so, like the secret, they are left out of the output of targets
+	Webhook string `json:"-" yaml:"-"`
+	// StatusURL is polled with ?id=<request id> until the request is approved or rejected
+	StatusURL string `json:"statusUrl"`
+	// Secret shared with the approval service, approvals must carry the HMAC-SHA256 of the request id
+	// keyed by it as their token. Env vars are expanded, e.g. ${APPROVAL_SECRET}
+	Secret string `json:"-" yaml:"-"`
+	// Timeout for a decision as a duration, e.g. 30m, defaults to an hour
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// String summarises the approval for the targets table without its webhook or secret
+func (a *Approval) String() string {
+	if a == nil {
+		return "-"
+	}
+	return "webhook"
 }
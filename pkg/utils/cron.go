@@ -0,0 +1,331 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var weekdays = map[string]int{
+	"sunday":    0,
+	"monday":    1,
+	"tuesday":   2,
+	"wednesday": 3,
+	"thursday":  4,
+	"friday":    5,
+	"saturday":  6,
+}
+
+// ExpressionToCron - Converts a schedule expression into a crontab expression
+// and the location it should be evaluated in (UTC unless a time zone is given).
+//
+// Supported expressions are
+//   - crontab expressions, e.g. "*/5 * * * *"
+//   - rate expressions, e.g. "5 minutes" or "every 5 minutes"
+//   - "every minute", "every hour", "every day", "hourly", "daily" and "weekly"
+//   - "daily at 02:00" and "weekly on monday at 02:00"
+//
+// optionally followed by a time zone name, e.g. "daily at 02:00 Australia/Sydney"
+func ExpressionToCron(exp string) (string, *time.Location, error) {
+	fields := strings.Fields(exp)
+	if len(fields) == 0 {
+		return "", nil, fmt.Errorf("invalid schedule expression %q; expression is empty", exp)
+	}
+
+	loc := time.UTC
+	// time zone names (e.g. Australia/Sydney) can be told apart from crontab fields (e.g. */5) by their first character
+	if last := fields[len(fields)-1]; last == "UTC" || (strings.Contains(last, "/") && !isCronField(last)) {
+		l, err := time.LoadLocation(last)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid schedule expression %q; unknown time zone %s", exp, last)
+		}
+		loc = l
+		fields = fields[:len(fields)-1]
+	}
+
+	if len(fields) == 5 && isCronField(fields[0]) {
+		cron := strings.Join(fields, " ")
+		if _, err := parseCron(cron); err != nil {
+			return "", nil, fmt.Errorf("invalid schedule expression %q; %v", exp, err)
+		}
+		return cron, loc, nil
+	}
+
+	cron, err := friendlyToCron(strings.ToLower(strings.Join(fields, " ")))
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid schedule expression %q; %v", exp, err)
+	}
+	return cron, loc, nil
+}
+
+func friendlyToCron(exp string) (string, error) {
+	fields := strings.Fields(exp)
+	if fields[0] == "every" {
+		fields = fields[1:]
+		if len(fields) == 1 {
+			// every minute, every hour, every day
+			fields = []string{"1", fields[0]}
+		}
+	}
+
+	switch {
+	case len(fields) == 2 && isNumber(fields[0]):
+		unit := fields[1]
+		if !strings.HasSuffix(unit, "s") {
+			unit = unit + "s"
+		}
+		return stepRateToCron(fields[0] + " " + unit)
+	case len(fields) == 1 && fields[0] == "hourly":
+		return "0 * * * *", nil
+	case len(fields) == 1 && fields[0] == "daily":
+		return "0 0 * * *", nil
+	case len(fields) == 1 && fields[0] == "weekly":
+		return "0 0 * * 0", nil
+	case len(fields) == 3 && fields[0] == "daily" && fields[1] == "at":
+		hour, minute, err := parseTimeOfDay(fields[2])
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d %d * * *", minute, hour), nil
+	case (len(fields) == 3 || len(fields) == 5) && fields[0] == "weekly" && fields[1] == "on":
+		day, ok := weekdays[fields[2]]
+		if !ok {
+			return "", fmt.Errorf("%s is not a day of the week", fields[2])
+		}
+		hour, minute := 0, 0
+		if len(fields) == 5 {
+			if fields[3] != "at" {
+				return "", fmt.Errorf("expected 'at' but found %s", fields[3])
+			}
+			var err error
+			hour, minute, err = parseTimeOfDay(fields[4])
+			if err != nil {
+				return "", err
+			}
+		}
+		return fmt.Sprintf("%d %d * * %d", minute, hour, day), nil
+	default:
+		return "", fmt.Errorf("expression not recognised")
+	}
+}
+
+// stepRateToCron converts a rate the way RateToCron does, but only accepts rates
+// that crontab steps can express. Steps restart at the start of each hour (or day),
+// so rates must divide the hour or day evenly, whole multiples are converted to the
+// larger unit (e.g. 120 minutes is every 2 hours)
+func stepRateToCron(rate string) (string, error) {
+	rateParts := strings.Split(rate, " ")
+	num, err := strconv.Atoi(rateParts[0])
+	if err != nil {
+		return "", fmt.Errorf("invalid rate expression %s; %v", rate, err)
+	}
+	if num < 1 {
+		return "", fmt.Errorf("invalid rate expression %s; the rate must be at least 1", rate)
+	}
+
+	switch rateParts[1] {
+	case "minutes":
+		if num%60 == 0 {
+			return stepRateToCron(fmt.Sprintf("%d hours", num/60))
+		}
+		if 60%num != 0 {
+			return "", fmt.Errorf("invalid rate expression %s; minutes must divide an hour evenly (e.g. 15 or 20), use a crontab expression for other schedules", rate)
+		}
+	case "hours":
+		if num%24 == 0 {
+			return stepRateToCron(fmt.Sprintf("%d days", num/24))
+		}
+		if 24%num != 0 {
+			return "", fmt.Errorf("invalid rate expression %s; hours must divide a day evenly (e.g. 6 or 8), use a crontab expression for other schedules", rate)
+		}
+	case "days":
+		switch num {
+		case 1:
+		case 7:
+			// Midnight every sunday
+			return "0 0 * * 0", nil
+		default:
+			// months don't divide evenly into any other number of days
+			return "", fmt.Errorf("invalid rate expression %s; days must be 1 or 7, use a crontab expression for other schedules", rate)
+		}
+	}
+
+	return RateToCron(rate)
+}
+
+func isCronField(s string) bool {
+	return strings.ContainsAny(s[:1], "0123456789*")
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+// parseTimeOfDay parses a 24 hour time, e.g. 02:00 or 17:30
+func parseTimeOfDay(s string) (int, int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s is not a valid time, use the 24 hour format HH:MM", s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+type cronSchedule struct {
+	minutes  map[int]bool
+	hours    map[int]bool
+	days     map[int]bool
+	months   map[int]bool
+	weekdays map[int]bool
+	// standard cron behaviour, when both days and weekdays are restricted either may match
+	anyDay     bool
+	anyWeekday bool
+}
+
+// parseCron parses a five field crontab expression (minute hour day-of-month month day-of-week)
+func parseCron(cron string) (*cronSchedule, error) {
+	fields := strings.Fields(cron)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("crontab expression must have 5 fields, found %d", len(fields))
+	}
+
+	bounds := []struct {
+		name     string
+		min, max int
+	}{
+		{"minute", 0, 59},
+		{"hour", 0, 23},
+		{"day of month", 1, 31},
+		{"month", 1, 12},
+		{"day of week", 0, 7},
+	}
+
+	sets := make([]map[int]bool, 5)
+	for i, f := range fields {
+		set, err := parseCronField(f, bounds[i].min, bounds[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s field %s; %v", bounds[i].name, f, err)
+		}
+		sets[i] = set
+	}
+
+	// sunday can be written as 0 or 7
+	if sets[4][7] {
+		sets[4][0] = true
+		delete(sets[4], 7)
+	}
+
+	return &cronSchedule{
+		minutes:    sets[0],
+		hours:      sets[1],
+		days:       sets[2],
+		months:     sets[3],
+		weekdays:   sets[4],
+		anyDay:     strings.HasPrefix(fields[2], "*"),
+		anyWeekday: strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	set := map[int]bool{}
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s < 1 {
+				return nil, fmt.Errorf("invalid step %s", part[i+1:])
+			}
+			rng, step = part[:i], s
+		}
+
+		lo, hi := min, max
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			lo, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %s", bounds[0])
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				hi, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return nil, fmt.Errorf("invalid value %s", bounds[1])
+				}
+			} else if step > 1 {
+				// e.g. 5/15 means every 15 starting at 5
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("%s is out of range %d-%d", rng, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+func (c *cronSchedule) matchesDay(t time.Time) bool {
+	day := c.days[t.Day()]
+	weekday := c.weekdays[int(t.Weekday())]
+	if c.anyDay || c.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
+
+// NextCronTimes - Returns the next n times (after from) that the crontab expression will run,
+// evaluated in the location of from
+func NextCronTimes(cron string, from time.Time, n int) ([]time.Time, error) {
+	c, err := parseCron(cron)
+	if err != nil {
+		return nil, err
+	}
+
+	times := []time.Time{}
+	loc := from.Location()
+	t := from.Truncate(time.Minute).Add(time.Minute)
+	// a valid expression can only be 4 years away from running (e.g. Feb 29th)
+	limit := t.AddDate(5, 0, 0)
+
+	for len(times) < n && t.Before(limit) {
+		switch {
+		case !c.months[int(t.Month())]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !c.hours[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case !c.minutes[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			times = append(times, t)
+			t = t.Add(time.Minute)
+		}
+	}
+
+	if len(times) == 0 {
+		return nil, fmt.Errorf("crontab expression %s never runs", cron)
+	}
+	return times, nil
+}
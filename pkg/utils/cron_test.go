@@ -0,0 +1,113 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExpressionToCron(t *testing.T) {
+	tests := []struct {
+		exp      string
+		wantCron string
+		wantLoc  string
+		wantErr  bool
+	}{
+		{exp: "*/5 * * * *", wantCron: "*/5 * * * *", wantLoc: "UTC"},
+		{exp: "5 minutes", wantCron: "*/5 * * * *", wantLoc: "UTC"},
+		{exp: "every 5 minutes", wantCron: "*/5 * * * *", wantLoc: "UTC"},
+		{exp: "every hour", wantCron: "0 */1 * * *", wantLoc: "UTC"},
+		{exp: "every 120 minutes", wantCron: "0 */2 * * *", wantLoc: "UTC"},
+		{exp: "every 24 hours", wantCron: "0 0 */1 * *", wantLoc: "UTC"},
+		{exp: "every 7 days", wantCron: "0 0 * * 0", wantLoc: "UTC"},
+		{exp: "every 90 minutes", wantErr: true},
+		{exp: "every 5 hours", wantErr: true},
+		{exp: "every 3 days", wantErr: true},
+		{exp: "every 0 minutes", wantErr: true},
+		{exp: "daily", wantCron: "0 0 * * *", wantLoc: "UTC"},
+		{exp: "daily at 02:00 Australia/Sydney", wantCron: "0 2 * * *", wantLoc: "Australia/Sydney"},
+		{exp: "Weekly on Monday at 17:30", wantCron: "30 17 * * 1", wantLoc: "UTC"},
+		{exp: "0 3 * * * UTC", wantCron: "0 3 * * *", wantLoc: "UTC"},
+		{exp: "daily at 25:00", wantErr: true},
+		{exp: "every fortnight", wantErr: true},
+		{exp: "daily Mars/Olympus", wantErr: true},
+		{exp: "61 * * * *", wantErr: true},
+		{exp: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.exp, func(t *testing.T) {
+			cron, loc, err := ExpressionToCron(tt.exp)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExpressionToCron() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if cron != tt.wantCron {
+				t.Errorf("ExpressionToCron() cron = %v, want %v", cron, tt.wantCron)
+			}
+			if loc.String() != tt.wantLoc {
+				t.Errorf("ExpressionToCron() location = %v, want %v", loc, tt.wantLoc)
+			}
+		})
+	}
+}
+
+func TestNextCronTimes(t *testing.T) {
+	from := time.Date(2022, 1, 30, 23, 58, 30, 0, time.UTC)
+	tests := []struct {
+		cron string
+		want []time.Time
+	}{
+		{
+			cron: "*/15 * * * *",
+			want: []time.Time{
+				time.Date(2022, 1, 31, 0, 0, 0, 0, time.UTC),
+				time.Date(2022, 1, 31, 0, 15, 0, 0, time.UTC),
+				time.Date(2022, 1, 31, 0, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			cron: "30 2 29 2 *",
+			want: []time.Time{
+				time.Date(2024, 2, 29, 2, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			// day of month or day of week when both are restricted
+			cron: "0 9 1 * 2",
+			want: []time.Time{
+				time.Date(2022, 2, 1, 9, 0, 0, 0, time.UTC),
+				time.Date(2022, 2, 8, 9, 0, 0, 0, time.UTC),
+				time.Date(2022, 2, 15, 9, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cron, func(t *testing.T) {
+			got, err := NextCronTimes(tt.cron, from, len(tt.want))
+			if err != nil {
+				t.Fatalf("NextCronTimes() error = %v", err)
+			}
+			if !reflect.DeepEqual(tt.want, got) {
+				t.Errorf("NextCronTimes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadEnvFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "plain",
+			content: "A=1\nB=two words\n",
+			want:    map[string]string{"A": "1", "B": "two words"},
+		},
+		{
+			name:    "comments, export and quotes",
+			content: "# comment\n\nexport A=\"quoted # value\"\nB='single'\nC=\n",
+			want:    map[string]string{"A": "quoted # value", "B": "single", "C": ""},
+		},
+		{
+			name:    "missing value",
+			content: "A\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), ".env")
+			if err := ioutil.WriteFile(file, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadEnvFile(file)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadEnvFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadEnvFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	os.Setenv("NITRIC_TEST_HOST", "host")
+	defer os.Unsetenv("NITRIC_TEST_HOST")
+
+	tests := []struct {
+		name string
+		in   string
+		vars map[string]string
+		want string
+	}{
+		{name: "host env", in: "${NITRIC_TEST_HOST}/path", want: "host/path"},
+		{name: "vars first", in: "$NITRIC_TEST_HOST", vars: map[string]string{"NITRIC_TEST_HOST": "file"}, want: "file"},
+		{name: "unset", in: "a${NITRIC_TEST_UNSET}b", want: "ab"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExpandEnv(tt.in, tt.vars); got != tt.want {
+				t.Errorf("ExpandEnv() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type GitInfo struct {
+	Commit    string
+	Branch    string
+	RemoteURL string
+	// Dirty is set when there are uncommitted changes
+	Dirty bool
+}
+
+// GitMetadata returns the git commit and the state of dir, an error is returned when dir is not in a git repository
+func GitMetadata(dir string) (*GitInfo, error) {
+	commit, err := git(dir, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	// not all repositories have a remote, and a detached HEAD has no branch
+	remote, _ := git(dir, "config", "--get", "remote.origin.url")
+	branch, _ := git(dir, "rev-parse", "--abbrev-ref", "HEAD")
+	if branch == "HEAD" {
+		branch = ""
+	}
+	// only the changes to dir make it dirty, not those of other projects in the repository
+	status, err := git(dir, "status", "--porcelain", "--", ".")
+	if err != nil {
+		return nil, err
+	}
+
+	return &GitInfo{
+		Commit:    commit,
+		Branch:    branch,
+		RemoteURL: remote,
+		Dirty:     status != "",
+	}, nil
+}
+
+// Labels returns the commit, branch and dirty flag as labels (or tags) with the given prefix,
+// so resources can be traced back to the commit they were deployed from
+func (g *GitInfo) Labels(prefix string) map[string]string {
+	labels := map[string]string{
+		prefix + "commit": g.Commit,
+		prefix + "dirty":  strconv.FormatBool(g.Dirty),
+	}
+	if g.Branch != "" {
+		labels[prefix+"branch"] = g.Branch
+	}
+	return labels
+}
+
+// GitChangedFiles returns the absolute paths of the files changed since the commit where HEAD branched from ref,
+// including uncommitted and untracked files, e.g. the files changed by a pull request against main
+func GitChangedFiles(dir, ref string) ([]string, error) {
+	root, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	base, err := git(dir, "merge-base", ref, "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("no common ancestor of %s and HEAD, is %s fetched?", ref, ref)
+	}
+	diff, err := git(dir, "diff", "--name-only", base)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git(dir, "ls-files", "--others", "--exclude-standard", "--full-name")
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{}
+	for _, f := range strings.Split(diff+"\n"+untracked, "\n") {
+		if f != "" {
+			files = append(files, filepath.Join(root, filepath.FromSlash(f)))
+		}
+	}
+	return files, nil
+}
+
+func git(dir string, args ...string) (string, error) {
+	out := &bytes.Buffer{}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = out
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.String()), nil
+}
@@ -0,0 +1,98 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGitInfoLabels(t *testing.T) {
+	tests := []struct {
+		name string
+		git  GitInfo
+		want map[string]string
+	}{
+		{
+			name: "branch",
+			git:  GitInfo{Commit: "abc", Branch: "main"},
+			want: map[string]string{"git.commit": "abc", "git.branch": "main", "git.dirty": "false"},
+		},
+		{
+			name: "detached and dirty",
+			git:  GitInfo{Commit: "abc", Dirty: true},
+			want: map[string]string{"git.commit": "abc", "git.dirty": "true"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.git.Labels("git."); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Labels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitMetadataDirty(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := t.TempDir()
+	stackDir := filepath.Join(repo, "stack")
+	if err := os.Mkdir(stackDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(stackDir, "nitric.yaml"), []byte("name: test\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init"},
+	} {
+		if _, err := git(repo, args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		file string
+		want bool
+	}{
+		{name: "change outside of the stack", file: filepath.Join(repo, "README.md"), want: false},
+		{name: "change to the stack", file: filepath.Join(stackDir, "main.ts"), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ioutil.WriteFile(tt.file, []byte(""), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			info, err := GitMetadata(stackDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Dirty != tt.want {
+				t.Errorf("GitMetadata() dirty = %v, want %v", info.Dirty, tt.want)
+			}
+		})
+	}
+}
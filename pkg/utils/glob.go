@@ -0,0 +1,143 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// IsGlob is true when the pattern contains wildcards
+func IsGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// Glob returns the files under dir matching the slash separated pattern, relative to dir. In addition to the
+// wildcards of filepath.Match, ** matches any number of directories, e.g. services/**/handler.ts
+// Dependency and VCS directories (node_modules, .git and .nitric) are never searched.
+func Glob(dir, pattern string) ([]string, error) {
+	parts := strings.Split(path.Clean(filepath.ToSlash(pattern)), "/")
+	matches := []string{}
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			switch info.Name() {
+			case "node_modules", ".git", ".nitric":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		ok, err := matchParts(parts, strings.Split(filepath.ToSlash(rel), "/"))
+		if err != nil {
+			return err
+		}
+		if ok {
+			matches = append(matches, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// MatchGlob is true when the slash separated path matches the pattern, which supports ** as Glob does
+func MatchGlob(pattern, name string) (bool, error) {
+	return matchParts(strings.Split(path.Clean(filepath.ToSlash(pattern)), "/"), strings.Split(path.Clean(filepath.ToSlash(name)), "/"))
+}
+
+func matchParts(pattern, name []string) (bool, error) {
+	if len(pattern) == 0 {
+		return len(name) == 0, nil
+	}
+	if pattern[0] == "**" {
+		// ** matches zero or more directories
+		for i := 0; i <= len(name); i++ {
+			ok, err := matchParts(pattern[1:], name[i:])
+			if ok || err != nil {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+	if len(name) == 0 {
+		return false, nil
+	}
+	ok, err := path.Match(pattern[0], name[0])
+	if !ok || err != nil {
+		return false, err
+	}
+	return matchParts(pattern[1:], name[1:])
+}
+
+// HandlerNames names the function of each handler after its file name, e.g. functions/orders.ts is orders.
+// When handlers share a file name (e.g. functions/*/main.go) they are named after their directory instead,
+// or their whole directory path when that is shared as well. Handlers in the same directory with the same
+// file name and different extensions (e.g. a/main.ts and a/main.js) can't be told apart, so they are an error.
+func HandlerNames(handlers []string) (map[string]string, error) {
+	namers := []func(string) string{
+		func(p string) string {
+			return strings.TrimSuffix(filepath.Base(p), filepath.Ext(p))
+		},
+		func(p string) string {
+			return filepath.Base(filepath.Dir(p))
+		},
+		func(p string) string {
+			return strings.ReplaceAll(filepath.ToSlash(filepath.Dir(p)), "/", "-")
+		},
+	}
+
+	names := map[string]string{}
+	remaining := handlers
+	for i, namer := range namers {
+		counts := map[string]int{}
+		for _, h := range remaining {
+			counts[namer(h)]++
+		}
+		clashes := []string{}
+		for _, h := range remaining {
+			// the last namer is unique unless the handlers are in the same directory
+			if counts[namer(h)] > 1 && i < len(namers)-1 {
+				clashes = append(clashes, h)
+				continue
+			}
+			names[h] = namer(h)
+		}
+		remaining = clashes
+	}
+
+	handlersByName := map[string]string{}
+	for _, h := range handlers {
+		if other, ok := handlersByName[names[h]]; ok && other != h {
+			return nil, fmt.Errorf("handlers %s and %s would both be named %s, move one of them to its own directory", other, h, names[h])
+		}
+		handlersByName[names[h]] = h
+	}
+	return names, nil
+}
@@ -0,0 +1,118 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGlob(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, f := range []string{
+		"functions/orders/main.go",
+		"functions/users/main.go",
+		"functions/users/util.go",
+		"services/a/handler.ts",
+		"services/b/c/handler.ts",
+		"services/handler.ts",
+		"node_modules/x/handler.ts",
+	} {
+		p := filepath.Join(dir, f)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte{}, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{
+			pattern: "functions/*/main.go",
+			want:    []string{"functions/orders/main.go", "functions/users/main.go"},
+		},
+		{
+			pattern: "services/**/handler.ts",
+			want:    []string{"services/a/handler.ts", "services/b/c/handler.ts", "services/handler.ts"},
+		},
+		{
+			pattern: "**/*.ts",
+			want:    []string{"services/a/handler.ts", "services/b/c/handler.ts", "services/handler.ts"},
+		},
+		{
+			pattern: "functions/users/util.go",
+			want:    []string{"functions/users/util.go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, err := Glob(dir, tt.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Glob() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlerNames(t *testing.T) {
+	got, err := HandlerNames([]string{
+		"functions/orders/main.go",
+		"functions/users/main.go",
+		"functions/list.ts",
+		"a/api/handler.ts",
+		"b/api/handler.ts",
+	})
+	want := map[string]string{
+		"functions/orders/main.go": "orders",
+		"functions/users/main.go":  "users",
+		"functions/list.ts":        "list",
+		"a/api/handler.ts":         "a-api",
+		"b/api/handler.ts":         "b-api",
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("HandlerNames() = %v, want %v", got, want)
+	}
+}
+
+func TestHandlerNamesCollision(t *testing.T) {
+	for _, handlers := range [][]string{
+		{"a/main.ts", "a/main.js"},
+		{"functions/orders.ts", "functions/orders/main.go", "services/main.go"},
+	} {
+		if got, err := HandlerNames(handlers); err == nil {
+			t.Errorf("HandlerNames(%v) = %v, want an error for the names that collide", handlers, got)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"regexp"
+)
+
+type errorHint struct {
+	match *regexp.Regexp
+	hint  string
+}
+
+// errorHints turn common errors of the container engine, registries and cloud CLIs
+// into the steps that fix them, the first match is used
+var errorHints = []errorHint{
+	{
+		regexp.MustCompile(`(?i)cannot connect to the docker daemon|docker daemon is not running|error during connect`),
+		"start docker (or podman) and check it is running with 'docker info', then run 'nitric doctor'",
+	},
+	{
+		regexp.MustCompile(`(?i)permission denied.*docker\.sock`),
+		"add your user to the docker group with 'sudo usermod -aG docker $USER' and log in again",
+	},
+	{
+		regexp.MustCompile(`(?i)none of podman, docker or nerdctl found`),
+		"install docker (https://docs.docker.com/get-docker/), podman or nerdctl, then run 'nitric doctor'",
+	},
+	{
+		regexp.MustCompile(`(?i)address already in use|port is already allocated`),
+		"another process is using the port, stop it (find it with 'lsof -i :<port>') or a previous 'nitric run' that is still running",
+	},
+	{
+		regexp.MustCompile(`(?i)'buildx' is not a docker command|unknown command "?buildx`),
+		"build caches need docker buildx, install it (https://docs.docker.com/buildx/working-with-buildx/) or remove --cache-from and --cache-to",
+	},
+	{
+		regexp.MustCompile(`(?i)no such image|image .* not found`),
+		"build the stack's images with 'nitric build create' first",
+	},
+	{
+		regexp.MustCompile(`(?i)registry login with '(aws|gcloud|az) [^']*'.*executable file not found`),
+		"pushing needs the ${1} cli to log in to the registry, install it and log in, or push to a registry that uses 'docker login'",
+	},
+	{
+		regexp.MustCompile(`(?i)exec: "cosign": executable file not found`),
+		"provenance attestations are signed and pushed with cosign, install it (https://docs.sigstore.dev/cosign/installation/)",
+	},
+	{
+		regexp.MustCompile(`(?i)pull access denied|unauthorized: authentication required|denied: requested access to the resource is denied`),
+		"log in to the registry with 'docker login <registry>'",
+	},
+	{
+		regexp.MustCompile(`(?i)AccessDenied.*ecr:GetAuthorizationToken|ecr:GetAuthorizationToken.*AccessDenied`),
+		"the AWS identity can't log in to ECR, attach the AmazonEC2ContainerRegistryPowerUser policy to it, check it with 'aws sts get-caller-identity'",
+	},
+	{
+		regexp.MustCompile(`(?i)no basic auth credentials|authorization token has expired`),
+		"log in to the registry again, for ECR 'aws ecr get-login-password | docker login --username AWS --password-stdin <account>.dkr.ecr.<region>.amazonaws.com'",
+	},
+	{
+		regexp.MustCompile(`(?i)MissingSubscriptionRegistration.*namespace '([^']+)'`),
+		"register the resource provider with 'az provider register --namespace ${1}'",
+	},
+	{
+		regexp.MustCompile(`(?i)MissingSubscriptionRegistration`),
+		"register the resource provider named in the error with 'az provider register --namespace <namespace>'",
+	},
+	{
+		regexp.MustCompile(`(?i)(\S+\.googleapis\.com) (has not been used|is disabled|api not enabled)`),
+		"enable the API with 'gcloud services enable ${1}'",
+	},
+	{
+		regexp.MustCompile(`(?i)api (has not been used|is not enabled|not enabled)`),
+		"enable the API named in the error with 'gcloud services enable <api>.googleapis.com'",
+	},
+}
+
+// ErrorHint - Returns the steps that fix a common error, or an empty string when there are none
+func ErrorHint(err error) string {
+	if err == nil {
+		return ""
+	}
+	msg := err.Error()
+	for _, h := range errorHints {
+		if m := h.match.FindStringSubmatchIndex(msg); m != nil {
+			return string(h.match.ExpandString(nil, h.hint, msg, m))
+		}
+	}
+	return ""
+}
+
+type hintedError struct {
+	err  error
+	hint string
+}
+
+func (e *hintedError) Error() string {
+	return e.err.Error() + "\nhint: " + e.hint
+}
+
+func (e *hintedError) Cause() error  { return e.err }
+func (e *hintedError) Unwrap() error { return e.err }
+
+// WithHint - Adds the steps that fix a common error to its message, other errors are returned as they are
+func WithHint(err error) error {
+	hint := ErrorHint(err)
+	if hint == "" {
+		return err
+	}
+	return &hintedError{err: err, hint: hint}
+}
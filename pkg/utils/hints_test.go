@@ -0,0 +1,92 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestErrorHint(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "docker not running",
+			err:  errors.New("Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?"),
+			want: "start docker",
+		},
+		{
+			name: "azure provider registration",
+			err:  errors.New("code=\"MissingSubscriptionRegistration\" message=\"The subscription is not registered to use namespace 'Microsoft.App'.\""),
+			want: "az provider register --namespace Microsoft.App",
+		},
+		{
+			name: "gcp api not enabled",
+			err:  errors.New("Error 403: Cloud Run Admin API has not been used in project 123 before or it is disabled: run.googleapis.com has not been used"),
+			want: "gcloud services enable run.googleapis.com",
+		},
+		{
+			name: "ecr access denied",
+			err:  errors.New("AccessDeniedException: User: arn:aws:iam::123:user/ci is not authorized to perform: ecr:GetAuthorizationToken"),
+			want: "AmazonEC2ContainerRegistryPowerUser",
+		},
+		{
+			name: "registry login cli missing",
+			err:  errors.New("registry login with 'gcloud auth print-access-token': : exec: \"gcloud\": executable file not found in $PATH"),
+			want: "needs the gcloud cli",
+		},
+		{
+			name: "unknown",
+			err:  errors.New("something else"),
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ErrorHint(tt.err)
+			if tt.want == "" && got != "" {
+				t.Errorf("ErrorHint() = %s, want no hint", got)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("ErrorHint() = %s, want it to contain %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithHint(t *testing.T) {
+	err := errors.New("listen tcp :9001: bind: address already in use")
+	got := WithHint(err)
+	if !strings.HasPrefix(got.Error(), err.Error()+"\nhint: ") {
+		t.Errorf("WithHint() = %s", got)
+	}
+	if !errors.Is(got, err) {
+		t.Error("WithHint() should wrap the error")
+	}
+
+	other := errors.New("other")
+	if WithHint(other) != other {
+		t.Error("WithHint() should return errors without a hint as they are")
+	}
+	if WithHint(nil) != nil {
+		t.Error("WithHint(nil) should be nil")
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import "testing"
+
+func TestRateToCron(t *testing.T) {
+	tests := []struct {
+		rate    string
+		want    string
+		wantErr bool
+	}{
+		{rate: "5 minutes", want: "*/5 * * * *"},
+		{rate: "7 minutes", want: "*/7 * * * *"},
+		{rate: "5 hours", want: "0 */5 * * *"},
+		{rate: "3 days", want: "0 0 */3 * *"},
+		{rate: "five minutes", wantErr: true},
+		{rate: "5 weeks", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rate, func(t *testing.T) {
+			got, err := RateToCron(tt.rate)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RateToCron() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("RateToCron() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
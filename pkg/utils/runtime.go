@@ -19,6 +19,8 @@ package utils
 import (
 	"errors"
 	"fmt"
+	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 )
@@ -31,6 +33,8 @@ const (
 	RuntimePython     Runtime = "python"
 	RuntimeGolang     Runtime = "go"
 	RuntimeJava       Runtime = "java"
+	// .NET functions use their project file as the handler
+	RuntimeDotnet Runtime = "csproj"
 
 	RuntimeUnknown Runtime = ""
 )
@@ -46,6 +50,8 @@ func NewRunTimeFromFilename(file string) (Runtime, error) {
 		return RuntimePython, nil
 	case RuntimeTypescript:
 		return RuntimeTypescript, nil
+	case RuntimeDotnet:
+		return RuntimeDotnet, nil
 	default:
 		return RuntimeUnknown, errors.New("runtime '" + string(rt) + "' not supported")
 	}
@@ -58,3 +64,36 @@ func (r Runtime) String() string {
 func (r Runtime) DevImageName() string {
 	return fmt.Sprintf("nitric-%s-dev", r)
 }
+
+// NativeCommand returns the command that runs the handler directly with the host's toolchain from dir,
+// false is returned when the runtime can't run natively or its toolchain isn't installed
+func NativeCommand(dir, handler string) ([]string, bool) {
+	rt, err := NewRunTimeFromFilename(handler)
+	if err != nil {
+		return nil, false
+	}
+
+	var cmd []string
+	switch rt {
+	case RuntimeJavascript:
+		cmd = []string{"node", handler}
+	case RuntimeTypescript:
+		if _, err := exec.LookPath("ts-node"); err == nil {
+			return []string{"ts-node", "-T", handler}, true
+		}
+		// npx would otherwise download ts-node on every start, so it's only used for a project's own install
+		if _, err := os.Stat(filepath.Join(dir, "node_modules", ".bin", "ts-node")); err != nil {
+			return nil, false
+		}
+		cmd = []string{"npx", "ts-node", "-T", handler}
+	case RuntimeGolang:
+		cmd = []string{"go", "run", handler}
+	default:
+		return nil, false
+	}
+
+	if _, err := exec.LookPath(cmd[0]); err != nil {
+		return nil, false
+	}
+	return cmd, true
+}
@@ -0,0 +1,73 @@
+// Copyright Nitric Pty Ltd.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNativeCommandTypescript(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      []string
+		localBin  bool
+		want      []string
+		wantFound bool
+	}{
+		{name: "global ts-node", path: []string{"npx", "ts-node"}, want: []string{"ts-node", "-T", "main.ts"}, wantFound: true},
+		{name: "project ts-node", path: []string{"npx"}, localBin: true, want: []string{"npx", "ts-node", "-T", "main.ts"}, wantFound: true},
+		{name: "npx without ts-node", path: []string{"npx"}},
+		{name: "no toolchain", localBin: true},
+	}
+	old := os.Getenv("PATH")
+	defer os.Setenv("PATH", old)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bin := t.TempDir()
+			for _, name := range tt.path {
+				if err := ioutil.WriteFile(filepath.Join(bin, name), []byte("#!/bin/sh\n"), 0o700); err != nil {
+					t.Fatal(err)
+				}
+			}
+			os.Setenv("PATH", bin)
+
+			dir := t.TempDir()
+			if tt.localBin {
+				if err := os.MkdirAll(filepath.Join(dir, "node_modules", ".bin"), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := ioutil.WriteFile(filepath.Join(dir, "node_modules", ".bin", "ts-node"), []byte("#!/bin/sh\n"), 0o700); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, found := NativeCommand(dir, "main.ts")
+			if found != tt.wantFound {
+				t.Fatalf("NativeCommand() found = %v, want %v", found, tt.wantFound)
+			}
+			if !cmp.Equal(tt.want, got) {
+				t.Error(cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}